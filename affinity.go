@@ -0,0 +1,140 @@
+package portal
+
+import (
+	"fmt"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// AffinityKeyFunc 从 Contract 请求中提取会话亲和键
+//
+// 返回空字符串表示该请求没有亲和要求。
+// 亲和键仅对实现了 selector.RequestAwareSelector 的选择器（如一致性哈希选择器）生效。
+type AffinityKeyFunc func(req *types.RequestContract) string
+
+// AffinityByPromptCacheKey 使用请求的 prompt_cache_key 作为亲和键
+func AffinityByPromptCacheKey() AffinityKeyFunc {
+	return func(req *types.RequestContract) string {
+		if req == nil || req.PromptCacheKey == nil {
+			return ""
+		}
+		return *req.PromptCacheKey
+	}
+}
+
+// AffinityByUser 使用请求的 user 字段作为亲和键
+func AffinityByUser() AffinityKeyFunc {
+	return func(req *types.RequestContract) string {
+		if req == nil || req.User == nil {
+			return ""
+		}
+		return *req.User
+	}
+}
+
+// AffinityByMetadata 使用请求 metadata 中指定字段的值作为亲和键
+func AffinityByMetadata(field string) AffinityKeyFunc {
+	return func(req *types.RequestContract) string {
+		if req == nil || len(req.Metadata) == 0 {
+			return ""
+		}
+		value, ok := req.Metadata[field]
+		if !ok || value == nil {
+			return ""
+		}
+		if s, ok := value.(string); ok {
+			return s
+		}
+		return fmt.Sprint(value)
+	}
+}
+
+// AffinityFirstOf 依次尝试多个提取函数，返回第一个非空的亲和键
+func AffinityFirstOf(fns ...AffinityKeyFunc) AffinityKeyFunc {
+	return func(req *types.RequestContract) string {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			if key := fn(req); key != "" {
+				return key
+			}
+		}
+		return ""
+	}
+}
+
+// DefaultAffinityKey 返回默认的亲和键提取函数
+//
+// 优先使用 prompt_cache_key，其次使用 user。
+func DefaultAffinityKey() AffinityKeyFunc {
+	return AffinityFirstOf(AffinityByPromptCacheKey(), AffinityByUser())
+}
+
+// contractSelectOptions 根据 Contract 请求与调用选项构造通道选择选项
+//
+// 调用选项中显式指定的亲和键与预估 Token 数优先于从请求中提取的值，
+// 标签约束与从请求中提取的约束合并，同名标签以调用选项为准；
+// 请求所需的能力（图像、工具、结构化输出等）与预估提示词 Token 数由请求内容推导。
+// 未指定预估 Token 数时以预估提示词 Token 数与请求的最大输出 Token 数之和作为 TPM 限流的预扣额度。
+func (p *Portal) contractSelectOptions(req *types.RequestContract, opts *nativeOptions) []routing.SelectOption {
+	affinityKey := ""
+	if opts != nil && opts.affinityKey != "" {
+		affinityKey = opts.affinityKey
+	} else if p.affinityKey != nil {
+		affinityKey = p.affinityKey(req)
+	}
+
+	var selectOpts []routing.SelectOption
+	if affinityKey != "" {
+		selectOpts = append(selectOpts, routing.WithAffinityKey(affinityKey))
+	}
+
+	if p.labelConstraints != nil {
+		if labels, _ := p.labelConstraints(req); len(labels) > 0 {
+			selectOpts = append(selectOpts, routing.WithLabelConstraints(labels))
+		}
+	}
+	if opts != nil && len(opts.labelConstraints) > 0 {
+		selectOpts = append(selectOpts, routing.WithLabelConstraints(opts.labelConstraints))
+	}
+	if capabilities := requiredCapabilities(req); len(capabilities) > 0 {
+		selectOpts = append(selectOpts, routing.WithRequiredCapabilities(capabilities...))
+	}
+
+	promptTokens, outputTokens := 0, 0
+	if p.tokenEstimator != nil {
+		promptTokens = p.tokenEstimator(req)
+	}
+	if req.MaxOutputTokens != nil {
+		outputTokens = *req.MaxOutputTokens
+	}
+	if promptTokens > 0 || outputTokens > 0 {
+		selectOpts = append(selectOpts, routing.WithContextRequirement(promptTokens, outputTokens))
+	}
+
+	estimatedTokens := promptTokens + outputTokens
+	if opts != nil && opts.estimatedTokens > 0 {
+		estimatedTokens = opts.estimatedTokens
+	}
+	if estimatedTokens > 0 {
+		selectOpts = append(selectOpts, routing.WithEstimatedTokens(estimatedTokens))
+	}
+	return selectOpts
+}
+
+// nativeSelectOptions 根据原生调用选项构造通道选择选项
+func nativeSelectOptions(opts *nativeOptions) []routing.SelectOption {
+	var selectOpts []routing.SelectOption
+	if opts != nil && opts.affinityKey != "" {
+		selectOpts = append(selectOpts, routing.WithAffinityKey(opts.affinityKey))
+	}
+	if opts != nil && opts.estimatedTokens > 0 {
+		selectOpts = append(selectOpts, routing.WithEstimatedTokens(opts.estimatedTokens))
+	}
+	if opts != nil && len(opts.labelConstraints) > 0 {
+		selectOpts = append(selectOpts, routing.WithLabelConstraints(opts.labelConstraints))
+	}
+	return selectOpts
+}
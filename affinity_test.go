@@ -0,0 +1,106 @@
+package portal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+func TestDefaultAffinityKey_PrefersPromptCacheKey(t *testing.T) {
+	cacheKey := "cache-1"
+	user := "user-1"
+	fn := DefaultAffinityKey()
+
+	if got := fn(&types.RequestContract{PromptCacheKey: &cacheKey, User: &user}); got != cacheKey {
+		t.Fatalf("应优先使用 prompt_cache_key，actual=%q", got)
+	}
+	if got := fn(&types.RequestContract{User: &user}); got != user {
+		t.Fatalf("缺少 prompt_cache_key 时应使用 user，actual=%q", got)
+	}
+	if got := fn(&types.RequestContract{}); got != "" {
+		t.Fatalf("无可用字段时应返回空亲和键，actual=%q", got)
+	}
+}
+
+func TestAffinityByMetadata(t *testing.T) {
+	fn := AffinityByMetadata("conversation_id")
+	req := &types.RequestContract{Metadata: map[string]interface{}{"conversation_id": 42}}
+	if got := fn(req); got != "42" {
+		t.Fatalf("metadata 亲和键提取错误，actual=%q", got)
+	}
+}
+
+func TestContractSelectOptions_CallOptionOverridesExtractor(t *testing.T) {
+	user := "user-1"
+	p := &Portal{affinityKey: AffinityByUser()}
+
+	if opts := p.contractSelectOptions(&types.RequestContract{}, nil); len(opts) != 0 {
+		t.Fatalf("无亲和键时不应生成选择选项，actual=%d", len(opts))
+	}
+	if opts := p.contractSelectOptions(&types.RequestContract{User: &user}, applyNativeOptions([]NativeOption{WithAffinityKey("override")})); len(opts) != 1 {
+		t.Fatalf("应生成一个亲和选择选项，actual=%d", len(opts))
+	}
+}
+
+func TestContractSelectOptions_ReservesPromptTokens(t *testing.T) {
+	p, _ := newTestPortal(t, []routing.ModelWithEndpoint{{
+		Platform: routing.Platform{ID: 1},
+		Model:    routing.Model{ID: 10, Name: "gpt-4o", APIKeys: []routing.APIKey{{ID: 100, RateLimit: routing.RateLimitConfig{TPM: 100}}}},
+		Endpoint: routing.Endpoint{ID: 1, EndpointType: "openai", EndpointVariant: "chat_completions"},
+	}}, Config{})
+
+	prompt := strings.Repeat("hello world ", 200)
+	req := &types.RequestContract{Model: "gpt-4o", Messages: []types.Message{{Role: "user", Content: types.Content{Text: &prompt}}}}
+	if tokens := EstimatePromptTokens(req); tokens <= 100 {
+		t.Fatalf("前置条件：提示词应超过密钥 TPM，actual=%d", tokens)
+	}
+
+	// 额度充足时放行，但应按提示词预扣额度
+	ch, err := p.getContractChannel(context.Background(), req.Model, p.contractSelectOptions(req, nil)...)
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+
+	if _, err := p.getContractChannel(context.Background(), req.Model, p.contractSelectOptions(req, nil)...); !errors.IsCode(err, errors.ErrCodeRateLimitExceeded) {
+		t.Fatalf("提示词已耗尽 TPM 时应被限流，actual=%v", err)
+	}
+}
+
+func TestLabelsFromMetadata(t *testing.T) {
+	fn := DefaultLabelConstraints()
+	req := &types.RequestContract{Metadata: map[string]interface{}{"route.region": "eu", "route.zdr": true, "user_tag": "x"}}
+
+	labels, consumed := fn(req)
+	if len(labels) != 2 || labels["region"] != "eu" || labels["zdr"] != "true" {
+		t.Fatalf("应提取带前缀的 metadata 字段作为标签约束，actual=%v", labels)
+	}
+	if len(consumed) != 2 {
+		t.Fatalf("应返回承载约束的 metadata 键，actual=%v", consumed)
+	}
+	if labels, _ := fn(&types.RequestContract{}); labels != nil {
+		t.Fatalf("无 metadata 时不应产生标签约束，actual=%v", labels)
+	}
+}
+
+func TestUpstreamRequest_StripsLabelMetadata(t *testing.T) {
+	p, _ := newTestPortal(t, nil, Config{})
+	req := &types.RequestContract{Metadata: map[string]interface{}{"route.region": "eu", "user_tag": "x"}}
+
+	upstream := p.upstreamRequest(req)
+	if _, ok := upstream.Metadata["route.region"]; ok || upstream.Metadata["user_tag"] != "x" {
+		t.Fatalf("转发上游的请求应只移除标签约束字段，actual=%v", upstream.Metadata)
+	}
+	if _, ok := req.Metadata["route.region"]; !ok {
+		t.Fatal("不应修改调用方的请求")
+	}
+
+	plain := &types.RequestContract{Metadata: map[string]interface{}{"user_tag": "x"}}
+	if p.upstreamRequest(plain) != plain {
+		t.Fatal("没有标签约束字段时应直接使用原请求")
+	}
+}
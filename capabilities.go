@@ -0,0 +1,81 @@
+package portal
+
+import (
+	"strings"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// requiredCapabilities 根据 Contract 请求推导所需的通道能力
+//
+//   - 图像内容片段 -> vision
+//   - 工具定义 -> tools
+//   - json_schema 响应格式 -> json_schema
+//   - 未显式关闭的推理配置 -> reasoning
+//   - 音频内容片段或 audio 输出模态 -> audio
+func requiredCapabilities(req *types.RequestContract) []routing.Capability {
+	if req == nil {
+		return nil
+	}
+
+	var vision, audio bool
+	scan := func(parts []types.ContentPart) {
+		for _, part := range parts {
+			if part.Image != nil || strings.Contains(part.Type, "image") {
+				vision = true
+			}
+			if part.Audio != nil || strings.Contains(part.Type, "audio") {
+				audio = true
+			}
+		}
+	}
+	if req.System != nil {
+		scan(req.System.Parts)
+	}
+	for _, msg := range req.Messages {
+		scan(msg.Content.Parts)
+	}
+	for _, modality := range req.Modalities {
+		if strings.EqualFold(modality, "audio") {
+			audio = true
+		}
+	}
+
+	var capabilities []routing.Capability
+	if vision {
+		capabilities = append(capabilities, routing.CapabilityVision)
+	}
+	if len(req.Tools) > 0 {
+		capabilities = append(capabilities, routing.CapabilityTools)
+	}
+	if req.ResponseFormat != nil && (req.ResponseFormat.Type == "json_schema" || req.ResponseFormat.JSONSchema != nil) {
+		capabilities = append(capabilities, routing.CapabilityJSONSchema)
+	}
+	if reasoningRequested(req.Reasoning) {
+		capabilities = append(capabilities, routing.CapabilityReasoning)
+	}
+	if audio {
+		capabilities = append(capabilities, routing.CapabilityAudio)
+	}
+	return capabilities
+}
+
+// reasoningRequested 判断推理配置是否要求开启推理
+//
+// effort 为 none、mode 为 disabled 或 budget 为 0 视为显式关闭。
+func reasoningRequested(reasoning *types.Reasoning) bool {
+	if reasoning == nil {
+		return false
+	}
+	if reasoning.Effort != nil && strings.EqualFold(*reasoning.Effort, "none") {
+		return false
+	}
+	if reasoning.Mode != nil && strings.EqualFold(*reasoning.Mode, "disabled") {
+		return false
+	}
+	if reasoning.Budget != nil && *reasoning.Budget == 0 {
+		return false
+	}
+	return true
+}
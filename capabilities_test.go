@@ -0,0 +1,45 @@
+package portal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+func TestRequiredCapabilities(t *testing.T) {
+	url := "https://example.com/cat.png"
+	effort := "high"
+	none := "none"
+
+	req := &types.RequestContract{
+		Messages: []types.Message{{
+			Role:    "user",
+			Content: types.Content{Parts: []types.ContentPart{{Type: "image", Image: &types.Image{URL: &url}}}},
+		}},
+		Tools:          []types.Tool{{Type: "function", Function: &types.Function{Name: "lookup"}}},
+		ResponseFormat: &types.ResponseFormat{Type: "json_schema"},
+		Reasoning:      &types.Reasoning{Effort: &effort},
+		Modalities:     []string{"text", "audio"},
+	}
+	expected := []routing.Capability{
+		routing.CapabilityVision,
+		routing.CapabilityTools,
+		routing.CapabilityJSONSchema,
+		routing.CapabilityReasoning,
+		routing.CapabilityAudio,
+	}
+	if got := requiredCapabilities(req); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("能力推导错误，actual=%v", got)
+	}
+
+	plain := &types.RequestContract{
+		Messages:       []types.Message{{Role: "user"}},
+		ResponseFormat: &types.ResponseFormat{Type: "json_object"},
+		Reasoning:      &types.Reasoning{Effort: &none},
+	}
+	if got := requiredCapabilities(plain); len(got) != 0 {
+		t.Fatalf("纯文本请求不应要求额外能力，actual=%v", got)
+	}
+}
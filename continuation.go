@@ -0,0 +1,402 @@
+package portal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// continuationInstruction 不支持预填充的供应商续写时追加的用户指令
+const continuationInstruction = "Your previous response was interrupted. Continue exactly where it stopped, " +
+	"without repeating any text that was already written and without adding any preamble."
+
+// toolContinuationInstruction 已输出工具调用时追加的续写指令，参数为已完成的工具调用列表
+const toolContinuationInstruction = "Your previous response was interrupted. These tool calls were already made and must not be made again:%s\n" +
+	"Continue exactly where it stopped, without repeating any text or tool call that was already written and without adding any preamble."
+
+// prefillProviders 支持以末尾助手消息作为预填充续写的供应商
+var prefillProviders = map[string]bool{
+	"anthropic": true,
+}
+
+// streamStitcher 将流式请求的多次尝试拼接为一条连续的事件序列
+//
+// 记录已转发给客户端的助手文本与工具调用；上游在输出过程中中断时，
+// 据此构建续写请求，并将续写尝试的事件改写到原有的响应、消息与输出项上：
+//   - 丢弃客户端已收到的生命周期起始事件（message_start、response_created 等）
+//   - 续写的首个输出项并入中断的输出项，其起始事件被丢弃
+//   - output_index 与 sequence_number 由同一个 StreamIndexContext 统一分配，跨尝试保持稳定
+//
+// 工具调用的参数无法可靠地从中断处接续，因此工具调用的事件在参数输出完毕前暂存、不转发：
+// 中断时暂存的事件被丢弃，由续写尝试重新发起完整的调用。
+type streamStitcher struct {
+	index   types.StreamIndexContext
+	attempt int // 当前尝试序号，每次续写递增（0 为首次请求）
+
+	emitted   int                // 已转发的事件数
+	text      strings.Builder    // 已转发的助手文本
+	toolCalls []*partialToolCall // 已转发的工具调用
+	completed bool               // 是否已转发结束事件
+
+	started    map[types.StreamEventType]bool // 已转发的生命周期起始事件
+	responseID string
+	messageID  string
+
+	lastKey          string // 最近输出项的索引键
+	lastItemID       string
+	lastContentIndex int
+	lastClosed       bool // 最近输出项是否已结束
+
+	aliasOutput   int  // 续写尝试中并入中断输出项的原始 output_index
+	aliasResolved bool // 本次续写是否已确定 aliasOutput
+
+	held    []heldEvent // 工具调用参数输出完毕前暂存的事件
+	heldKey string      // 暂存的工具调用所属输出项的索引键
+
+	trimLeading bool // 续写输出的首段文本是否去掉开头空白（预填充去掉的末尾空白已转发给客户端）
+}
+
+// partialToolCall 已转发给客户端的工具调用
+type partialToolCall struct {
+	key       string // 所属输出项的索引键
+	index     int    // 输出项内的工具调用序号（OpenAI Chat 的 tool_calls[].index）
+	id        string
+	name      string
+	arguments strings.Builder // 已转发的参数
+}
+
+// heldEvent 暂存的事件及其所属输出项的索引键
+type heldEvent struct {
+	event *types.StreamEventContract
+	key   string
+}
+
+// newStreamStitcher 创建流拼接器
+func newStreamStitcher() *streamStitcher {
+	return &streamStitcher{
+		index:   types.NewStreamIndexContext(),
+		started: make(map[types.StreamEventType]bool),
+	}
+}
+
+// resuming 判断当前是否处于续写尝试
+func (s *streamStitcher) resuming() bool {
+	return s.attempt > 0
+}
+
+// canContinue 判断已输出的内容能否续写
+//
+// 已输出文本或工具调用时可续写；已转发结束事件、请求不含消息或尚无输出时不可续写。
+func (s *streamStitcher) canContinue(req *types.RequestContract) bool {
+	return (s.text.Len() > 0 || len(s.toolCalls) > 0) && !s.completed && len(req.Messages) > 0
+}
+
+// continuationRequest 基于已输出的文本与工具调用构建在 channel 上执行的续写请求
+//
+// 已输出文本去掉末尾空白后作为末尾助手消息（Anthropic 拒绝以空白结尾的预填充），
+// 不支持预填充的供应商额外追加续写指令；已输出工具调用时（工具调用无法预填充）总是追加指令，列出已完成的调用。
+// 原请求不会被修改。
+func (s *streamStitcher) continuationRequest(req *types.RequestContract, channel *routing.Channel) *types.RequestContract {
+	messages := make([]types.Message, 0, len(req.Messages)+2)
+	messages = append(messages, req.Messages...)
+	if partial := strings.TrimRightFunc(s.text.String(), unicode.IsSpace); partial != "" {
+		messages = append(messages, types.Message{Role: "assistant", Content: types.Content{Text: &partial}})
+	}
+	if len(s.toolCalls) > 0 || !prefillProviders[strings.ToLower(channel.Provider)] {
+		instruction := s.instruction()
+		messages = append(messages, types.Message{Role: "user", Content: types.Content{Text: &instruction}})
+	}
+
+	continued := *req
+	continued.Messages = messages
+	return &continued
+}
+
+// instruction 返回续写指令
+func (s *streamStitcher) instruction() string {
+	if len(s.toolCalls) == 0 {
+		return continuationInstruction
+	}
+
+	var done strings.Builder
+	for _, call := range s.toolCalls {
+		fmt.Fprintf(&done, "\n- %s(%s)", call.name, call.arguments.String())
+	}
+	return fmt.Sprintf(toolContinuationInstruction, done.String())
+}
+
+// beginContinuation 开始一次续写尝试
+//
+// 丢弃中断时暂存的工具调用事件；已输出文本以空白结尾时，续写输出的首段文本去掉开头空白，
+// 由客户端已收到的空白衔接预填充与续写内容。
+func (s *streamStitcher) beginContinuation() {
+	s.attempt++
+	s.aliasResolved = false
+	s.held, s.heldKey = nil, ""
+
+	text := s.text.String()
+	s.trimLeading = len(strings.TrimRightFunc(text, unicode.IsSpace)) < len(text)
+}
+
+// apply 改写即将转发的事件，返回此时应转发的事件
+//
+// 被丢弃或暂存的事件不会返回；工具调用参数输出完毕时一并返回此前暂存的事件。
+func (s *streamStitcher) apply(event *types.StreamEventContract) []*types.StreamEventContract {
+	if event == nil {
+		return nil
+	}
+
+	if s.resuming() {
+		if s.started[event.Type] {
+			return nil
+		}
+		if event.ResponseID != "" && s.responseID != "" {
+			event.ResponseID = s.responseID
+		}
+		if event.MessageID != "" && s.messageID != "" {
+			event.MessageID = s.messageID
+		}
+		s.trimResumedText(event)
+	}
+
+	key, aliased := s.outputKey(event)
+	if aliased {
+		switch event.Type {
+		case types.StreamEventOutputItemAdded, types.StreamEventContentBlockStart, types.StreamEventContentPartAdded:
+			// 中断的输出项已向客户端发送过起始事件
+			return nil
+		}
+		if s.lastItemID != "" {
+			event.ItemID = s.lastItemID
+		}
+		event.ContentIndex = s.lastContentIndex
+	}
+
+	var out []*types.StreamEventContract
+	if s.held != nil {
+		if !isOutputItemEvent(event.Type) || key == s.heldKey {
+			s.held = append(s.held, heldEvent{event: event, key: key})
+			if closesToolCall(event.Type) {
+				return s.flush()
+			}
+			return nil
+		}
+		// 开始了新的输出项，暂存的工具调用已结束
+		out = s.flush()
+	}
+
+	if opensToolCall(event) && !closesToolCall(event.Type) {
+		s.held, s.heldKey = []heldEvent{{event: event, key: key}}, key
+		return out
+	}
+	return append(out, s.emit(event, key))
+}
+
+// flush 转发全部暂存的事件
+//
+// 尝试正常结束时调用，转发未收到参数结束事件的工具调用（如 OpenAI Chat 无 finish_reason 的流）。
+func (s *streamStitcher) flush() []*types.StreamEventContract {
+	out := make([]*types.StreamEventContract, 0, len(s.held))
+	for _, held := range s.held {
+		out = append(out, s.emit(held.event, held.key))
+	}
+	s.held, s.heldKey = nil, ""
+	return out
+}
+
+// emit 为事件分配输出位置并记录其输出内容
+func (s *streamStitcher) emit(event *types.StreamEventContract, key string) *types.StreamEventContract {
+	event.OutputIndex = s.index.EnsureOutputIndex(key)
+	if event.SequenceNumber > 0 {
+		event.SequenceNumber = s.index.NextSequence()
+	}
+	s.record(event, key)
+	return event
+}
+
+// trimResumedText 去掉续写输出首段文本的开头空白
+func (s *streamStitcher) trimResumedText(event *types.StreamEventContract) {
+	if !s.trimLeading {
+		return
+	}
+
+	var text **string
+	switch {
+	case event.Message != nil && event.Message.ContentText != nil:
+		text = &event.Message.ContentText
+	case event.Delta != nil && event.Delta.Text != nil &&
+		(event.Type == types.StreamEventContentBlockDelta || event.Type == types.StreamEventOutputTextDelta):
+		text = &event.Delta.Text
+	default:
+		return
+	}
+	trimmed := strings.TrimLeftFunc(**text, unicode.IsSpace)
+	*text = &trimmed
+	s.trimLeading = trimmed == ""
+}
+
+// outputKey 返回事件所属输出项的索引键，续写首个输出项返回中断输出项的键
+func (s *streamStitcher) outputKey(event *types.StreamEventContract) (string, bool) {
+	if s.resuming() && s.lastKey != "" && !s.lastClosed && isOutputItemEvent(event.Type) {
+		if !s.aliasResolved {
+			s.aliasOutput = event.OutputIndex
+			s.aliasResolved = true
+		}
+		if event.OutputIndex == s.aliasOutput {
+			return s.lastKey, true
+		}
+	}
+	return types.BuildStreamIndexKey(strconv.Itoa(s.attempt), event.OutputIndex, -1), false
+}
+
+// record 记录已转发事件的输出内容与位置
+func (s *streamStitcher) record(event *types.StreamEventContract, key string) {
+	s.emitted++
+	if s.responseID == "" {
+		s.responseID = event.ResponseID
+	}
+	if s.messageID == "" {
+		s.messageID = event.MessageID
+	}
+
+	switch event.Type {
+	case types.StreamEventMessageStart, types.StreamEventResponseCreated,
+		types.StreamEventResponseInProgress, types.StreamEventResponseQueued:
+		s.started[event.Type] = true
+	case types.StreamEventMessageStop, types.StreamEventResponseCompleted,
+		types.StreamEventResponseIncomplete, types.StreamEventResponseFailed:
+		s.completed = true
+	}
+
+	if isOutputItemEvent(event.Type) {
+		s.lastKey = key
+		if event.ItemID != "" {
+			s.lastItemID = event.ItemID
+		}
+		s.lastContentIndex = event.ContentIndex
+		s.lastClosed = event.Type == types.StreamEventContentBlockStop || event.Type == types.StreamEventOutputItemDone
+	}
+
+	switch {
+	case event.Message != nil && event.Message.ContentText != nil:
+		s.text.WriteString(*event.Message.ContentText)
+	case event.Delta != nil && event.Delta.Text != nil &&
+		(event.Type == types.StreamEventContentBlockDelta || event.Type == types.StreamEventOutputTextDelta):
+		s.text.WriteString(*event.Delta.Text)
+	}
+
+	s.recordToolCalls(event, key)
+}
+
+// recordToolCalls 记录事件中的工具调用增量
+func (s *streamStitcher) recordToolCalls(event *types.StreamEventContract, key string) {
+	if event.Message != nil {
+		for i := range event.Message.ToolCalls {
+			call := &event.Message.ToolCalls[i]
+			recorded := s.toolCall(key, toolCallIndex(call), true)
+			recorded.setIdentity(call.ID, call.Name)
+			recorded.arguments.WriteString(call.Arguments)
+		}
+	}
+	if event.Content != nil && event.Content.Tool != nil {
+		recorded := s.toolCall(key, -1, event.Type == types.StreamEventContentBlockStart || event.Type == types.StreamEventOutputItemAdded)
+		recorded.setIdentity(event.Content.Tool.ID, event.Content.Tool.Name)
+		if isToolArgumentsDone(event.Type) && recorded.arguments.Len() == 0 {
+			recorded.arguments.WriteString(event.Content.Tool.Arguments)
+		}
+	}
+	if event.Delta != nil && event.Delta.PartialJSON != nil {
+		s.toolCall(key, -1, false).arguments.WriteString(*event.Delta.PartialJSON)
+	}
+}
+
+// toolCall 返回输出项内的工具调用
+//
+// index >= 0 时按序号查找；否则 start 为 true 时开始新调用，为 false 时返回输出项内最近的调用。
+// 未找到时创建新调用。
+func (s *streamStitcher) toolCall(key string, index int, start bool) *partialToolCall {
+	if index >= 0 || !start {
+		for i := len(s.toolCalls) - 1; i >= 0; i-- {
+			call := s.toolCalls[i]
+			if call.key == key && (index < 0 || call.index == index) {
+				return call
+			}
+		}
+	}
+
+	if index < 0 {
+		index = 0
+	}
+	call := &partialToolCall{key: key, index: index}
+	s.toolCalls = append(s.toolCalls, call)
+	return call
+}
+
+// setIdentity 记录工具调用的 ID 与名称（仅首次出现时携带）
+func (c *partialToolCall) setIdentity(id, name string) {
+	if id != "" {
+		c.id = id
+	}
+	if name != "" {
+		c.name = name
+	}
+}
+
+// toolCallIndex 返回 OpenAI Chat 工具调用增量的序号
+func toolCallIndex(call *types.StreamToolCall) int {
+	switch index := call.Raw["index"].(type) {
+	case int:
+		return index
+	case float64:
+		return int(index)
+	}
+	return 0
+}
+
+// opensToolCall 判断事件是否开始输出工具调用
+func opensToolCall(event *types.StreamEventContract) bool {
+	if event.Message != nil && len(event.Message.ToolCalls) > 0 {
+		return true
+	}
+	return event.Content != nil && event.Content.Tool != nil &&
+		(event.Type == types.StreamEventContentBlockStart || event.Type == types.StreamEventOutputItemAdded)
+}
+
+// closesToolCall 判断事件是否结束暂存的工具调用（参数输出完毕、输出项结束或响应结束）
+func closesToolCall(eventType types.StreamEventType) bool {
+	switch eventType {
+	case types.StreamEventContentBlockStop, types.StreamEventOutputItemDone,
+		types.StreamEventMessageStop, types.StreamEventResponseCompleted,
+		types.StreamEventResponseIncomplete, types.StreamEventResponseFailed:
+		return true
+	}
+	return isToolArgumentsDone(eventType)
+}
+
+// isToolArgumentsDone 判断事件是否为工具调用参数输出完毕事件
+func isToolArgumentsDone(eventType types.StreamEventType) bool {
+	switch eventType {
+	case types.StreamEventFunctionCallArgumentsDone,
+		types.StreamEventCustomToolCallInputDone,
+		types.StreamEventMCPCallArgumentsDone:
+		return true
+	}
+	return false
+}
+
+// isOutputItemEvent 判断事件是否属于某个输出项（而非消息或响应级生命周期事件）
+func isOutputItemEvent(eventType types.StreamEventType) bool {
+	switch eventType {
+	case types.StreamEventMessageStart, types.StreamEventMessageStop,
+		types.StreamEventResponseCreated, types.StreamEventResponseInProgress,
+		types.StreamEventResponseQueued, types.StreamEventResponseCompleted,
+		types.StreamEventResponseIncomplete, types.StreamEventResponseFailed,
+		types.StreamEventPing, types.StreamEventError:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package portal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+func textDelta(responseID string, seq int, text string) *types.StreamEventContract {
+	return &types.StreamEventContract{
+		Type:           types.StreamEventContentBlockDelta,
+		ResponseID:     responseID,
+		MessageID:      responseID,
+		ItemID:         "item-" + responseID,
+		SequenceNumber: seq,
+		Delta:          &types.StreamDeltaPayload{DeltaType: "text_delta", Text: &text},
+	}
+}
+
+func TestStreamStitcher_StitchesContinuation(t *testing.T) {
+	s := newStreamStitcher()
+
+	first := []*types.StreamEventContract{
+		{Type: types.StreamEventMessageStart, ResponseID: "a", MessageID: "a", SequenceNumber: 1},
+		{Type: types.StreamEventContentBlockStart, ResponseID: "a", MessageID: "a", ItemID: "item-a", SequenceNumber: 2},
+		textDelta("a", 3, "Hello "),
+	}
+	for _, event := range first {
+		if len(s.apply(event)) != 1 {
+			t.Fatalf("首次尝试的事件不应被丢弃: %s", event.Type)
+		}
+	}
+
+	req := &types.RequestContract{Messages: []types.Message{{Role: "user"}}}
+	if s.emitted == 0 || !s.canContinue(req) {
+		t.Fatal("仅输出文本时应可续写")
+	}
+	s.beginContinuation()
+
+	resumed := []*types.StreamEventContract{
+		{Type: types.StreamEventMessageStart, ResponseID: "b", MessageID: "b", SequenceNumber: 1},
+		{Type: types.StreamEventContentBlockStart, ResponseID: "b", MessageID: "b", ItemID: "item-b", SequenceNumber: 2},
+		textDelta("b", 3, " world"),
+		{Type: types.StreamEventContentBlockStart, ResponseID: "b", MessageID: "b", ItemID: "item-b2", OutputIndex: 1, SequenceNumber: 4},
+	}
+	var forwarded []*types.StreamEventContract
+	for _, event := range resumed {
+		forwarded = append(forwarded, s.apply(event)...)
+	}
+
+	if len(forwarded) != 2 {
+		t.Fatalf("续写应丢弃已发送过的起始事件，实际转发 %d 个", len(forwarded))
+	}
+	delta := forwarded[0]
+	if delta.ResponseID != "a" || delta.ItemID != "item-a" || delta.OutputIndex != 0 || delta.SequenceNumber != 4 {
+		t.Fatalf("续写增量应并入中断的输出项，actual=%+v", delta)
+	}
+	if next := forwarded[1]; next.OutputIndex != 1 || next.SequenceNumber != 5 {
+		t.Fatalf("续写的新输出项应分配新的索引，actual=%+v", next)
+	}
+	if got := s.text.String(); got != "Hello world" {
+		t.Fatalf("续写文本应以已转发的末尾空白衔接，actual=%q", got)
+	}
+}
+
+func TestStreamStitcher_ContinuationRequest(t *testing.T) {
+	s := newStreamStitcher()
+	s.apply(textDelta("a", 1, "partial \n"))
+
+	req := &types.RequestContract{Model: "m", Messages: []types.Message{{Role: "user"}}}
+
+	prefill := s.continuationRequest(req, &routing.Channel{Provider: "anthropic"})
+	if len(prefill.Messages) != 2 || prefill.Messages[1].Role != "assistant" || *prefill.Messages[1].Content.Text != "partial" {
+		t.Fatalf("支持预填充的供应商应以去掉末尾空白的助手消息续写，actual=%+v", prefill.Messages)
+	}
+
+	instructed := s.continuationRequest(req, &routing.Channel{Provider: "openai"})
+	if len(instructed.Messages) != 3 || instructed.Messages[2].Role != "user" {
+		t.Fatalf("不支持预填充的供应商应追加续写指令，actual=%+v", instructed.Messages)
+	}
+	if len(req.Messages) != 1 {
+		t.Fatal("构建续写请求不应修改原请求")
+	}
+}
+
+func TestStreamStitcher_ReissuesInterruptedToolCall(t *testing.T) {
+	s := newStreamStitcher()
+	toolStart := func(id string, index int) *types.StreamEventContract {
+		return &types.StreamEventContract{
+			Type: types.StreamEventContentBlockStart, OutputIndex: index, ContentIndex: index, ItemID: id,
+			Content: &types.StreamContentPayload{Kind: "tool_use", Tool: &types.StreamToolCall{ID: id, Type: "tool_use", Name: "get_weather"}},
+		}
+	}
+	argsDelta := func(id string, index int, partial string) *types.StreamEventContract {
+		return &types.StreamEventContract{
+			Type: types.StreamEventContentBlockDelta, OutputIndex: index, ContentIndex: index, ItemID: id,
+			Delta: &types.StreamDeltaPayload{DeltaType: "input_json_delta", PartialJSON: &partial},
+		}
+	}
+	blockStop := func(id string, index int) *types.StreamEventContract {
+		return &types.StreamEventContract{Type: types.StreamEventContentBlockStop, OutputIndex: index, ContentIndex: index, ItemID: id}
+	}
+
+	s.apply(&types.StreamEventContract{Type: types.StreamEventMessageStart, MessageID: "a"})
+	s.apply(toolStart("toolu_a", 0))
+	s.apply(argsDelta("toolu_a", 0, `{"city":"Berlin"}`))
+	if len(s.apply(blockStop("toolu_a", 0))) != 3 {
+		t.Fatal("工具调用参数输出完毕后应一并转发暂存的事件")
+	}
+	if got := s.apply(toolStart("toolu_b", 1)); got != nil {
+		t.Fatalf("参数输出完毕前工具调用事件应暂存，actual=%d", len(got))
+	}
+	if got := s.apply(argsDelta("toolu_b", 1, `{"city":`)); got != nil {
+		t.Fatalf("参数输出完毕前工具调用事件应暂存，actual=%d", len(got))
+	}
+
+	req := &types.RequestContract{Messages: []types.Message{{Role: "user"}}}
+	if !s.canContinue(req) {
+		t.Fatal("已输出工具调用时应可续写")
+	}
+	s.beginContinuation()
+
+	continued := s.continuationRequest(req, &routing.Channel{Provider: "anthropic"})
+	if len(continued.Messages) != 2 || continued.Messages[1].Role != "user" {
+		t.Fatalf("未输出文本时应只追加续写指令，actual=%+v", continued.Messages)
+	}
+	instruction := *continued.Messages[1].Content.Text
+	if !strings.Contains(instruction, `get_weather({"city":"Berlin"})`) || strings.Contains(instruction, `{"city":`+"`") {
+		t.Fatalf("续写指令应只列出已完成的工具调用，actual=%s", instruction)
+	}
+
+	// 续写重新发起完整的调用，客户端从未收到被中断调用的参数
+	var forwarded []*types.StreamEventContract
+	for _, event := range []*types.StreamEventContract{
+		toolStart("toolu_c", 0),
+		argsDelta("toolu_c", 0, `{"city":"Paris"}`),
+		blockStop("toolu_c", 0),
+	} {
+		forwarded = append(forwarded, s.apply(event)...)
+	}
+	if len(forwarded) != 3 || forwarded[0].ItemID != "toolu_c" || forwarded[0].OutputIndex != 1 {
+		t.Fatalf("续写的工具调用应作为新的输出项转发，actual=%d", len(forwarded))
+	}
+	if len(s.toolCalls) != 2 || s.toolCalls[1].id != "toolu_c" || s.toolCalls[1].arguments.String() != `{"city":"Paris"}` {
+		t.Fatalf("应只记录完整的工具调用参数，actual=%d", len(s.toolCalls))
+	}
+}
+
+func TestStreamStitcher_HoldsChatToolCallsUntilFinish(t *testing.T) {
+	s := newStreamStitcher()
+	chunk := func(calls ...types.StreamToolCall) *types.StreamEventContract {
+		return &types.StreamEventContract{Type: types.StreamEventMessageDelta, Message: &types.StreamMessagePayload{ToolCalls: calls}}
+	}
+	call := func(index int, id, name, arguments string) types.StreamToolCall {
+		return types.StreamToolCall{ID: id, Type: "function", Name: name, Arguments: arguments, Raw: map[string]interface{}{"index": index}}
+	}
+	text := "Checking. "
+
+	s.apply(&types.StreamEventContract{Type: types.StreamEventMessageDelta, Message: &types.StreamMessagePayload{ContentText: &text}})
+	if s.apply(chunk(call(0, "call_a", "search", `{"q":"go"}`))) != nil || s.apply(chunk(call(1, "call_b", "fetch", `{"url":`))) != nil {
+		t.Fatal("结束前 OpenAI Chat 工具调用增量应暂存")
+	}
+	s.beginContinuation()
+
+	instruction := *s.continuationRequest(&types.RequestContract{Messages: []types.Message{{Role: "user"}}}, &routing.Channel{Provider: "openai"}).Messages[2].Content.Text
+	if instruction != continuationInstruction {
+		t.Fatalf("未转发的工具调用不应出现在续写指令中，actual=%s", instruction)
+	}
+
+	resumed := chunk(call(0, "call_c", "search", `{"q":"go"}`))
+	if s.apply(resumed) != nil {
+		t.Fatal("续写的工具调用增量同样应暂存")
+	}
+	if got := s.flush(); len(got) != 1 || got[0] != resumed {
+		t.Fatalf("尝试正常结束时应转发暂存的事件，actual=%d", len(got))
+	}
+	if first := resumed.Message.ToolCalls[0]; first.ID != "call_c" || first.Raw["index"] != 0 {
+		t.Fatalf("重新发起的工具调用应保持原样，actual=%+v", first)
+	}
+	if len(s.toolCalls) != 1 || s.toolCalls[0].arguments.String() != `{"q":"go"}` {
+		t.Fatalf("应只记录完整的工具调用参数，actual=%d", len(s.toolCalls))
+	}
+}
+
+func TestContractStreamForward_WithoutStitcherPassesThrough(t *testing.T) {
+	attempt := &contractStreamAttempt{
+		events:   make(chan *types.StreamEventContract, 4),
+		finished: make(chan struct{}),
+	}
+	events := []*types.StreamEventContract{
+		{Type: types.StreamEventResponseCreated, ResponseID: "a", SequenceNumber: 7},
+		{Type: types.StreamEventOutputTextDelta, ResponseID: "a", ItemID: "item-a", OutputIndex: 3, ContentIndex: 2, SequenceNumber: 8},
+	}
+	for _, event := range events {
+		attempt.events <- event
+	}
+	close(attempt.events)
+	close(attempt.finished)
+
+	out := make(chan *types.StreamEventContract, 4)
+	forwarded, err := attempt.forward(context.Background(), out, nil, nil)
+	if err != nil || !forwarded {
+		t.Fatalf("应转发全部事件，forwarded=%v err=%v", forwarded, err)
+	}
+	close(out)
+
+	i := 0
+	for event := range out {
+		if event != events[i] || event.SequenceNumber != 7+i {
+			t.Fatalf("未启用续写时事件应原样转发，actual=%+v", event)
+		}
+		i++
+	}
+	if i != len(events) || events[1].OutputIndex != 3 || events[1].ContentIndex != 2 {
+		t.Fatalf("未启用续写时不应改写输出位置，count=%d event=%+v", i, events[1])
+	}
+}
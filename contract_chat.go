@@ -28,13 +28,15 @@ func classifyStreamCanceledStatus(err error) (status string, cancelSource string
 // ChatCompletion 处理聊天完成请求（非流式）
 func (p *Portal) ChatCompletion(ctx context.Context, request *types.RequestContract) (*types.ResponseContract, error) {
 	p.logger.DebugContext(ctx, "request_started", "model", request.Model)
+	selectOpts := p.contractSelectOptions(request, nil)
+	upstream := p.upstreamRequest(request)
 
 	response, err := retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannel(ctx, request.Model)
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.getContractChannel(ctx, request.Model, append(selectOpts, extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel) (*types.ResponseContract, error) {
-			return p.request.ChatCompletion(reqCtx, request, ch)
+			return p.request.ChatCompletion(reqCtx, upstream, ch)
 		},
 		nil,
 	)
@@ -56,14 +58,29 @@ func (p *Portal) ChatCompletion(ctx context.Context, request *types.RequestContr
 // ChatCompletionStream 处理流式聊天完成请求
 func (p *Portal) ChatCompletionStream(ctx context.Context, request *types.RequestContract) <-chan *types.StreamEventContract {
 	p.logger.DebugContext(ctx, "request_started", "model", request.Model)
+	selectOpts := p.contractSelectOptions(request, nil)
+	upstream := p.upstreamRequest(request)
 
 	// 创建内部流（用于接收原始响应）
 	internalStream := make(chan *types.StreamEventContract, StreamBufferSize)
 
+	getChannel := func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+		return p.getContractChannel(ctx, request.Model, append(selectOpts, extra...)...)
+	}
+
 	// 启动内部流处理协程
 	go func() {
+		defer close(internalStream)
+
+		state := p.newRetryState(ctx)
+		// 仅在启用续写时拼接多次尝试的事件，未启用时事件原样转发
+		var stitcher *streamStitcher
+		if state.policy.StreamContinuation {
+			stitcher = newStreamStitcher()
+		}
+		emitted := false // 是否已有事件转发给客户端
 		for {
-			channel, err := p.routing.GetChannel(ctx, request.Model)
+			channel, err := getChannel(ctx, state.selectOptions()...)
 			if err != nil {
 				if errors.IsCode(err, errors.ErrCodeAborted) || errors.IsCanceled(err) || errors.IsCanceled(ctx.Err()) {
 					cancelErr := normalizeStreamCanceledError(ctx, err)
@@ -105,8 +122,7 @@ func (p *Portal) ChatCompletionStream(ctx context.Context, request *types.Reques
 					default:
 					}
 				}
-				close(internalStream)
-				break
+				return
 			}
 
 			// 使用 With 创建带有通道上下文的日志记录器
@@ -115,17 +131,57 @@ func (p *Portal) ChatCompletionStream(ctx context.Context, request *types.Reques
 				"model_id", channel.ModelID,
 				"api_key_id", channel.APIKeyID)
 
-			err = p.session.WithSession(ctx, func(reqCtx context.Context, reqCancel context.CancelFunc) (err error) {
-				defer reqCancel()
-				return p.request.ChatCompletionStream(reqCtx, request, internalStream, channel)
-			})
+			// 已有输出时以续写请求接续中断的流
+			attemptRequest := upstream
+			if stitcher != nil && stitcher.resuming() {
+				attemptRequest = stitcher.continuationRequest(upstream, channel)
+			}
 
-			// 检查错误是否可以重试
+			// 每次尝试使用独立的输出通道，由本协程转发到内部流；
+			// 启用对冲时先等待首个事件决出胜者（续写尝试不对冲）
+			var (
+				attempt *contractStreamAttempt
+				first   *types.StreamEventContract
+			)
+			if delay, hedging := state.hedgeDelay(channel, true); hedging && (stitcher == nil || !stitcher.resuming()) {
+				groupID := newHedgeGroupID()
+				attemptCtx, attemptCancel := newHedgeAttemptContext(ctx, groupID, false)
+				attempt = startContractStreamAttempt(ctx, attemptCtx, attemptCancel, p, attemptRequest, channel)
+				attempt, first = hedgeContractStream(ctx, p, state, getChannel, attempt, groupID, delay, upstream)
+				if attempt.channel != channel {
+					channel = attempt.channel
+					channelLogger = p.logger.With(
+						"platform_id", channel.PlatformID,
+						"model_id", channel.ModelID,
+						"api_key_id", channel.APIKeyID)
+				}
+			} else {
+				attemptCtx, attemptCancel := context.WithCancelCause(ctx)
+				attempt = startContractStreamAttempt(ctx, attemptCtx, attemptCancel, p, attemptRequest, channel)
+			}
+			forwarded, err := attempt.forward(ctx, internalStream, first, stitcher)
+			emitted = emitted || forwarded
+
+			// 检查错误是否可以重试：已有输出时仅在启用续写且可续写时重试
 			if err != nil {
-				if errors.IsRetryable(err) {
-					channelLogger.WarnContext(ctx, "request_retry_scheduled", "error", err)
+				resumable := !emitted || (stitcher != nil && stitcher.canContinue(request))
+				if resumable && state.shouldRetry(ctx, channel, err) {
+					if emitted {
+						stitcher.beginContinuation()
+						channelLogger.WarnContext(ctx, "stream_continuation_scheduled",
+							"error", err,
+							"attempt", state.attempts,
+							"emitted_events", stitcher.emitted,
+						)
+					} else {
+						channelLogger.WarnContext(ctx, "request_retry_scheduled", "error", err, "attempt", state.attempts)
+					}
 					channel.MarkFailure(ctx, err)
-					continue
+					if waitErr := state.wait(ctx); waitErr != nil {
+						err = waitErr
+					} else {
+						continue
+					}
 				}
 				// 特殊处理：主动取消/操作终止不视为失败噪音
 				if errors.IsCode(err, errors.ErrCodeAborted) || errors.IsCanceled(err) || errors.IsCanceled(ctx.Err()) {
@@ -138,13 +194,11 @@ func (p *Portal) ChatCompletionStream(ctx context.Context, request *types.Reques
 						"cancel_source", cancelSource,
 						"error", cancelErr,
 					)
-					close(internalStream)
-					break
+					return
 				}
 				p.logger.ErrorContext(ctx, "request_failed", "model", request.Model, "error", err)
 				channel.MarkFailure(ctx, err)
-				close(internalStream)
-				break
+				return
 			}
 			channel.MarkSuccess(ctx)
 			p.logger.InfoContext(ctx, "stream_finished",
@@ -153,7 +207,7 @@ func (p *Portal) ChatCompletionStream(ctx context.Context, request *types.Reques
 				"completion_state", "completed",
 				"connection_status", "disconnected",
 			)
-			break
+			return
 		}
 	}()
 
@@ -162,3 +216,136 @@ func (p *Portal) ChatCompletionStream(ctx context.Context, request *types.Reques
 
 	return outputStream
 }
+
+// contractStreamAttempt 一次已启动的 Contract 流式尝试
+type contractStreamAttempt struct {
+	ctx        context.Context // 外层请求上下文
+	attemptCtx context.Context // 本次尝试的上下文
+	cancel     context.CancelCauseFunc
+	channel    *routing.Channel
+	events     chan *types.StreamEventContract // 本次尝试的输出
+	finished   chan struct{}                   // 尝试结束时关闭
+	err        error                           // 尝试结束时的错误（finished 关闭后可读）
+}
+
+// startContractStreamAttempt 在独立会话中异步执行一次 Contract 流式尝试
+//
+// 尝试结束后释放通道在途计数并关闭 finished。
+func startContractStreamAttempt(
+	ctx context.Context,
+	attemptCtx context.Context,
+	cancel context.CancelCauseFunc,
+	p *Portal,
+	request *types.RequestContract,
+	channel *routing.Channel,
+) *contractStreamAttempt {
+	attempt := &contractStreamAttempt{
+		ctx:        ctx,
+		attemptCtx: attemptCtx,
+		cancel:     cancel,
+		channel:    channel,
+		events:     make(chan *types.StreamEventContract, StreamBufferSize),
+		finished:   make(chan struct{}),
+	}
+
+	go func() {
+		err := p.session.WithSession(attemptCtx, func(reqCtx context.Context, reqCancel context.CancelFunc) error {
+			defer reqCancel()
+			return p.request.ChatCompletionStream(reqCtx, request, attempt.events, channel)
+		})
+		channel.Release()
+		attempt.err = err
+		close(attempt.finished)
+		cancel(nil)
+	}()
+
+	return attempt
+}
+
+// forward 将尝试的输出转发到 out，返回是否转发过事件与尝试结束时的错误
+//
+// first 为对冲阶段已读取的首个事件（可为 nil）。stitcher 不为 nil 时事件经其改写后转发，
+// 否则原样转发。客户端断开后丢弃剩余输出，直至尝试结束。
+func (a *contractStreamAttempt) forward(
+	ctx context.Context,
+	out chan<- *types.StreamEventContract,
+	first *types.StreamEventContract,
+	stitcher *streamStitcher,
+) (bool, error) {
+	discard, forwarded := false, false
+	deliver := func(event *types.StreamEventContract) {
+		if discard {
+			return
+		}
+		select {
+		case out <- event:
+			forwarded = true
+		case <-ctx.Done():
+			discard = true
+		}
+	}
+	send := func(event *types.StreamEventContract) {
+		if stitcher == nil {
+			deliver(event)
+			return
+		}
+		for _, event := range stitcher.apply(event) {
+			deliver(event)
+		}
+	}
+	// 尝试正常结束时转发拼接器暂存的事件，失败时留待续写丢弃
+	finish := func() (bool, error) {
+		if stitcher != nil && a.err == nil {
+			for _, event := range stitcher.flush() {
+				deliver(event)
+			}
+		}
+		return forwarded, a.err
+	}
+
+	if first != nil {
+		send(first)
+	}
+	for {
+		select {
+		case event, ok := <-a.events:
+			if !ok {
+				<-a.finished
+				return finish()
+			}
+			send(event)
+		case <-a.finished:
+			// 启动阶段失败时输出通道不会被关闭，转发剩余的缓冲事件后结束
+			for {
+				select {
+				case event, ok := <-a.events:
+					if !ok {
+						return finish()
+					}
+					send(event)
+				default:
+					return finish()
+				}
+			}
+		}
+	}
+}
+
+// abandon 以对冲落选原因结束尝试并丢弃其剩余输出
+func (a *contractStreamAttempt) abandon() {
+	cancelHedgeLoser(a.cancel)
+	go func() {
+		for {
+			select {
+			case _, ok := <-a.events:
+				if ok {
+					continue
+				}
+				<-a.finished
+			case <-a.finished:
+			}
+			markHedgeLoser(a.ctx, a.attemptCtx, a.channel, a.err)
+			return
+		}
+	}()
+}
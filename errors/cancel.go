@@ -105,3 +105,20 @@ func NormalizeCanceledWithSource(err error, isClientDisconnect bool) error {
 	return WrapWithHTTPStatus(classification.ErrorCode, message, err, classification.HTTPStatus).
 		WithContext("error_from", string(classification.ErrorFrom))
 }
+
+// NewGatewayCanceled 创建网关主动取消的错误。
+//
+// 用于网关出于自身策略提前结束请求的场景（如对冲请求落选），
+// 错误码为 CANCELED、来源为 gateway，不计入通道健康惩罚。
+// reason 写入上下文 cancel_reason，便于审计区分取消原因。
+func NewGatewayCanceled(reason string) *Error {
+	return New(ErrCodeCanceled, "请求已被网关取消").
+		WithHTTPStatus(HTTPStatusClientClosedRequest).
+		WithContext("error_from", string(ErrorFromGateway)).
+		WithContext("cancel_reason", reason)
+}
+
+// IsGatewayCanceled 判断错误是否为网关主动取消。
+func IsGatewayCanceled(err error) bool {
+	return IsCode(err, ErrCodeCanceled) && GetErrorFrom(err) == ErrorFromGateway
+}
@@ -0,0 +1,26 @@
+package errors
+
+import "time"
+
+// GetRetryAfter 从错误上下文中提取 retry_after 重试等待提示。
+//
+// retry_after 可以是 time.Duration，也可以是以秒为单位的数值。
+func GetRetryAfter(err error) (time.Duration, bool) {
+	context := GetContext(err)
+	if context == nil {
+		return 0, false
+	}
+
+	switch v := context["retry_after"].(type) {
+	case time.Duration:
+		return v, v > 0
+	case int:
+		return time.Duration(v) * time.Second, v > 0
+	case int64:
+		return time.Duration(v) * time.Second, v > 0
+	case float64:
+		return time.Duration(v * float64(time.Second)), v > 0
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,108 @@
+package portal
+
+import (
+	"context"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// ModelFallbacks 跨模型回退链配置
+//
+// 键为请求的模型名称，值为按顺序尝试的回退模型名称，
+// 例如 {"claude-sonnet": {"gpt-4o", "gemini-pro"}}。
+// 回退只发生在统一格式（Contract）路径和兼容模式路径上，
+// 因为 RequestContract 可以转换为任意供应商的请求格式。
+type ModelFallbacks map[string][]string
+
+// cloneModelFallbacks 复制回退链配置并过滤空名称与自身引用
+func cloneModelFallbacks(fallbacks ModelFallbacks) ModelFallbacks {
+	if len(fallbacks) == 0 {
+		return nil
+	}
+
+	cloned := make(ModelFallbacks, len(fallbacks))
+	for model, chain := range fallbacks {
+		if model == "" {
+			continue
+		}
+		filtered := make([]string, 0, len(chain))
+		seen := map[string]struct{}{model: {}}
+		for _, name := range chain {
+			if name == "" {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			filtered = append(filtered, name)
+		}
+		if len(filtered) > 0 {
+			cloned[model] = filtered
+		}
+	}
+	return cloned
+}
+
+// getContractChannel 获取统一格式请求的通道，必要时沿回退链切换模型
+//
+// 首先按请求模型获取通道；当该模型没有可用通道（全部处于退避、不可用或本地限流饱和）时，
+// 依次尝试回退链中的模型。回退模型未配置或同样没有可用通道时继续尝试下一个，
+// 全部失败时返回请求模型的原始错误。
+//
+// 回退得到的通道会通过 Channel.FallbackFrom 记录原始请求模型，
+// 用于在响应与请求日志中标记实际服务的模型。
+func (p *Portal) getContractChannel(ctx context.Context, modelName string, opts ...routing.SelectOption) (*routing.Channel, error) {
+	channel, err := p.routing.GetChannel(ctx, modelName, opts...)
+	if err == nil || !isModelUnavailable(err) {
+		return channel, err
+	}
+
+	chain := p.modelFallbacks[modelName]
+	for _, fallbackModel := range chain {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		fallbackChannel, fallbackErr := p.routing.GetChannel(ctx, fallbackModel, opts...)
+		if fallbackErr != nil {
+			if isModelUnavailable(fallbackErr) || errors.IsCode(fallbackErr, errors.ErrCodeNotFound) {
+				p.logger.DebugContext(ctx, "model_fallback_unavailable",
+					"model", modelName,
+					"fallback_model", fallbackModel,
+					"error", fallbackErr,
+				)
+				continue
+			}
+			return nil, fallbackErr
+		}
+
+		fallbackChannel.FallbackFrom = modelName
+		p.logger.WarnContext(ctx, "model_fallback_selected",
+			"model", modelName,
+			"fallback_model", fallbackModel,
+			"platform_id", fallbackChannel.PlatformID,
+			"model_id", fallbackChannel.ModelID,
+		)
+		return fallbackChannel, nil
+	}
+
+	if len(chain) > 0 {
+		if e, ok := err.(*errors.Error); ok {
+			return nil, e.WithContext("fallback_models", chain)
+		}
+	}
+	return nil, err
+}
+
+// isModelUnavailable 判断错误是否表示模型没有可用通道
+//
+// 包括全部处于退避/不可用、熔断器半开试探名额已满、本地限流饱和，以及没有通道满足能力、标签约束或上下文窗口（回退模型可能满足）。
+func isModelUnavailable(err error) bool {
+	return errors.IsCode(err, errors.ErrCodeResourceExhausted) ||
+		errors.IsCode(err, errors.ErrCodeRateLimitExceeded) ||
+		errors.IsCode(err, errors.ErrCodeNoHealthyChannel) ||
+		errors.IsCode(err, errors.ErrCodeCircuitBreakerOpen) ||
+		errors.IsCode(err, errors.ErrCodeOutOfRange)
+}
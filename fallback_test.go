@@ -0,0 +1,213 @@
+package portal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing"
+	"github.com/MeowSalty/portal/routing/health"
+)
+
+type testPlatformRepo struct{}
+
+func (testPlatformRepo) GetPlatformByID(ctx context.Context, id uint) (*routing.Platform, error) {
+	return nil, nil
+}
+
+type testKeyRepo struct{}
+
+func (testKeyRepo) GetAllAPIKeysByPlatformID(ctx context.Context, platformID uint) ([]*routing.APIKey, error) {
+	return nil, nil
+}
+
+type testModelRepo struct {
+	models []routing.ModelWithEndpoint
+}
+
+func (r *testModelRepo) FindModelsWithDefaultEndpoint(ctx context.Context, name string) ([]routing.ModelWithEndpoint, error) {
+	var result []routing.ModelWithEndpoint
+	for _, mwe := range r.models {
+		if mwe.Model.Name == name {
+			result = append(result, mwe)
+		}
+	}
+	return result, nil
+}
+
+func (r *testModelRepo) FindModelsWithEndpoint(ctx context.Context, name, endpointType, endpointVariant string) ([]routing.ModelWithEndpoint, error) {
+	var result []routing.ModelWithEndpoint
+	for _, mwe := range r.models {
+		if mwe.Model.Name == name && mwe.Endpoint.EndpointType == endpointType && mwe.Endpoint.EndpointVariant == endpointVariant {
+			result = append(result, mwe)
+		}
+	}
+	return result, nil
+}
+
+func (r *testModelRepo) FindModelsByAlias(ctx context.Context, alias string) ([]routing.Model, error) {
+	var result []routing.Model
+	for _, mwe := range r.models {
+		if mwe.Model.Alias == alias {
+			result = append(result, mwe.Model)
+		}
+	}
+	return result, nil
+}
+
+type testHealthKey struct {
+	resourceType health.ResourceType
+	resourceID   uint
+}
+
+type testHealthStorage struct {
+	mu   sync.Mutex
+	data map[testHealthKey]*health.Health
+}
+
+func newTestHealthStorage() *testHealthStorage {
+	return &testHealthStorage{data: make(map[testHealthKey]*health.Health)}
+}
+
+func (s *testHealthStorage) Get(resourceType health.ResourceType, resourceID uint) (*health.Health, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[testHealthKey{resourceType: resourceType, resourceID: resourceID}], nil
+}
+
+func (s *testHealthStorage) Set(status *health.Health) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[testHealthKey{resourceType: status.ResourceType, resourceID: status.ResourceID}] = status
+	return nil
+}
+
+func (s *testHealthStorage) Delete(resourceType health.ResourceType, resourceID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, testHealthKey{resourceType: resourceType, resourceID: resourceID})
+	return nil
+}
+
+// markBackoff 将资源置为退避中，使其在退避结束前不可选
+func (s *testHealthStorage) markBackoff(resourceType health.ResourceType, resourceID uint) {
+	now := time.Now()
+	next := now.Add(time.Hour)
+	_ = s.Set(&health.Health{
+		ResourceType:    resourceType,
+		ResourceID:      resourceID,
+		Status:          health.HealthStatusUnavailable,
+		NextAvailableAt: &next,
+		LastCheckAt:     now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	})
+}
+
+func newTestPortal(t *testing.T, models []routing.ModelWithEndpoint, cfg Config) (*Portal, *testHealthStorage) {
+	t.Helper()
+
+	storage := newTestHealthStorage()
+	cfg.PlatformRepo = testPlatformRepo{}
+	cfg.ModelRepo = &testModelRepo{models: models}
+	cfg.KeyRepo = testKeyRepo{}
+	cfg.HealthStorage = storage
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatalf("创建 Portal 失败: %v", err)
+	}
+	return p, storage
+}
+
+func fallbackTestModels() []routing.ModelWithEndpoint {
+	return []routing.ModelWithEndpoint{
+		{
+			Platform: routing.Platform{ID: 1},
+			Model:    routing.Model{ID: 10, Name: "claude-sonnet", APIKeys: []routing.APIKey{{ID: 100}}},
+			Endpoint: routing.Endpoint{ID: 1, EndpointType: "anthropic", EndpointVariant: "messages"},
+		},
+		{
+			Platform: routing.Platform{ID: 2},
+			Model:    routing.Model{ID: 20, Name: "gpt-4o", APIKeys: []routing.APIKey{{ID: 200}}},
+			Endpoint: routing.Endpoint{ID: 2, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+		{
+			Platform: routing.Platform{ID: 3},
+			Model:    routing.Model{ID: 30, Name: "gemini-pro", APIKeys: []routing.APIKey{{ID: 300}}},
+			Endpoint: routing.Endpoint{ID: 3, EndpointType: "google", EndpointVariant: "generate"},
+		},
+	}
+}
+
+func TestGetContractChannel_FallsBackAlongChain(t *testing.T) {
+	p, storage := newTestPortal(t, fallbackTestModels(), Config{
+		ModelFallbacks: ModelFallbacks{"claude-sonnet": {"missing-model", "gpt-4o", "gemini-pro"}},
+	})
+	ctx := context.Background()
+
+	ch, err := p.getContractChannel(ctx, "claude-sonnet")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.ModelName != "claude-sonnet" || ch.FallbackFrom != "" {
+		t.Fatalf("主模型可用时不应回退，actual=%s fallback_from=%q", ch.ModelName, ch.FallbackFrom)
+	}
+	ch.Release()
+
+	storage.markBackoff(health.ResourceTypeModel, 10)
+	ch, err = p.getContractChannel(ctx, "claude-sonnet")
+	if err != nil {
+		t.Fatalf("回退获取通道失败: %v", err)
+	}
+	if ch.ModelName != "gpt-4o" || ch.FallbackFrom != "claude-sonnet" {
+		t.Fatalf("应跳过未配置模型并回退到 gpt-4o，actual=%s fallback_from=%q", ch.ModelName, ch.FallbackFrom)
+	}
+	ch.Release()
+
+	storage.markBackoff(health.ResourceTypePlatform, 2)
+	ch, err = p.getContractChannel(ctx, "claude-sonnet")
+	if err != nil {
+		t.Fatalf("回退获取通道失败: %v", err)
+	}
+	if ch.ModelName != "gemini-pro" {
+		t.Fatalf("应继续回退到 gemini-pro，actual=%s", ch.ModelName)
+	}
+	ch.Release()
+
+	storage.markBackoff(health.ResourceTypeAPIKey, 300)
+	_, err = p.getContractChannel(ctx, "claude-sonnet")
+	if !errors.IsCode(err, errors.ErrCodeResourceExhausted) {
+		t.Fatalf("回退链耗尽时应返回主模型的资源耗尽错误，actual=%v", err)
+	}
+	if chain, ok := errors.GetContext(err)["fallback_models"].([]string); !ok || len(chain) != 3 {
+		t.Fatalf("错误上下文应包含回退链，actual=%v", errors.GetContext(err))
+	}
+}
+
+func TestGetContractChannel_NoFallbackForUnknownModel(t *testing.T) {
+	p, _ := newTestPortal(t, fallbackTestModels(), Config{
+		ModelFallbacks: ModelFallbacks{"unknown": {"gpt-4o"}},
+	})
+
+	_, err := p.getContractChannel(context.Background(), "unknown")
+	if !errors.IsCode(err, errors.ErrCodeNotFound) {
+		t.Fatalf("未配置的请求模型不应触发回退，actual=%v", err)
+	}
+}
+
+func TestCloneModelFallbacks_FiltersInvalidEntries(t *testing.T) {
+	cloned := cloneModelFallbacks(ModelFallbacks{
+		"a": {"", "a", "b", "b", "c"},
+		"":  {"x"},
+		"d": {"d"},
+	})
+	if len(cloned) != 1 {
+		t.Fatalf("应只保留有效回退链，actual=%v", cloned)
+	}
+	if chain := cloned["a"]; len(chain) != 2 || chain[0] != "b" || chain[1] != "c" {
+		t.Fatalf("回退链应去除空名称、自身与重复项，actual=%v", chain)
+	}
+}
@@ -0,0 +1,41 @@
+package portal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+)
+
+func TestSubscribeHealthEvents_ReceivesChannelFailure(t *testing.T) {
+	p, _ := newTestPortal(t, fallbackTestModels(), Config{})
+	sub := p.SubscribeHealthEvents(0)
+	defer sub.Close()
+
+	ch, err := p.getContractChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.MarkFailure(context.Background(), errors.New(errors.ErrCodeAuthenticationFailed, "密钥无效").
+		WithHTTPStatus(401).
+		WithContext("error_from", string(errors.ErrorFromServer)))
+	ch.Release()
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case event := <-sub.C:
+			if event.ResourceType != health.ResourceTypeAPIKey {
+				continue
+			}
+			if event.ResourceID != 200 || event.NewStatus == health.HealthStatusUnknown || event.Snapshot.HTTPStatus == nil {
+				t.Fatalf("密钥失败事件内容不正确，actual=%+v", event)
+			}
+			return
+		case <-deadline:
+			t.Fatal("通道失败后应收到密钥的健康事件")
+		}
+	}
+}
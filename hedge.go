@@ -0,0 +1,346 @@
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/request"
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// hedgeLostReason 对冲落选的取消原因
+const hedgeLostReason = "hedge_lost"
+
+// HedgePolicy 定义对冲请求策略
+//
+// 启用后，若尝试在对冲延迟内仍未返回（流式请求为未产生首个事件），
+// 会在另一个通道上发起对冲请求，取先返回者的结果，并以网关取消结束落选的请求。
+// 落选请求不计入通道健康惩罚，两者的请求日志通过 HedgeGroupID 关联。
+// 对冲尝试计入 RetryPolicy 的尝试次数：剩余尝试次数不足或总耗时已用尽时不发起对冲。
+// 零值表示不启用。
+type HedgePolicy struct {
+	// Delay 发起对冲请求前的等待时长；启用 Percentile 时作为样本不足时的兜底，<= 0 表示无兜底
+	Delay time.Duration
+
+	// Percentile 以所属模型最近成功请求耗时的该分位数（如 0.95）作为对冲延迟，0 表示仅使用 Delay。
+	// 非流式请求使用总耗时，流式请求使用首字耗时。
+	Percentile float64
+}
+
+// Validate 校验对冲策略配置
+func (hp HedgePolicy) Validate() error {
+	if hp.Delay < 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "对冲延迟不能为负数").
+			WithContext("delay", hp.Delay)
+	}
+	if hp.Percentile < 0 || hp.Percentile >= 1 {
+		return errors.New(errors.ErrCodeConfigInvalid, "对冲分位数必须在 0 到 1 之间（不含 1）").
+			WithContext("percentile", hp.Percentile)
+	}
+	return nil
+}
+
+// delayFor 计算通道本轮尝试的对冲延迟，未启用对冲时返回 false
+func (hp HedgePolicy) delayFor(ch *routing.Channel, stream bool) (time.Duration, bool) {
+	if hp.Percentile > 0 && ch != nil {
+		if d, ok := ch.LatencyPercentile(hp.Percentile, stream); ok && d > 0 {
+			return d, true
+		}
+	}
+	if hp.Delay > 0 {
+		return hp.Delay, true
+	}
+	return 0, false
+}
+
+// newHedgeGroupID 生成对冲组 ID
+func newHedgeGroupID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// newHedgeAttemptContext 创建对冲组内单个尝试的上下文
+//
+// 返回的取消函数以给定原因取消该尝试，落选的尝试使用对冲落选错误取消，
+// 请求层据此将其记录为网关取消。
+func newHedgeAttemptContext(ctx context.Context, groupID string, isHedge bool) (context.Context, context.CancelCauseFunc) {
+	return context.WithCancelCause(request.WithHedgeAttempt(ctx, groupID, isHedge))
+}
+
+// cancelHedgeLoser 以对冲落选原因取消尝试
+func cancelHedgeLoser(cancel context.CancelCauseFunc) {
+	cancel(errors.NewGatewayCanceled(hedgeLostReason))
+}
+
+// markHedgeLoser 以网关取消标记落选尝试的通道（不计入健康惩罚）
+func markHedgeLoser(ctx, attemptCtx context.Context, ch *routing.Channel, err error) {
+	if err == nil {
+		return
+	}
+	if cause := context.Cause(attemptCtx); errors.IsGatewayCanceled(cause) {
+		ch.MarkFailure(ctx, cause)
+	}
+}
+
+// recordHedgeFailure 记录对冲组内先失败的尝试
+//
+// 另一尝试仍在进行时调用：计入重试状态（后续不再选择该通道），
+// 与常规重试流程一致按重试规则判定错误，仅可重试的失败标记通道健康，
+// 请求参数错误等不可重试的失败不惩罚通道。
+func recordHedgeFailure(ctx context.Context, state *retryState, ch *routing.Channel, err error, timedOut bool) {
+	state.recordFailure(ch)
+	if !timedOut && (ctx.Err() != nil || errors.IsCanceled(err)) {
+		return
+	}
+	if state.classify(ch, err) {
+		ch.MarkFailure(ctx, err)
+	}
+}
+
+// hedgeChannel 在重试预算允许时为对冲尝试选择通道，返回 nil 表示不发起对冲
+func hedgeChannel(
+	ctx context.Context,
+	p *Portal,
+	state *retryState,
+	getChannel channelFunc,
+	primary *routing.Channel,
+	groupID string,
+	delay time.Duration,
+) *routing.Channel {
+	if !state.canHedge() {
+		p.logger.DebugContext(ctx, "hedge_skipped", "reason", "retry_budget_exhausted", "attempt", state.attempts)
+		return nil
+	}
+	ch, err := getChannel(ctx, hedgeSelectOptions(state, primary)...)
+	if err != nil {
+		p.logger.DebugContext(ctx, "hedge_skipped", "error", err)
+		return nil
+	}
+	p.logger.DebugContext(ctx, "hedge_started",
+		"hedge_group_id", groupID,
+		"delay", delay.String(),
+		"platform_id", ch.PlatformID,
+		"model_id", ch.ModelID,
+		"api_key_id", ch.APIKeyID,
+	)
+	return ch
+}
+
+// hedgeSelectOptions 返回对冲请求的通道选择约束：在重试约束之外排除主尝试的通道
+func hedgeSelectOptions(state *retryState, primary *routing.Channel) []routing.SelectOption {
+	return append(state.selectOptions(), routing.WithExcludedChannels(primary.ID()))
+}
+
+// hedgeNonStream 执行一轮带对冲的非流式尝试
+//
+// 主尝试在对冲延迟内未返回时，在另一通道上发起对冲尝试；任一尝试成功即返回，并以网关取消结束另一尝试。
+// 先失败的尝试记入重试状态并继续等待仍在进行的尝试；全部失败时返回最后一个失败结果，
+// 由调用方按常规重试流程处理。
+func hedgeNonStream[T any](
+	ctx context.Context,
+	p *Portal,
+	state *retryState,
+	getChannel channelFunc,
+	primary *routing.Channel,
+	delay time.Duration,
+	execute func(reqCtx context.Context, ch *routing.Channel) (T, error),
+) nonStreamAttempt[T] {
+	groupID := newHedgeGroupID()
+	results := make(chan nonStreamAttempt[T], 2)
+	var cancels []context.CancelCauseFunc
+	defer func() {
+		// 返回时仍在进行的尝试均为落选者；已结束的尝试取消无副作用
+		for _, cancel := range cancels {
+			cancelHedgeLoser(cancel)
+		}
+	}()
+
+	launch := func(ch *routing.Channel, isHedge bool) {
+		attemptCtx, cancel := newHedgeAttemptContext(ctx, groupID, isHedge)
+		cancels = append(cancels, cancel)
+		go func() {
+			attempt := runNonStreamAttempt(attemptCtx, p, state, ch, execute)
+			markHedgeLoser(ctx, attemptCtx, ch, attempt.err)
+			results <- attempt
+		}()
+	}
+
+	launch(primary, false)
+	pending := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+
+	var last nonStreamAttempt[T]
+	for pending > 0 {
+		select {
+		case <-timerC:
+			timerC = nil
+			ch := hedgeChannel(ctx, p, state, getChannel, primary, groupID, delay)
+			if ch == nil {
+				continue
+			}
+			launch(ch, true)
+			pending++
+		case attempt := <-results:
+			pending--
+			if attempt.err == nil {
+				return attempt
+			}
+			if pending > 0 {
+				recordHedgeFailure(ctx, state, attempt.channel, attempt.err, attempt.timedOut)
+				continue
+			}
+			last = attempt
+		}
+	}
+	return last
+}
+
+// hedgeNativeStream 等待原生流式主尝试的首个事件，超过对冲延迟时在另一通道发起对冲尝试
+//
+// 先产生首个事件（或先结束）的尝试胜出，返回胜出的尝试及其已读取的首个事件；
+// 落选的尝试以网关取消结束，其剩余输出被丢弃。对冲尝试启动失败时记入重试状态并继续等待主尝试。
+// 上下文结束时返回主尝试且不读取事件，由调用方按取消处理。
+func hedgeNativeStream(
+	ctx context.Context,
+	p *Portal,
+	state *retryState,
+	getChannel channelFunc,
+	primary *nativeStreamAttempt,
+	groupID string,
+	delay time.Duration,
+	execute func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error,
+) (winner *nativeStreamAttempt, first streamEvent) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+
+	var hedge *nativeStreamAttempt
+	for {
+		var hedgeOutput chan any
+		if hedge != nil {
+			hedgeOutput = hedge.output
+		}
+
+		select {
+		case event, ok := <-primary.output:
+			if hedge != nil {
+				hedge.abandon()
+				state.recordAbandoned()
+			}
+			return primary, streamEvent{value: event, ok: ok, received: true}
+		case event, ok := <-hedgeOutput:
+			primary.abandon()
+			state.recordAbandoned()
+			return hedge, streamEvent{value: event, ok: ok, received: true}
+		case <-timerC:
+			timerC = nil
+			ch := hedgeChannel(ctx, p, state, getChannel, primary.channel, groupID, delay)
+			if ch == nil {
+				continue
+			}
+			attemptCtx, cancel := newHedgeAttemptContext(ctx, groupID, true)
+			started, err := startNativeStreamAttempt(ctx, attemptCtx, cancel, p, ch, execute)
+			if err != nil {
+				recordHedgeFailure(ctx, state, ch, err, false)
+				continue
+			}
+			hedge = started
+		case <-ctx.Done():
+			if hedge != nil {
+				hedge.abandon()
+			}
+			return primary, streamEvent{}
+		}
+	}
+}
+
+// hedgeContractStream 等待 Contract 流式主尝试的首个事件，超过对冲延迟时在另一通道发起对冲尝试
+//
+// 先产生首个事件（或先成功结束）的尝试胜出，返回胜出的尝试及其已读取的首个事件（可为 nil）；
+// 落选的尝试以网关取消结束。先失败的尝试记入重试状态，继续等待另一尝试。
+// 上下文结束时返回主尝试，由调用方按取消处理。
+func hedgeContractStream(
+	ctx context.Context,
+	p *Portal,
+	state *retryState,
+	getChannel channelFunc,
+	primary *contractStreamAttempt,
+	groupID string,
+	delay time.Duration,
+	req *types.RequestContract,
+) (*contractStreamAttempt, *types.StreamEventContract) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+
+	var hedge *contractStreamAttempt
+	for {
+		var (
+			hedgeEvents   chan *types.StreamEventContract
+			hedgeFinished chan struct{}
+		)
+		if hedge != nil {
+			hedgeEvents, hedgeFinished = hedge.events, hedge.finished
+		}
+
+		select {
+		case event, ok := <-primary.events:
+			if hedge != nil {
+				hedge.abandon()
+				state.recordAbandoned()
+			}
+			if !ok {
+				return primary, nil
+			}
+			return primary, event
+		case event, ok := <-hedgeEvents:
+			primary.abandon()
+			state.recordAbandoned()
+			if !ok {
+				return hedge, nil
+			}
+			return hedge, event
+		case <-primary.finished:
+			if hedge == nil || primary.err == nil {
+				if hedge != nil {
+					hedge.abandon()
+					state.recordAbandoned()
+				}
+				return primary, nil
+			}
+			recordHedgeFailure(ctx, state, primary.channel, primary.err, false)
+			return hedge, nil
+		case <-hedgeFinished:
+			if hedge.err == nil {
+				primary.abandon()
+				state.recordAbandoned()
+				return hedge, nil
+			}
+			recordHedgeFailure(ctx, state, hedge.channel, hedge.err, false)
+			hedge = nil
+		case <-timerC:
+			timerC = nil
+			ch := hedgeChannel(ctx, p, state, getChannel, primary.channel, groupID, delay)
+			if ch == nil {
+				continue
+			}
+			attemptCtx, cancel := newHedgeAttemptContext(ctx, groupID, true)
+			hedge = startContractStreamAttempt(ctx, attemptCtx, cancel, p, req, ch)
+		case <-ctx.Done():
+			if hedge != nil {
+				hedge.abandon()
+			}
+			return primary, nil
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing"
+	"github.com/MeowSalty/portal/routing/health"
+)
+
+func TestRetryNonStream_HedgeWinsAndCancelsPrimary(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{
+		MaxAttempts: 2,
+		Hedge:       HedgePolicy{Delay: 10 * time.Millisecond},
+	})
+
+	var exclusions []int
+	keyID := uint(0)
+	primaryCause := make(chan error, 1)
+	result, err := retryNonStream(context.Background(), p,
+		func(ctx context.Context, opts ...routing.SelectOption) (*routing.Channel, error) {
+			exclusions = append(exclusions, len(opts))
+			keyID++
+			return &routing.Channel{APIKeyID: keyID}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			if ch.APIKeyID == 1 {
+				<-reqCtx.Done()
+				primaryCause <- context.Cause(reqCtx)
+				return "", reqCtx.Err()
+			}
+			return "hedge", nil
+		},
+		nil,
+	)
+
+	if err != nil || result != "hedge" {
+		t.Fatalf("对冲请求先返回时应采用其结果，result=%q err=%v", result, err)
+	}
+	if len(exclusions) != 2 || exclusions[1] == 0 {
+		t.Fatalf("对冲请求应排除主请求的通道，actual=%v", exclusions)
+	}
+
+	select {
+	case cause := <-primaryCause:
+		if !errors.IsGatewayCanceled(cause) {
+			t.Fatalf("落选请求应以网关取消结束，actual=%v", cause)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("落选请求未被取消")
+	}
+}
+
+func TestRetryNonStream_HedgeNotStartedWhenPrimaryIsFast(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{Hedge: HedgePolicy{Delay: time.Second}})
+
+	calls := 0
+	result, err := retryNonStream(context.Background(), p,
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+			calls++
+			return &routing.Channel{}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			return "primary", nil
+		},
+		nil,
+	)
+
+	if err != nil || result != "primary" || calls != 1 {
+		t.Fatalf("主请求在对冲延迟内返回时不应发起对冲，result=%q calls=%d err=%v", result, calls, err)
+	}
+}
+
+func TestRetryNonStream_HedgeRespectsRetryBudget(t *testing.T) {
+	policies := map[string]RetryPolicy{
+		"max_attempts": {MaxAttempts: 1, Hedge: HedgePolicy{Delay: 10 * time.Millisecond}},
+		"max_elapsed":  {MaxElapsed: 5 * time.Millisecond, Hedge: HedgePolicy{Delay: 20 * time.Millisecond}},
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			p := newRetryPolicyTestPortal(policy)
+
+			calls := 0
+			result, err := retryNonStream(context.Background(), p,
+				func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+					calls++
+					return &routing.Channel{}, nil
+				},
+				func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+					time.Sleep(40 * time.Millisecond)
+					return "primary", nil
+				},
+				nil,
+			)
+
+			if err != nil || result != "primary" || calls != 1 {
+				t.Fatalf("重试预算耗尽时不应发起对冲，result=%q calls=%d err=%v", result, calls, err)
+			}
+		})
+	}
+}
+
+func TestRecordHedgeFailure_ClassifiesLikeRetry(t *testing.T) {
+	p, storage := newTestPortal(t, fallbackTestModels(), Config{})
+	ctx := context.Background()
+	state := p.newRetryState(ctx)
+	errorCount := func() int {
+		total := 0
+		for _, key := range []testHealthKey{
+			{resourceType: health.ResourceTypePlatform, resourceID: 2},
+			{resourceType: health.ResourceTypeModel, resourceID: 20},
+			{resourceType: health.ResourceTypeAPIKey, resourceID: 200},
+		} {
+			if status, _ := storage.Get(key.resourceType, key.resourceID); status != nil {
+				total += status.ErrorCount
+			}
+		}
+		return total
+	}
+
+	ch, err := p.getContractChannel(ctx, "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+
+	// 客户端请求错误不可重试，不应惩罚通道
+	recordHedgeFailure(ctx, state, ch, errors.New(errors.ErrCodeInvalidArgument, "请求参数错误").
+		WithHTTPStatus(http.StatusBadRequest).
+		WithContext("error_from", string(errors.ErrorFromClient)), false)
+	if got := errorCount(); got != 0 {
+		t.Fatalf("不可重试的失败不应标记通道健康，error_count=%d", got)
+	}
+
+	recordHedgeFailure(ctx, state, ch, retryableTestError(), false)
+	if got := errorCount(); got != 1 {
+		t.Fatalf("可重试的失败应标记通道健康，error_count=%d", got)
+	}
+	if state.attempts != 2 {
+		t.Fatalf("两次失败均应计入尝试次数，actual=%d", state.attempts)
+	}
+}
+
+func TestHedgePolicy_ValidateAndDelay(t *testing.T) {
+	invalid := []HedgePolicy{{Delay: -time.Second}, {Percentile: 1}, {Percentile: -0.1}}
+	for _, policy := range invalid {
+		if err := policy.Validate(); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+			t.Fatalf("策略 %+v 应校验失败，actual=%v", policy, err)
+		}
+	}
+
+	if _, ok := (HedgePolicy{}).delayFor(nil, false); ok {
+		t.Fatal("零值策略不应启用对冲")
+	}
+	// 无延迟样本时回退到固定延迟
+	delay, ok := HedgePolicy{Delay: 50 * time.Millisecond, Percentile: 0.95}.delayFor(&routing.Channel{}, false)
+	if !ok || delay != 50*time.Millisecond {
+		t.Fatalf("样本不足时应使用固定延迟，delay=%s ok=%v", delay, ok)
+	}
+}
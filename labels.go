@@ -0,0 +1,84 @@
+package portal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+)
+
+// DefaultLabelMetadataPrefix 默认的标签约束 metadata 键前缀
+//
+// 例如 metadata {"route.region": "eu"} 表示请求只能路由到标签 region=eu 的通道。
+const DefaultLabelMetadataPrefix = "route."
+
+// LabelConstraintsFunc 从 Contract 请求中提取路由标签约束
+//
+// 返回空表示该请求没有标签约束。consumed 为承载约束的 metadata 键，
+// 转发上游的请求副本会移除这些键，避免内部路由约束泄露给供应商。
+type LabelConstraintsFunc func(req *types.RequestContract) (labels map[string]string, consumed []string)
+
+// LabelsFromMetadata 将请求 metadata 中以 prefix 开头的字段作为标签约束（去掉前缀后作为标签名）
+func LabelsFromMetadata(prefix string) LabelConstraintsFunc {
+	return func(req *types.RequestContract) (map[string]string, []string) {
+		if req == nil || len(req.Metadata) == 0 {
+			return nil, nil
+		}
+		var (
+			labels   map[string]string
+			consumed []string
+		)
+		for key, value := range req.Metadata {
+			name, ok := strings.CutPrefix(key, prefix)
+			if !ok {
+				continue
+			}
+			consumed = append(consumed, key)
+			if name == "" || value == nil {
+				continue
+			}
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			if s, ok := value.(string); ok {
+				labels[name] = s
+			} else {
+				labels[name] = fmt.Sprint(value)
+			}
+		}
+		return labels, consumed
+	}
+}
+
+// upstreamRequest 返回转发上游的请求：移除承载标签约束的 metadata 键
+//
+// 没有需要移除的键时返回原请求，否则返回浅拷贝，不修改调用方的请求。
+func (p *Portal) upstreamRequest(req *types.RequestContract) *types.RequestContract {
+	if p.labelConstraints == nil || req == nil || len(req.Metadata) == 0 {
+		return req
+	}
+	_, consumed := p.labelConstraints(req)
+	if len(consumed) == 0 {
+		return req
+	}
+	metadata := make(map[string]interface{}, len(req.Metadata))
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+	for _, key := range consumed {
+		delete(metadata, key)
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	upstream := *req
+	upstream.Metadata = metadata
+	return &upstream
+}
+
+// DefaultLabelConstraints 返回默认的标签约束提取函数
+//
+// 读取 metadata 中以 DefaultLabelMetadataPrefix 开头的字段。
+func DefaultLabelConstraints() LabelConstraintsFunc {
+	return LabelsFromMetadata(DefaultLabelMetadataPrefix)
+}
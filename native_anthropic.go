@@ -29,8 +29,8 @@ func (p *Portal) NativeAnthropicMessages(
 	options := applyNativeOptions(opts)
 
 	return retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, req.Model, "anthropic", "messages")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, req.Model, "anthropic", "messages", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel) (*anthropicTypes.Response, error) {
 			resp, err := p.request.Native(reqCtx, req, ch, req.Model)
@@ -47,7 +47,7 @@ func (p *Portal) NativeAnthropicMessages(
 				"provider", "anthropic",
 				"endpoint_variant", "messages",
 			)
-			return p.nativeAnthropicCompatFallback(ctx, req)
+			return p.nativeAnthropicCompatFallback(ctx, req, options)
 		}),
 	)
 }
@@ -72,8 +72,8 @@ func (p *Portal) NativeAnthropicMessagesStream(
 	options := applyNativeOptions(opts)
 
 	return retryNativeStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, req.Model, "anthropic", "messages")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, req.Model, "anthropic", "messages", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
 			return p.request.NativeStream(reqCtx, req, ch, req.Model, output)
@@ -85,7 +85,7 @@ func (p *Portal) NativeAnthropicMessagesStream(
 				"provider", "anthropic",
 				"endpoint_variant", "messages",
 			)
-			return p.nativeAnthropicStreamCompatFallback(ctx, req)
+			return p.nativeAnthropicStreamCompatFallback(ctx, req, options)
 		}),
 	)
 }
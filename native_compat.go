@@ -22,6 +22,7 @@ import (
 func (p *Portal) nativeOpenAIChatCompatFallback(
 	ctx context.Context,
 	req *openaiChat.Request,
+	options *nativeOptions,
 ) (*openaiChat.Response, error) {
 	compatLogger := p.logger.WithGroup("native_compat").With(
 		"request_mode", "compat",
@@ -34,12 +35,13 @@ func (p *Portal) nativeOpenAIChatCompatFallback(
 		return nil, err
 	}
 
-	channel, err := p.routing.GetChannel(ctx, req.Model)
+	state := p.newRetryState(ctx)
+	channel, err := p.getContractChannel(ctx, req.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel)
+	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +53,7 @@ func (p *Portal) nativeOpenAIChatCompatFallback(
 func (p *Portal) nativeOpenAIResponsesCompatFallback(
 	ctx context.Context,
 	req *openaiResponses.Request,
+	options *nativeOptions,
 ) (*openaiResponses.Response, error) {
 	modelName := ""
 	if req.Model != nil {
@@ -68,12 +71,13 @@ func (p *Portal) nativeOpenAIResponsesCompatFallback(
 		return nil, err
 	}
 
-	channel, err := p.routing.GetChannel(ctx, modelName)
+	state := p.newRetryState(ctx)
+	channel, err := p.getContractChannel(ctx, modelName, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel)
+	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +89,7 @@ func (p *Portal) nativeOpenAIResponsesCompatFallback(
 func (p *Portal) nativeAnthropicCompatFallback(
 	ctx context.Context,
 	req *anthropicTypes.Request,
+	options *nativeOptions,
 ) (*anthropicTypes.Response, error) {
 	compatLogger := p.logger.WithGroup("native_compat").With(
 		"request_mode", "compat",
@@ -97,12 +102,13 @@ func (p *Portal) nativeAnthropicCompatFallback(
 		return nil, err
 	}
 
-	channel, err := p.routing.GetChannel(ctx, req.Model)
+	state := p.newRetryState(ctx)
+	channel, err := p.getContractChannel(ctx, req.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel)
+	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -114,18 +120,20 @@ func (p *Portal) nativeAnthropicCompatFallback(
 func (p *Portal) nativeGeminiCompatFallback(
 	ctx context.Context,
 	req *geminiTypes.Request,
+	options *nativeOptions,
 ) (*geminiTypes.Response, error) {
 	contractReq, err := geminiConverter.RequestToContract(req)
 	if err != nil {
 		return nil, err
 	}
 
-	channel, err := p.routing.GetChannel(ctx, req.Model)
+	state := p.newRetryState(ctx)
+	channel, err := p.getContractChannel(ctx, req.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel)
+	contractResp, err := p.doCompatContractNonStream(ctx, contractReq, channel, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +145,7 @@ func (p *Portal) nativeGeminiCompatFallback(
 func (p *Portal) nativeOpenAIChatStreamCompatFallback(
 	ctx context.Context,
 	req *openaiChat.Request,
+	options *nativeOptions,
 ) <-chan *openaiChat.StreamEvent {
 	outputStream := make(chan *openaiChat.StreamEvent, StreamBufferSize)
 
@@ -154,8 +163,10 @@ func (p *Portal) nativeOpenAIChatStreamCompatFallback(
 			p.sendNativeCompatOpenAIChatStreamErrorEvent(outputStream, err)
 			return
 		}
+		upstreamReq := p.upstreamRequest(contractReq)
 
-		channel, err := p.routing.GetChannel(ctx, req.Model)
+		state := p.newRetryState(ctx)
+		channel, err := p.getContractChannel(ctx, req.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 		if err != nil {
 			p.sendNativeCompatOpenAIChatStreamErrorEvent(outputStream, err)
 			return
@@ -171,22 +182,27 @@ func (p *Portal) nativeOpenAIChatStreamCompatFallback(
 			contractStream := make(chan *adapterTypes.StreamEventContract, StreamBufferSize)
 			done := make(chan struct{})
 			var doneOnce sync.Once
+			attemptChannel := channel
 			closeDone := func() {
 				doneOnce.Do(func() {
 					close(done)
+					attemptChannel.Release()
 				})
 			}
 
 			err = p.session.WithSessionStream(ctx, done, func(reqCtx context.Context) error {
-				return p.request.ChatCompletionStream(reqCtx, contractReq, contractStream, channel)
+				return p.request.ChatCompletionStream(reqCtx, upstreamReq, contractStream, channel)
 			})
 
 			if err != nil {
 				closeDone()
-				if errors.IsRetryable(err) {
-					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err)
+				if state.shouldRetry(ctx, channel, err) {
+					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err, "attempt", state.attempts)
 					channel.MarkFailure(ctx, err)
-					nextChannel, routeErr := p.routing.GetChannel(ctx, contractReq.Model)
+					if state.wait(ctx) != nil {
+						return
+					}
+					nextChannel, routeErr := p.getContractChannel(ctx, contractReq.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 					if routeErr != nil {
 						p.sendNativeCompatOpenAIChatStreamErrorEvent(outputStream, routeErr)
 						return
@@ -238,6 +254,7 @@ func (p *Portal) nativeOpenAIChatStreamCompatFallback(
 func (p *Portal) nativeOpenAIResponsesStreamCompatFallback(
 	ctx context.Context,
 	req *openaiResponses.Request,
+	options *nativeOptions,
 ) <-chan *openaiResponses.StreamEvent {
 	outputStream := make(chan *openaiResponses.StreamEvent, StreamBufferSize)
 
@@ -260,8 +277,10 @@ func (p *Portal) nativeOpenAIResponsesStreamCompatFallback(
 			p.sendNativeCompatOpenAIResponsesStreamErrorEvent(outputStream, err)
 			return
 		}
+		upstreamReq := p.upstreamRequest(contractReq)
 
-		channel, err := p.routing.GetChannel(ctx, modelName)
+		state := p.newRetryState(ctx)
+		channel, err := p.getContractChannel(ctx, modelName, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 		if err != nil {
 			p.sendNativeCompatOpenAIResponsesStreamErrorEvent(outputStream, err)
 			return
@@ -277,22 +296,27 @@ func (p *Portal) nativeOpenAIResponsesStreamCompatFallback(
 			contractStream := make(chan *adapterTypes.StreamEventContract, StreamBufferSize)
 			done := make(chan struct{})
 			var doneOnce sync.Once
+			attemptChannel := channel
 			closeDone := func() {
 				doneOnce.Do(func() {
 					close(done)
+					attemptChannel.Release()
 				})
 			}
 
 			err = p.session.WithSessionStream(ctx, done, func(reqCtx context.Context) error {
-				return p.request.ChatCompletionStream(reqCtx, contractReq, contractStream, channel)
+				return p.request.ChatCompletionStream(reqCtx, upstreamReq, contractStream, channel)
 			})
 
 			if err != nil {
 				closeDone()
-				if errors.IsRetryable(err) {
-					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err)
+				if state.shouldRetry(ctx, channel, err) {
+					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err, "attempt", state.attempts)
 					channel.MarkFailure(ctx, err)
-					nextChannel, routeErr := p.routing.GetChannel(ctx, contractReq.Model)
+					if state.wait(ctx) != nil {
+						return
+					}
+					nextChannel, routeErr := p.getContractChannel(ctx, contractReq.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 					if routeErr != nil {
 						p.sendNativeCompatOpenAIResponsesStreamErrorEvent(outputStream, routeErr)
 						return
@@ -347,6 +371,7 @@ func (p *Portal) nativeOpenAIResponsesStreamCompatFallback(
 func (p *Portal) nativeAnthropicStreamCompatFallback(
 	ctx context.Context,
 	req *anthropicTypes.Request,
+	options *nativeOptions,
 ) <-chan *anthropicTypes.StreamEvent {
 	outputStream := make(chan *anthropicTypes.StreamEvent, StreamBufferSize)
 
@@ -364,8 +389,10 @@ func (p *Portal) nativeAnthropicStreamCompatFallback(
 			p.sendNativeCompatAnthropicStreamErrorEvent(outputStream, err)
 			return
 		}
+		upstreamReq := p.upstreamRequest(contractReq)
 
-		channel, err := p.routing.GetChannel(ctx, req.Model)
+		state := p.newRetryState(ctx)
+		channel, err := p.getContractChannel(ctx, req.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 		if err != nil {
 			p.sendNativeCompatAnthropicStreamErrorEvent(outputStream, err)
 			return
@@ -381,22 +408,27 @@ func (p *Portal) nativeAnthropicStreamCompatFallback(
 			contractStream := make(chan *adapterTypes.StreamEventContract, StreamBufferSize)
 			done := make(chan struct{})
 			var doneOnce sync.Once
+			attemptChannel := channel
 			closeDone := func() {
 				doneOnce.Do(func() {
 					close(done)
+					attemptChannel.Release()
 				})
 			}
 
 			err = p.session.WithSessionStream(ctx, done, func(reqCtx context.Context) error {
-				return p.request.ChatCompletionStream(reqCtx, contractReq, contractStream, channel)
+				return p.request.ChatCompletionStream(reqCtx, upstreamReq, contractStream, channel)
 			})
 
 			if err != nil {
 				closeDone()
-				if errors.IsRetryable(err) {
-					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err)
+				if state.shouldRetry(ctx, channel, err) {
+					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err, "attempt", state.attempts)
 					channel.MarkFailure(ctx, err)
-					nextChannel, routeErr := p.routing.GetChannel(ctx, contractReq.Model)
+					if state.wait(ctx) != nil {
+						return
+					}
+					nextChannel, routeErr := p.getContractChannel(ctx, contractReq.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 					if routeErr != nil {
 						p.sendNativeCompatAnthropicStreamErrorEvent(outputStream, routeErr)
 						return
@@ -448,6 +480,7 @@ func (p *Portal) nativeAnthropicStreamCompatFallback(
 func (p *Portal) nativeGeminiStreamCompatFallback(
 	ctx context.Context,
 	req *geminiTypes.Request,
+	options *nativeOptions,
 ) <-chan *geminiTypes.StreamEvent {
 	outputStream := make(chan *geminiTypes.StreamEvent, StreamBufferSize)
 
@@ -465,8 +498,10 @@ func (p *Portal) nativeGeminiStreamCompatFallback(
 			p.sendNativeCompatGeminiStreamErrorEvent(outputStream, err)
 			return
 		}
+		upstreamReq := p.upstreamRequest(contractReq)
 
-		channel, err := p.routing.GetChannel(ctx, req.Model)
+		state := p.newRetryState(ctx)
+		channel, err := p.getContractChannel(ctx, req.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 		if err != nil {
 			p.sendNativeCompatGeminiStreamErrorEvent(outputStream, err)
 			return
@@ -482,22 +517,27 @@ func (p *Portal) nativeGeminiStreamCompatFallback(
 			contractStream := make(chan *adapterTypes.StreamEventContract, StreamBufferSize)
 			done := make(chan struct{})
 			var doneOnce sync.Once
+			attemptChannel := channel
 			closeDone := func() {
 				doneOnce.Do(func() {
 					close(done)
+					attemptChannel.Release()
 				})
 			}
 
 			err = p.session.WithSessionStream(ctx, done, func(reqCtx context.Context) error {
-				return p.request.ChatCompletionStream(reqCtx, contractReq, contractStream, channel)
+				return p.request.ChatCompletionStream(reqCtx, upstreamReq, contractStream, channel)
 			})
 
 			if err != nil {
 				closeDone()
-				if errors.IsRetryable(err) {
-					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err)
+				if state.shouldRetry(ctx, channel, err) {
+					channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err, "attempt", state.attempts)
 					channel.MarkFailure(ctx, err)
-					nextChannel, routeErr := p.routing.GetChannel(ctx, contractReq.Model)
+					if state.wait(ctx) != nil {
+						return
+					}
+					nextChannel, routeErr := p.getContractChannel(ctx, contractReq.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 					if routeErr != nil {
 						p.sendNativeCompatGeminiStreamErrorEvent(outputStream, routeErr)
 						return
@@ -546,12 +586,17 @@ func (p *Portal) nativeGeminiStreamCompatFallback(
 }
 
 // doCompatContractNonStream 在默认通道上执行 Contract 非流式请求，并复用重试逻辑。
+//
+// state 须为选择首个通道时使用的重试状态，保证重试落在同一流量切分分组。
 func (p *Portal) doCompatContractNonStream(
 	ctx context.Context,
 	contractReq *adapterTypes.RequestContract,
 	channel *routing.Channel,
+	options *nativeOptions,
+	state *retryState,
 ) (*adapterTypes.ResponseContract, error) {
 	var response *adapterTypes.ResponseContract
+	upstreamReq := p.upstreamRequest(contractReq)
 
 	for {
 		channelLogger := p.logger.WithGroup("native_compat").With(
@@ -563,16 +608,25 @@ func (p *Portal) doCompatContractNonStream(
 
 		err := p.session.WithSession(ctx, func(reqCtx context.Context, reqCancel context.CancelFunc) error {
 			defer reqCancel()
+			attemptCtx, attemptCancel := state.attemptContext(reqCtx)
+			defer attemptCancel()
 			var callErr error
-			response, callErr = p.request.ChatCompletion(reqCtx, contractReq, channel)
+			response, callErr = p.request.ChatCompletion(attemptCtx, upstreamReq, channel)
+			if callErr != nil && attemptTimedOut(reqCtx, attemptCtx) {
+				callErr = newAttemptTimeoutError(callErr)
+			}
 			return callErr
 		})
+		channel.Release()
 
 		if err != nil {
-			if errors.IsRetryable(err) {
-				channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err)
+			if state.shouldRetry(ctx, channel, err) {
+				channelLogger.WarnContext(ctx, "请求失败，尝试重试", "error", err, "attempt", state.attempts)
 				channel.MarkFailure(ctx, err)
-				nextChannel, routeErr := p.routing.GetChannel(ctx, contractReq.Model)
+				if waitErr := state.wait(ctx); waitErr != nil {
+					return nil, normalizeNonStreamCanceledError(ctx, waitErr)
+				}
+				nextChannel, routeErr := p.getContractChannel(ctx, contractReq.Model, append(p.contractSelectOptions(contractReq, options), state.selectOptions()...)...)
 				if routeErr != nil {
 					return nil, routeErr
 				}
@@ -0,0 +1,99 @@
+package portal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MeowSalty/portal/request"
+	openaiChat "github.com/MeowSalty/portal/request/adapter/openai/types/chat"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// recordingLogRepo 记录所有写入的请求日志
+type recordingLogRepo struct {
+	mu   sync.Mutex
+	logs []*request.RequestLog
+}
+
+func (r *recordingLogRepo) CreateRequestLog(_ context.Context, log *request.RequestLog) error {
+	r.mu.Lock()
+	r.logs = append(r.logs, log)
+	r.mu.Unlock()
+	return nil
+}
+
+func TestNativeCompat_RetryStaysInSplitArm(t *testing.T) {
+	// 奇数次请求失败、偶数次成功：每个逻辑请求都是一次失败加一次重试
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"error":{"message":"overloaded","type":"server_error"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"ok","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	endpoint := routing.Endpoint{ID: 1, EndpointType: "openai", EndpointVariant: "chat_completions"}
+	models := []routing.ModelWithEndpoint{
+		{
+			Platform: routing.Platform{ID: 1, BaseURL: server.URL},
+			Model:    routing.Model{ID: 10, Name: "gpt-4o", APIKeys: []routing.APIKey{{ID: 100, Value: "k100"}, {ID: 101, Value: "k101"}}},
+			Endpoint: endpoint,
+		},
+		{
+			Platform: routing.Platform{ID: 2, BaseURL: server.URL},
+			Model:    routing.Model{ID: 20, Name: "gpt-4o", APIKeys: []routing.APIKey{{ID: 200, Value: "k200"}, {ID: 201, Value: "k201"}}},
+			Endpoint: endpoint,
+		},
+	}
+	logs := &recordingLogRepo{}
+	p, err := New(Config{
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     &testModelRepo{models: models},
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: discardHealthStorage{newTestHealthStorage()},
+		LogRepo:       logs,
+		RetryPolicy:   &RetryPolicy{MaxAttempts: 2},
+		TrafficSplits: []routing.TrafficSplit{{
+			Model: "gpt-4o",
+			Arms:  []routing.SplitArm{{Name: "canary", Percent: 50, PlatformIDs: []uint{1}}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("创建 Portal 失败: %v", err)
+	}
+
+	text := "hello"
+	for i := 0; i < 20; i++ {
+		req := &openaiChat.Request{
+			Model:    "gpt-4o",
+			Messages: []openaiChat.RequestMessage{{Role: "user", Content: openaiChat.MessageContent{StringValue: &text}}},
+		}
+		if _, err := p.nativeOpenAIChatCompatFallback(context.Background(), req, nil); err != nil {
+			t.Fatalf("第 %d 次请求失败: %v", i, err)
+		}
+	}
+
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	if len(logs.logs) != 40 {
+		t.Fatalf("每个逻辑请求应有两次尝试，actual=%d", len(logs.logs))
+	}
+	for i := 0; i < len(logs.logs); i += 2 {
+		failed, retried := logs.logs[i], logs.logs[i+1]
+		if failed.Success || !retried.Success {
+			t.Fatalf("第 %d 个逻辑请求应先失败后成功", i/2)
+		}
+		if failed.SplitArm != retried.SplitArm {
+			t.Fatalf("第 %d 个逻辑请求的重试切换了分组: %s -> %s", i/2, failed.SplitArm, retried.SplitArm)
+		}
+	}
+}
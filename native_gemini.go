@@ -31,8 +31,8 @@ func (p *Portal) NativeGeminiGenerateContent(
 	p.logger.DebugContext(ctx, "request_started", "model", modelName)
 
 	return retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, modelName, "google", "generate")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, modelName, "google", "generate", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel) (*geminiTypes.Response, error) {
 			resp, err := p.request.Native(reqCtx, req, ch, modelName)
@@ -49,7 +49,7 @@ func (p *Portal) NativeGeminiGenerateContent(
 				"provider", "google",
 				"endpoint_variant", "generate",
 			)
-			return p.nativeGeminiCompatFallback(ctx, req)
+			return p.nativeGeminiCompatFallback(ctx, req, options)
 		}),
 	)
 }
@@ -76,8 +76,8 @@ func (p *Portal) NativeGeminiStreamGenerateContent(
 	p.logger.DebugContext(ctx, "request_started", "model", modelName)
 
 	return retryNativeStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, modelName, "google", "generate")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, modelName, "google", "generate", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
 			return p.request.NativeStream(reqCtx, req, ch, modelName, output)
@@ -89,7 +89,7 @@ func (p *Portal) NativeGeminiStreamGenerateContent(
 				"provider", "google",
 				"endpoint_variant", "generate",
 			)
-			return p.nativeGeminiStreamCompatFallback(ctx, req)
+			return p.nativeGeminiStreamCompatFallback(ctx, req, options)
 		}),
 	)
 }
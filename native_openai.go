@@ -30,8 +30,8 @@ func (p *Portal) NativeOpenAIChatCompletion(
 	options := applyNativeOptions(opts)
 
 	return retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, req.Model, "openai", "chat_completions")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, req.Model, "openai", "chat_completions", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel) (*openaiChat.Response, error) {
 			resp, err := p.request.Native(reqCtx, req, ch, req.Model)
@@ -48,7 +48,7 @@ func (p *Portal) NativeOpenAIChatCompletion(
 				"provider", "openai",
 				"endpoint_variant", "chat_completions",
 			)
-			return p.nativeOpenAIChatCompatFallback(ctx, req)
+			return p.nativeOpenAIChatCompatFallback(ctx, req, options)
 		}),
 	)
 }
@@ -73,8 +73,8 @@ func (p *Portal) NativeOpenAIChatCompletionStream(
 	options := applyNativeOptions(opts)
 
 	return retryNativeStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, req.Model, "openai", "chat_completions")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, req.Model, "openai", "chat_completions", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
 			return p.request.NativeStream(reqCtx, req, ch, req.Model, output)
@@ -86,7 +86,7 @@ func (p *Portal) NativeOpenAIChatCompletionStream(
 				"provider", "openai",
 				"endpoint_variant", "chat_completions",
 			)
-			return p.nativeOpenAIChatStreamCompatFallback(ctx, req)
+			return p.nativeOpenAIChatStreamCompatFallback(ctx, req, options)
 		}),
 	)
 }
@@ -118,8 +118,8 @@ func (p *Portal) NativeOpenAIResponses(
 	options := applyNativeOptions(opts)
 
 	return retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, modelName, "openai", "responses")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, modelName, "openai", "responses", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel) (*openaiResponses.Response, error) {
 			resp, err := p.request.Native(reqCtx, req, ch, modelName)
@@ -136,7 +136,7 @@ func (p *Portal) NativeOpenAIResponses(
 				"provider", "openai",
 				"endpoint_variant", "responses",
 			)
-			return p.nativeOpenAIResponsesCompatFallback(ctx, req)
+			return p.nativeOpenAIResponsesCompatFallback(ctx, req, options)
 		}),
 	)
 }
@@ -167,8 +167,8 @@ func (p *Portal) NativeOpenAIResponsesStream(
 	options := applyNativeOptions(opts)
 
 	return retryNativeStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
-			return p.routing.GetChannelByProvider(ctx, modelName, "openai", "responses")
+		func(ctx context.Context, extra ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannelByProvider(ctx, modelName, "openai", "responses", append(nativeSelectOptions(options), extra...)...)
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
 			return p.request.NativeStream(reqCtx, req, ch, modelName, output)
@@ -180,7 +180,7 @@ func (p *Portal) NativeOpenAIResponsesStream(
 				"provider", "openai",
 				"endpoint_variant", "responses",
 			)
-			return p.nativeOpenAIResponsesStreamCompatFallback(ctx, req)
+			return p.nativeOpenAIResponsesStreamCompatFallback(ctx, req, options)
 		}),
 	)
 }
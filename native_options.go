@@ -5,7 +5,11 @@ type NativeOption func(*nativeOptions)
 
 // nativeOptions 存储原生请求的所有可选配置。
 type nativeOptions struct {
-	compatMode bool // 是否启用兼容模式
+	compatMode      bool   // 是否启用兼容模式
+	affinityKey     string // 会话亲和键
+	estimatedTokens int    // 预估 Token 数
+
+	labelConstraints map[string]string // 标签约束
 }
 
 // applyNativeOptions 应用所有选项并返回配置。
@@ -26,3 +30,32 @@ func WithCompatMode() NativeOption {
 		o.compatMode = true
 	}
 }
+
+// WithAffinityKey 指定会话亲和键。
+//
+// 相同亲和键的请求会被一致性哈希选择器稳定地路由到同一通道，
+// 以提高供应商侧提示词缓存的命中率。兼容模式下优先于 Config.AffinityKey 提取的结果。
+func WithAffinityKey(key string) NativeOption {
+	return func(o *nativeOptions) {
+		o.affinityKey = key
+	}
+}
+
+// WithEstimatedTokens 指定本次请求的预估 Token 数。
+//
+// 用于平台/密钥配置了 TPM 时的本地限流预扣，请求结束后按实际用量校正。
+func WithEstimatedTokens(tokens int) NativeOption {
+	return func(o *nativeOptions) {
+		o.estimatedTokens = tokens
+	}
+}
+
+// WithLabelConstraints 指定本次请求的路由标签约束。
+//
+// 请求只会被路由到标签（平台/端点的 Labels）包含全部键值对的通道，
+// 没有通道满足时返回 ErrCodeNoHealthyChannel。兼容模式下与 Config.LabelConstraints 提取的约束合并，同名标签以本选项为准。
+func WithLabelConstraints(labels map[string]string) NativeOption {
+	return func(o *nativeOptions) {
+		o.labelConstraints = labels
+	}
+}
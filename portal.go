@@ -8,6 +8,7 @@ import (
 	"github.com/MeowSalty/portal/middleware"
 	"github.com/MeowSalty/portal/request"
 	"github.com/MeowSalty/portal/routing"
+	"github.com/MeowSalty/portal/routing/health"
 	"github.com/MeowSalty/portal/routing/selector"
 	"github.com/MeowSalty/portal/session"
 )
@@ -29,31 +30,140 @@ func New(cfg Config) (*Portal, error) {
 	rootLog := log.WithGroup("portal")
 	portalLog := rootLog.With("component", "portal")
 	requestLog := rootLog.WithGroup("request").With("component", "request")
+	probeLog := rootLog.WithGroup("probe").With("component", "probe")
 
 	// 初始化全局默认日志记录器
 	logger.SetDefault(rootLog)
 
+	retryPolicy := DefaultRetryPolicy()
+	if cfg.RetryPolicy != nil {
+		if err := cfg.RetryPolicy.Validate(); err != nil {
+			return nil, err
+		}
+		retryPolicy = *cfg.RetryPolicy
+	}
+
+	selectorType := cfg.SelectorType
+	if cfg.Selector == nil && selectorType == "" {
+		selectorType = selector.LRUSelector
+	}
+
+	healthStorage := cfg.HealthStorage
+	if healthStorage == nil {
+		healthStorage = health.NewMemoryStorage(0)
+	}
+
 	routing, err := routing.New(context.TODO(), routing.Config{
 		PlatformRepo:  cfg.PlatformRepo,
 		ModelRepo:     cfg.ModelRepo,
 		KeyRepo:       cfg.KeyRepo,
-		HealthStorage: cfg.HealthStorage,
-		Selector:      selector.NewLRUSelector(),
+		HealthStorage: healthStorage,
+		Selector:      cfg.Selector,
+		SelectorType:  selectorType,
+		ModelResolver: cfg.ModelResolver,
+		Cache:         cfg.Cache,
+		Health:        cfg.Health,
+		TrafficSplits: cfg.TrafficSplits,
 	})
 	if err != nil {
 		return nil, err
 	}
+
+	affinityKey := cfg.AffinityKey
+	if affinityKey == nil {
+		affinityKey = DefaultAffinityKey()
+	}
+
+	labelConstraints := cfg.LabelConstraints
+	if labelConstraints == nil {
+		labelConstraints = DefaultLabelConstraints()
+	}
+
+	tokenEstimator := cfg.TokenEstimator
+	if tokenEstimator == nil {
+		tokenEstimator = EstimatePromptTokens
+	}
+
 	portal := &Portal{
 		session:    session.New(),
 		routing:    routing,
 		request:    request.New(cfg.LogRepo, requestLog),
 		logger:     portalLog,
 		middleware: middleware.NewChain(cfg.Middlewares...),
+
+		affinityKey:      affinityKey,
+		labelConstraints: labelConstraints,
+		tokenEstimator:   tokenEstimator,
+		modelFallbacks:   cloneModelFallbacks(cfg.ModelFallbacks),
+		retryPolicy:      retryPolicy,
+	}
+
+	if cfg.HealthProber != nil {
+		prober, err := routing.NewProber(
+			channelProber(request.New(nil, probeLog), cfg.HealthProber.Payloads),
+			health.ProberConfig{
+				Interval:    cfg.HealthProber.Interval,
+				Concurrency: cfg.HealthProber.Concurrency,
+				Timeout:     cfg.HealthProber.Timeout,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		prober.Start()
+		portal.prober = prober
 	}
 	return portal, nil
 }
 
+// InFlightStats 返回各平台/模型/密钥当前在途请求数的快照
+func (p *Portal) InFlightStats() routing.InFlightStats {
+	return p.routing.InFlightStats()
+}
+
+// Invalidate 使路由缓存失效，管理端修改平台、模型或密钥后调用可使修改立即生效
+func (p *Portal) Invalidate(inv routing.Invalidation) {
+	p.routing.Invalidate(inv)
+}
+
+// Explain 解释指定模型的通道选择过程（dry-run），不会选中通道或更新最近尝试时间
+//
+// endpointType 与 endpointVariant 均为空时使用默认端点。
+func (p *Portal) Explain(
+	ctx context.Context,
+	model, endpointType, endpointVariant string,
+	opts ...routing.SelectOption,
+) (*routing.Explanation, error) {
+	return p.routing.Explain(ctx, model, endpointType, endpointVariant, opts...)
+}
+
+// SetSelector 在运行时替换通道选择器，sel 为空时按 selectorType 创建（须已注册）
+func (p *Portal) SetSelector(sel selector.Selector, selectorType selector.SelectorType) error {
+	return p.routing.SetSelector(sel, selectorType)
+}
+
+// SetHealthConfig 在运行时调整退避策略、探测开关、失败阈值、半开试探请求数与失败判定器
+func (p *Portal) SetHealthConfig(cfg routing.HealthConfig) error {
+	return p.routing.SetHealthConfig(cfg)
+}
+
+// SubscribeHealthEvents 订阅平台/模型/密钥的健康状态变更事件
+//
+// 事件以非阻塞方式投递，buffer（<= 0 时使用 health.DefaultEventBuffer）已满时丢弃并计入 Subscription.Dropped。
+// 不再需要时须调用 Subscription.Close。
+func (p *Portal) SubscribeHealthEvents(buffer int) *health.Subscription {
+	return p.routing.SubscribeHealthEvents(buffer)
+}
+
+// SetTrafficSplits 在运行时替换模型级流量切分规则，nil 表示关闭流量切分
+func (p *Portal) SetTrafficSplits(splits []routing.TrafficSplit) error {
+	return p.routing.SetTrafficSplits(splits)
+}
+
 // Close 关闭 Portal 实例，释放资源
 func (p *Portal) Close(timeout time.Duration) error {
+	if p.prober != nil {
+		p.prober.Stop()
+	}
 	return p.session.Shutdown(timeout)
 }
@@ -0,0 +1,53 @@
+package portal
+
+import (
+	"context"
+	"time"
+
+	"github.com/MeowSalty/portal/request"
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// ProbePayloadFunc 为通道构建健康探测请求
+type ProbePayloadFunc func(ch *routing.Channel) *types.RequestContract
+
+// HealthProberConfig 后台主动健康探测配置
+//
+// 启用后，处于警告或不可用状态、且退避时间已过的平台/模型/密钥会被周期性地探测，
+// 探测成功即恢复可用，不再依赖真实用户流量或手动调用 ResetHealth。
+// 探测请求不写入请求日志，日志输出到独立的 probe 分组。
+type HealthProberConfig struct {
+	Interval    time.Duration // 探测周期（<= 0 时为 30 秒）
+	Concurrency int           // 同时进行的探测数（<= 0 时为 4）
+	Timeout     time.Duration // 单次探测超时（<= 0 时为 10 秒）
+
+	// Payloads 按端点类型（Channel.Provider，如 "openai"）自定义探测请求，
+	// 未配置的类型使用 DefaultProbePayload
+	Payloads map[string]ProbePayloadFunc
+}
+
+// DefaultProbePayload 默认探测请求：单条用户消息，最多输出 1 个 Token
+func DefaultProbePayload(ch *routing.Channel) *types.RequestContract {
+	text := "ping"
+	maxTokens := 1
+	return &types.RequestContract{
+		Model: ch.ModelName,
+		Messages: []types.Message{{
+			Role:    "user",
+			Content: types.Content{Text: &text},
+		}},
+		MaxOutputTokens: &maxTokens,
+	}
+}
+
+// channelProber 返回通过适配器发送探测请求的函数
+func channelProber(req *request.Request, payloads map[string]ProbePayloadFunc) routing.ChannelProbeFunc {
+	return func(ctx context.Context, ch *routing.Channel) error {
+		payload := DefaultProbePayload
+		if custom, ok := payloads[ch.Provider]; ok && custom != nil {
+			payload = custom
+		}
+		return req.Probe(ctx, payload(ch), ch)
+	}
+}
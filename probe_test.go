@@ -0,0 +1,60 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MeowSalty/portal/request"
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+func TestChannelProber_SendsPayloadWithoutRequestLog(t *testing.T) {
+	var raw string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		raw = string(data)
+		_ = json.Unmarshal(data, &body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"probe","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"p"},"finish_reason":"length"}]}`)
+	}))
+	defer server.Close()
+
+	ch := &routing.Channel{
+		Provider:   "openai",
+		BaseURL:    server.URL,
+		ModelName:  "gpt-4o",
+		APIKey:     "sk-test",
+		APIVariant: "chat_completions",
+	}
+
+	// 请求日志仓库为 nil：探测若写入请求日志会直接失败
+	probe := channelProber(request.New(nil, nil), nil)
+	if err := probe(context.Background(), ch); err != nil {
+		t.Fatalf("探测失败: %v", err)
+	}
+	if body["model"] != "gpt-4o" || body["max_tokens"] == nil && body["max_completion_tokens"] == nil {
+		t.Fatalf("默认探测请求应指定模型并限制输出 Token，actual=%v", body)
+	}
+
+	custom := channelProber(request.New(nil, nil), map[string]ProbePayloadFunc{
+		"openai": func(ch *routing.Channel) *types.RequestContract {
+			text := "health-check"
+			req := DefaultProbePayload(ch)
+			req.Messages[0].Content.Text = &text
+			return req
+		},
+	})
+	if err := custom(context.Background(), ch); err != nil {
+		t.Fatalf("探测失败: %v", err)
+	}
+	if !strings.Contains(raw, "health-check") {
+		t.Fatalf("应使用按端点类型配置的探测请求，actual=%s", raw)
+	}
+}
@@ -35,8 +35,10 @@ func (p *Anthropic) Name() string {
 
 // CreateRequest 创建 Anthropic 请求
 func (p *Anthropic) CreateRequest(request *adapterTypes.RequestContract, channel *routing.Channel) (interface{}, error) {
-	request.Model = channel.ModelName
-	return converter.RequestFromContract(request)
+	// 复制请求契约，避免改写调用方的模型名称（重试时需要原始名称）
+	contract := *request
+	contract.Model = channel.ModelName
+	return converter.RequestFromContract(&contract)
 }
 
 // ParseResponse 解析 Anthropic 响应
@@ -121,8 +123,9 @@ func (p *Anthropic) SupportsNative() bool {
 // BuildNativeRequest 构建原生请求
 func (p *Anthropic) BuildNativeRequest(channel *routing.Channel, payload any) (body any, err error) {
 	if req, ok := payload.(*anthropicTypes.Request); ok {
-		req.Model = channel.ModelName
-		return req, nil
+		body := *req
+		body.Model = channel.ModelName
+		return &body, nil
 	}
 	return nil, errors.New(errors.ErrCodeInvalidArgument, "无效的请求类型，期望 anthropicTypes.Request")
 }
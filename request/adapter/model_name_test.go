@@ -0,0 +1,79 @@
+package adapter
+
+import (
+	"testing"
+
+	anthropicTypes "github.com/MeowSalty/portal/request/adapter/anthropic/types"
+	openaiChat "github.com/MeowSalty/portal/request/adapter/openai/types/chat"
+	openaiResponses "github.com/MeowSalty/portal/request/adapter/openai/types/responses"
+	adapterTypes "github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// 构建上游请求时应使用通道的上游模型名称，且不能改写调用方请求中的原始模型名称，
+// 否则重试时会按上游名称重新路由，日志中的原始模型名称也会丢失。
+func TestBuildRequest_KeepsOriginalModelName(t *testing.T) {
+	channel := &routing.Channel{ModelName: "claude-sonnet-4-20250514", APIVariant: "chat_completions"}
+
+	contract := &adapterTypes.RequestContract{Model: "claude-latest"}
+	if _, err := NewAnthropicProvider().CreateRequest(contract, channel); err != nil {
+		t.Fatalf("创建请求失败: %v", err)
+	}
+	if contract.Model != "claude-latest" {
+		t.Fatalf("请求契约的模型名称不应被改写，actual=%s", contract.Model)
+	}
+
+	anthropicReq := &anthropicTypes.Request{Model: "claude-latest"}
+	body, err := NewAnthropicProvider().BuildNativeRequest(channel, anthropicReq)
+	if err != nil {
+		t.Fatalf("构建原生请求失败: %v", err)
+	}
+	if got := body.(*anthropicTypes.Request).Model; got != channel.ModelName {
+		t.Fatalf("原生请求应使用上游模型名称，actual=%s", got)
+	}
+	if anthropicReq.Model != "claude-latest" {
+		t.Fatalf("原生请求载荷不应被改写，actual=%s", anthropicReq.Model)
+	}
+
+	chatReq := &openaiChat.Request{Model: "gpt-latest"}
+	body, err = NewOpenAIProvider().BuildNativeRequest(&routing.Channel{ModelName: "gpt-4o", APIVariant: "chat_completions"}, chatReq)
+	if err != nil {
+		t.Fatalf("构建原生请求失败: %v", err)
+	}
+	if got := body.(*openaiChat.Request).Model; got != "gpt-4o" || chatReq.Model != "gpt-latest" {
+		t.Fatalf("OpenAI 原生请求模型名称处理错误，body=%s original=%s", got, chatReq.Model)
+	}
+}
+
+// Responses 原生请求保留客户端指定的模型名称，仅在解析器映射或跨模型回退时改写为上游名称。
+func TestBuildNativeRequest_ResponsesOverridesOnlyMappedModel(t *testing.T) {
+	provider := NewOpenAIProvider()
+	requested := "gpt-latest"
+
+	tests := []struct {
+		name    string
+		channel *routing.Channel
+		model   *string
+		want    string
+	}{
+		{name: "未映射保留请求模型", channel: &routing.Channel{ModelName: "gpt-4o", APIVariant: "responses"}, model: &requested, want: "gpt-latest"},
+		{name: "解析器映射", channel: &routing.Channel{ModelName: "gpt-4o", APIVariant: "responses", ResolvedFrom: requested}, model: &requested, want: "gpt-4o"},
+		{name: "跨模型回退", channel: &routing.Channel{ModelName: "gpt-4o", APIVariant: "responses", FallbackFrom: requested}, model: &requested, want: "gpt-4o"},
+		{name: "未指定模型", channel: &routing.Channel{ModelName: "gpt-4o", APIVariant: "responses"}, want: "gpt-4o"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &openaiResponses.Request{Model: tt.model}
+			body, err := provider.BuildNativeRequest(tt.channel, req)
+			if err != nil {
+				t.Fatalf("构建原生请求失败: %v", err)
+			}
+			if got := body.(*openaiResponses.Request).Model; got == nil || *got != tt.want {
+				t.Fatalf("期望模型 %s，actual=%v", tt.want, got)
+			}
+			if req.Model != tt.model {
+				t.Fatal("原生请求载荷不应被改写")
+			}
+		})
+	}
+}
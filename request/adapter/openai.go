@@ -38,12 +38,14 @@ func (p *OpenAI) Name() string {
 
 // CreateRequest 创建 OpenAI 请求
 func (p *OpenAI) CreateRequest(request *adapterTypes.RequestContract, channel *routing.Channel) (interface{}, error) {
-	request.Model = channel.ModelName
+	// 复制请求契约，避免改写调用方的模型名称（重试时需要原始名称）
+	contract := *request
+	contract.Model = channel.ModelName
 	style := resolveAPIVariant(channel)
 	if style == "responses" {
-		return responsesConverter.RequestFromContract(request)
+		return responsesConverter.RequestFromContract(&contract)
 	}
-	return chatConverter.RequestFromContract(request)
+	return chatConverter.RequestFromContract(&contract)
 }
 
 // ParseResponse 解析 OpenAI 响应
@@ -140,18 +142,21 @@ func (p *OpenAI) BuildNativeRequest(channel *routing.Channel, payload any) (body
 	switch style {
 	case "chat_completions":
 		if req, ok := payload.(*openaiChat.Request); ok {
-			req.Model = channel.ModelName
-			return req, nil
+			body := *req
+			body.Model = channel.ModelName
+			return &body, nil
 		}
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "无效的请求类型，期望 openaiChat.Request")
 
 	case "responses":
 		if req, ok := payload.(*openaiResponses.Request); ok {
-			if req.Model == nil {
+			// 仅在请求未指定模型，或请求模型被解析器映射、跨模型回退时改写为上游模型名称
+			body := *req
+			if body.Model == nil || channel.ResolvedFrom != "" || channel.FallbackFrom != "" {
 				model := channel.ModelName
-				req.Model = &model
+				body.Model = &model
 			}
-			return req, nil
+			return &body, nil
 		}
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "无效的请求类型，期望 openaiResponses.Request")
 
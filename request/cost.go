@@ -0,0 +1,137 @@
+package request
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/MeowSalty/portal/routing"
+)
+
+// usageBreakdown 供应商用量细分中的缓存命中与推理 Token 数
+//
+// 各供应商的统计口径不同：OpenAI 与 Gemini 的输入 Token 数包含缓存命中部分，
+// Anthropic 的 cache_read_input_tokens 不计入 input_tokens；
+// OpenAI 的输出 Token 数包含推理部分，Gemini 的 thoughts_token_count 不计入 candidates。
+type usageBreakdown struct {
+	cached            int
+	cachedInInput     bool // 缓存命中部分是否已计入输入 Token 数
+	reasoning         int
+	reasoningInOutput bool // 推理部分是否已计入输出 Token 数
+}
+
+// parseUsageBreakdown 从用量扩展字段（ResponseUsage.Extras 或 StreamUsagePayload.Raw）中提取用量细分
+//
+// 扩展字段的键可能带有供应商前缀（如 "openai.chat.prompt_tokens_details"），按最后一段匹配。
+func parseUsageBreakdown(extras map[string]interface{}) usageBreakdown {
+	var breakdown usageBreakdown
+	for key, value := range extras {
+		if idx := strings.LastIndex(key, "."); idx >= 0 {
+			key = key[idx+1:]
+		}
+		switch key {
+		case "prompt_tokens_details", "input_tokens_details":
+			if cached := detailTokens(value, "cached_tokens"); cached > 0 {
+				breakdown.cached, breakdown.cachedInInput = cached, true
+			}
+		case "completion_tokens_details", "output_tokens_details":
+			if reasoning := detailTokens(value, "reasoning_tokens"); reasoning > 0 {
+				breakdown.reasoning, breakdown.reasoningInOutput = reasoning, true
+			}
+		case "cached_content_token_count":
+			if cached := tokenCount(value); cached > 0 {
+				breakdown.cached, breakdown.cachedInInput = cached, true
+			}
+		case "cache_read_input_tokens":
+			if cached := tokenCount(value); cached > 0 {
+				breakdown.cached, breakdown.cachedInInput = cached, false
+			}
+		case "thoughts_token_count":
+			if reasoning := tokenCount(value); reasoning > 0 {
+				breakdown.reasoning, breakdown.reasoningInOutput = reasoning, false
+			}
+		}
+	}
+	return breakdown
+}
+
+// detailTokens 读取用量明细对象中的指定 Token 数
+func detailTokens(value interface{}, field string) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal(data, &details); err != nil {
+		return 0
+	}
+	return tokenCount(details[field])
+}
+
+// tokenCount 将数值型扩展字段转换为 Token 数
+func tokenCount(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+// recordUsageBreakdown 记录用量细分到 RequestLog
+//
+// 流式响应的用量可能分多次上报（如 Anthropic 在 message_start 中上报缓存命中数），
+// 因此只覆盖本次上报中出现的细分字段。
+func recordUsageBreakdown(log *RequestLog, extras map[string]interface{}) {
+	breakdown := parseUsageBreakdown(extras)
+	if breakdown.cached > 0 {
+		cached := breakdown.cached
+		log.usage.cached, log.usage.cachedInInput = cached, breakdown.cachedInInput
+		log.CachedTokens = &cached
+	}
+	if breakdown.reasoning > 0 {
+		reasoning := breakdown.reasoning
+		log.usage.reasoning, log.usage.reasoningInOutput = reasoning, breakdown.reasoningInOutput
+		log.ReasoningTokens = &reasoning
+	}
+}
+
+// fillRequestLogCost 按通道价格与 Token 用量估算请求费用
+//
+// 通道未配置价格或没有用量信息时不填充。
+func fillRequestLogCost(log *RequestLog) {
+	if log.channel == nil || log.channel.Pricing == nil {
+		return
+	}
+	if log.PromptTokens == nil && log.CompletionTokens == nil {
+		return
+	}
+
+	usage := routing.TokenUsage{
+		CachedInput: log.usage.cached,
+		Reasoning:   log.usage.reasoning,
+	}
+	if log.PromptTokens != nil {
+		usage.Input = *log.PromptTokens
+	}
+	if log.CompletionTokens != nil {
+		usage.Output = *log.CompletionTokens
+	}
+	if log.usage.cachedInInput {
+		usage.Input = max(usage.Input-usage.CachedInput, 0)
+	}
+	if log.usage.reasoningInOutput {
+		usage.Output = max(usage.Output-usage.Reasoning, 0)
+	}
+
+	cost := log.channel.Pricing.Cost(usage)
+	log.EstimatedCost = &cost
+}
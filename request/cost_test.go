@@ -0,0 +1,54 @@
+package request
+
+import (
+	"math"
+	"testing"
+
+	chatTypes "github.com/MeowSalty/portal/request/adapter/openai/types/chat"
+	"github.com/MeowSalty/portal/routing"
+)
+
+func TestFillRequestLogCost(t *testing.T) {
+	prompt, completion := 1_000_000, 1_000_000
+	log := &RequestLog{
+		PromptTokens:     &prompt,
+		CompletionTokens: &completion,
+		channel:          &routing.Channel{Pricing: &routing.Pricing{Input: 2, Output: 8, CachedInput: 1, Reasoning: 10}},
+	}
+	recordUsageBreakdown(log, map[string]interface{}{
+		"openai.chat.prompt_tokens_details":     &chatTypes.PromptTokensDetails{CachedTokens: 400_000},
+		"openai.chat.completion_tokens_details": &chatTypes.CompletionTokensDetails{ReasoningTokens: 500_000},
+	})
+	fillRequestLogCost(log)
+
+	// 未命中缓存输入 0.6M×2 + 缓存 0.4M×1 + 输出 0.5M×8 + 推理 0.5M×10
+	if log.EstimatedCost == nil || math.Abs(*log.EstimatedCost-10.6) > 1e-9 {
+		t.Fatalf("费用估算错误，actual=%v", log.EstimatedCost)
+	}
+	if log.CachedTokens == nil || *log.CachedTokens != 400_000 || log.ReasoningTokens == nil || *log.ReasoningTokens != 500_000 {
+		t.Fatalf("应记录缓存命中与推理 Token 数，cached=%v reasoning=%v", log.CachedTokens, log.ReasoningTokens)
+	}
+}
+
+func TestFillRequestLogCost_AnthropicCacheRead(t *testing.T) {
+	prompt, completion := 1_000_000, 0
+	log := &RequestLog{
+		PromptTokens:     &prompt,
+		CompletionTokens: &completion,
+		channel:          &routing.Channel{Pricing: &routing.Pricing{Input: 3, Output: 15, CachedInput: 0.3}},
+	}
+	recordUsageBreakdown(log, map[string]interface{}{"cache_read_input_tokens": 1_000_000})
+	recordUsageBreakdown(log, map[string]interface{}{})
+	fillRequestLogCost(log)
+
+	// Anthropic 的缓存命中不计入 input_tokens：3 + 0.3
+	if log.EstimatedCost == nil || math.Abs(*log.EstimatedCost-3.3) > 1e-9 {
+		t.Fatalf("费用估算错误，actual=%v", log.EstimatedCost)
+	}
+
+	unpriced := &RequestLog{PromptTokens: &prompt, channel: &routing.Channel{}}
+	fillRequestLogCost(unpriced)
+	if unpriced.EstimatedCost != nil {
+		t.Fatal("通道未配置价格时不应估算费用")
+	}
+}
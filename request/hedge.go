@@ -0,0 +1,64 @@
+package request
+
+import (
+	"context"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// hedgeAttemptContextKey 对冲尝试信息的上下文键
+type hedgeAttemptContextKey struct{}
+
+// hedgeAttempt 对冲尝试信息
+type hedgeAttempt struct {
+	groupID string // 对冲组 ID
+	isHedge bool   // 是否为对冲请求（主请求为 false）
+}
+
+// WithHedgeAttempt 返回标记了对冲尝试信息的上下文
+//
+// 同一逻辑请求的主请求与对冲请求使用相同的 groupID，
+// 请求日志会据此写入 HedgeGroupID 与 IsHedge，便于关联同组的日志记录。
+func WithHedgeAttempt(ctx context.Context, groupID string, isHedge bool) context.Context {
+	return context.WithValue(ctx, hedgeAttemptContextKey{}, hedgeAttempt{groupID: groupID, isHedge: isHedge})
+}
+
+// applyHedgeAttempt 将上下文中的对冲尝试信息写入请求日志
+func applyHedgeAttempt(ctx context.Context, log *RequestLog) {
+	if ctx == nil || log == nil {
+		return
+	}
+	attempt, ok := ctx.Value(hedgeAttemptContextKey{}).(hedgeAttempt)
+	if !ok {
+		return
+	}
+	log.HedgeGroupID = attempt.groupID
+	log.IsHedge = attempt.isHedge
+}
+
+// gatewayCancelCause 返回网关主动取消请求时记录的原因
+//
+// 网关取消（如对冲落选）通过 context.WithCancelCause 传递原因，
+// 上下文未被网关取消时返回 nil。
+func gatewayCancelCause(ctx context.Context) error {
+	if ctx == nil || ctx.Err() == nil {
+		return nil
+	}
+	if cause := context.Cause(ctx); errors.IsGatewayCanceled(cause) {
+		return cause
+	}
+	return nil
+}
+
+// withGatewayCancelCause 在上下文被网关取消时以取消原因替换请求错误
+//
+// 避免被网关取消的请求在日志中被误记为客户端取消或上游失败。
+func withGatewayCancelCause(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if cause := gatewayCancelCause(ctx); cause != nil {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package request
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func TestWithGatewayCancelCause_HedgeLoser(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(WithHedgeAttempt(context.Background(), "group-1", true))
+	cancel(errors.NewGatewayCanceled("hedge_lost"))
+
+	err := withGatewayCancelCause(ctx, context.Canceled)
+	if !errors.IsGatewayCanceled(err) {
+		t.Fatalf("网关取消应以取消原因替换请求错误，actual=%v", err)
+	}
+
+	log := &RequestLog{}
+	applyHedgeAttempt(ctx, log)
+	fillRequestLogCancelSource(log, err)
+
+	if log.HedgeGroupID != "group-1" || !log.IsHedge {
+		t.Fatalf("对冲信息写入错误，group=%q is_hedge=%v", log.HedgeGroupID, log.IsHedge)
+	}
+	if log.CancelSource == nil || *log.CancelSource != "gateway" {
+		t.Fatalf("cancel_source 期望 gateway，actual=%v", log.CancelSource)
+	}
+}
+
+func TestWithGatewayCancelCause_ClientCancelUnchanged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := withGatewayCancelCause(ctx, context.Canceled); err != context.Canceled {
+		t.Fatalf("非网关取消应保持原错误，actual=%v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	portalErrors "github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing"
 )
 
 const (
@@ -33,6 +34,14 @@ type RequestLog struct {
 	OriginalModelName string    `json:"original_model_name,omitempty"` // 原始模型名称（用户请求中的模型名称）
 	IsStream          bool      `json:"is_stream"`
 	IsNative          bool      `json:"is_native"`
+	IsFallback        bool      `json:"is_fallback"` // 是否由跨模型回退链中的模型服务（实际服务模型见 ModelName）
+
+	// 对冲请求信息：同一逻辑请求的主请求与对冲请求共享 HedgeGroupID
+	HedgeGroupID string `json:"hedge_group_id,omitempty"` // 对冲组 ID（未启用对冲时为空）
+	IsHedge      bool   `json:"is_hedge"`                 // 是否为对冲请求（主请求为 false）
+
+	// 流量切分信息：用于对比灰度分组与其余流量的错误率和延迟
+	SplitArm string `json:"split_arm,omitempty"` // 流量切分分组（未命中流量切分规则时为空）
 
 	// 通道信息
 	PlatformID uint `json:"platform_id"` // 平台 ID
@@ -85,13 +94,23 @@ type RequestLog struct {
 	ResponseBodyRaw    *string `json:"response_body_raw,omitempty"`
 
 	// Token 使用统计
-	PromptTokens     *int `json:"prompt_tokens"`     // 提示 Token 数
-	CompletionTokens *int `json:"completion_tokens"` // 完成 Token 数
-	TotalTokens      *int `json:"total_tokens"`      // 总 Token 数
+	PromptTokens     *int `json:"prompt_tokens"`              // 提示 Token 数
+	CompletionTokens *int `json:"completion_tokens"`          // 完成 Token 数
+	TotalTokens      *int `json:"total_tokens"`               // 总 Token 数
+	CachedTokens     *int `json:"cached_tokens,omitempty"`    // 缓存命中输入 Token 数（供应商上报时）
+	ReasoningTokens  *int `json:"reasoning_tokens,omitempty"` // 推理 Token 数（供应商上报时）
+
+	// 费用估算：按通道价格（routing.Pricing）与 Token 用量计算，用于财务对账；
+	// 通道未配置价格或没有用量信息时为空
+	EstimatedCost *float64 `json:"estimated_cost,omitempty"`
 
 	// 以下字段仅用于运行时日志上下文，不持久化到存储。
 	errorClassifyExplain      string
 	errorClassifyMatchedRules string
+	usage                     usageBreakdown
+
+	// channel 为本次请求使用的通道，用于在请求结束时反馈耗时与 Token 用量统计。
+	channel *routing.Channel
 }
 
 // recordRequestLog 记录请求统计信息
@@ -158,6 +177,24 @@ func (p *Request) recordRequestLog(
 	}
 	log.Debug("请求结束摘要", debugArgs...)
 
+	// 将成功请求的耗时反馈给通道延迟统计
+	if success && requestLog.channel != nil {
+		requestLog.channel.ObserveLatency(requestLog.Duration, requestLog.FirstByteTime)
+	}
+
+	// 以实际 Token 用量校正本地 TPM 限流；失败且无用量时退还预扣额度
+	if requestLog.channel != nil {
+		switch {
+		case requestLog.TotalTokens != nil:
+			requestLog.channel.ReportUsage(*requestLog.TotalTokens)
+		case !success:
+			requestLog.channel.ReportUsage(0)
+		}
+	}
+
+	// 按通道价格估算请求费用
+	fillRequestLogCost(requestLog)
+
 	// 保存到数据库
 	err := p.repo.CreateRequestLog(context.Background(), requestLog)
 	if err != nil {
@@ -579,6 +616,11 @@ func fillRequestLogCancelSource(log *RequestLog, err error) {
 			source := "server"
 			log.CancelSource = &source
 		}
+	case portalErrors.ErrorFromGateway:
+		if log.CancelSource == nil {
+			source := "gateway"
+			log.CancelSource = &source
+		}
 	default:
 		if log.CancelSource == nil {
 			source := "unknown"
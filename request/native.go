@@ -62,7 +62,10 @@ func (p *Request) Native(
 		PlatformID:        channel.PlatformID,
 		APIKeyID:          channel.APIKeyID,
 		ModelID:           channel.ModelID,
+		SplitArm:          channel.SplitArm,
+		channel:           channel,
 	}
+	applyHedgeAttempt(ctx, requestLog)
 	log.DebugContext(ctx, "创建请求日志")
 
 	// 执行原生请求
@@ -80,6 +83,7 @@ func (p *Request) Native(
 	)
 
 	if err != nil {
+		err = withGatewayCancelCause(ctx, err)
 		if errors.IsCanceled(err) {
 			err = normalizeNonStreamCanceledError(err)
 		}
@@ -160,11 +164,14 @@ func (p *Request) NativeStream(
 		PlatformID:        channel.PlatformID,
 		APIKeyID:          channel.APIKeyID,
 		ModelID:           channel.ModelID,
+		SplitArm:          channel.SplitArm,
+		channel:           channel,
 	}
+	applyHedgeAttempt(ctx, requestLog)
 	log.DebugContext(ctx, "创建请求日志")
 
 	// 创建 RequestLogHooks 实例用于记录流式响应统计
-	hooks := &RequestLogHooks{log: requestLog, request: p}
+	hooks := &RequestLogHooks{ctx: ctx, log: requestLog, request: p}
 
 	// 执行原生流式请求
 	log.DebugContext(ctx, "执行原生流式请求")
@@ -173,6 +180,7 @@ func (p *Request) NativeStream(
 
 	// 只在 adapter.NativeStream 调用失败时返回错误
 	if err != nil {
+		err = withGatewayCancelCause(ctx, err)
 		if errors.IsCanceled(err) {
 			err = errors.NormalizeCanceled(err)
 		}
@@ -0,0 +1,45 @@
+package request
+
+import (
+	"context"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/request/adapter/types"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// Probe 通过通道发送一次健康探测请求
+//
+// 探测请求经由与通道匹配的适配器发送，但不创建请求日志、不反馈延迟与 Token 用量，
+// 避免探测流量混入用户请求统计。健康状态由调用方根据返回值更新。
+// 建议使用独立日志分组的 Request 实例发送探测，使探测日志与用户请求日志分离。
+func (p *Request) Probe(
+	ctx context.Context,
+	request *types.RequestContract,
+	channel *routing.Channel,
+) error {
+	log := p.logger.With(
+		"platform_type", channel.Provider,
+		"platform_id", channel.PlatformID,
+		"model_id", channel.ModelID,
+		"api_key_id", channel.APIKeyID,
+		"model_name", channel.ModelName,
+	)
+
+	adapter, err := p.getAdapter(channel.Provider)
+	if err != nil {
+		return errors.Wrap(errors.ErrCodeAdapterNotFound, "获取适配器失败", err).
+			WithContext("format", channel.Provider).
+			WithContext("error_from", string(errors.ErrorFromGateway))
+	}
+
+	start := time.Now()
+	_, err = adapter.ChatCompletion(ctx, request, channel)
+	if err != nil {
+		log.DebugContext(ctx, "健康探测失败", "duration", time.Since(start).String(), "error", err)
+		return err
+	}
+	log.DebugContext(ctx, "健康探测成功", "duration", time.Since(start).String())
+	return nil
+}
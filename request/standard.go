@@ -47,10 +47,14 @@ func (p *Request) ChatCompletion(
 		IsNative:          false,
 		ModelName:         channel.ModelName,
 		OriginalModelName: request.Model,
+		IsFallback:        channel.FallbackFrom != "",
 		PlatformID:        channel.PlatformID,
 		APIKeyID:          channel.APIKeyID,
 		ModelID:           channel.ModelID,
+		SplitArm:          channel.SplitArm,
+		channel:           channel,
 	}
+	applyHedgeAttempt(ctx, requestLog)
 	log.DebugContext(ctx, "创建请求日志")
 
 	// 执行请求
@@ -67,6 +71,7 @@ func (p *Request) ChatCompletion(
 	)
 
 	if err != nil {
+		err = withGatewayCancelCause(ctx, err)
 		if errors.IsCanceled(err) {
 			err = normalizeNonStreamCanceledError(err)
 		}
@@ -87,6 +92,7 @@ func (p *Request) ChatCompletion(
 		requestLog.PromptTokens = response.Usage.InputTokens
 		requestLog.CompletionTokens = response.Usage.OutputTokens
 		requestLog.TotalTokens = response.Usage.TotalTokens
+		recordUsageBreakdown(requestLog, response.Usage.Extras)
 
 		log.DebugContext(ctx, "记录 Token 使用情况",
 			"prompt_tokens", response.Usage.InputTokens,
@@ -95,6 +101,12 @@ func (p *Request) ChatCompletion(
 		)
 	}
 
+	// 跨模型回退时在响应中标明实际服务的模型
+	if channel.FallbackFrom != "" {
+		servedModel := channel.ModelName
+		response.Model = &servedModel
+	}
+
 	// 记录成功统计
 	requestLog.Success = true
 	ensureNonStreamDefaults(requestLog, true)
@@ -16,6 +16,8 @@ import (
 //
 // 该结构体持有对 RequestLog 和 Request 的引用，在流式响应的生命周期各个关键时机更新统计字段。
 type RequestLogHooks struct {
+	// ctx 为请求上下文，用于识别网关主动取消（如对冲落选）
+	ctx context.Context
 	// log 指向需要更新的请求日志记录
 	log *RequestLog
 	// request 指向 Request 实例，用于调用 recordRequestLog 方法
@@ -133,6 +135,13 @@ func (h *RequestLogHooks) OnError(err error) {
 		// 计算总耗时
 		h.log.Duration = time.Since(h.log.Timestamp)
 
+		// 网关主动取消时以取消原因为准，覆盖适配层推导的结束语义
+		if cause := gatewayCancelCause(h.ctx); cause != nil {
+			err = cause
+			h.log.CancelSource = nil
+			h.log.FinishStatus = nil
+		}
+
 		if h.log.IsStream {
 			ensureStreamErrorDefaults(h.log, err)
 		}
@@ -215,6 +224,10 @@ func streamTerminationFromError(err error) (finishStatus string, cancelSource st
 		return "timed_out", "deadline"
 	}
 
+	if errors.IsGatewayCanceled(err) {
+		return "canceled", "gateway"
+	}
+
 	if errors.IsCode(err, errors.ErrCodeCanceled) || errors.GetErrorFrom(err) == errors.ErrorFromServer {
 		return "canceled", "server"
 	}
@@ -288,14 +301,18 @@ func (p *Request) ChatCompletionStream(
 		IsNative:          false,
 		ModelName:         channel.ModelName,
 		OriginalModelName: request.Model,
+		IsFallback:        channel.FallbackFrom != "",
 		PlatformID:        channel.PlatformID,
 		APIKeyID:          channel.APIKeyID,
 		ModelID:           channel.ModelID,
+		SplitArm:          channel.SplitArm,
+		channel:           channel,
 	}
+	applyHedgeAttempt(ctx, requestLog)
 	log.DebugContext(ctx, "创建请求日志")
 
 	// 创建 RequestLogHooks 实例用于记录流式响应统计
-	hooks := &RequestLogHooks{log: requestLog, request: p}
+	hooks := &RequestLogHooks{ctx: ctx, log: requestLog, request: p}
 
 	// 创建内部流
 	log.DebugContext(ctx, "创建内部流通道")
@@ -304,6 +321,7 @@ func (p *Request) ChatCompletionStream(
 	log.DebugContext(ctx, "执行流式聊天完成请求")
 	err = adapter.ChatCompletionStream(ctx, request, channel, internalStream)
 	if err != nil {
+		err = withGatewayCancelCause(ctx, err)
 		if errors.IsCanceled(err) {
 			err = errors.NormalizeCanceled(err)
 		}
@@ -341,6 +359,7 @@ func (p *Request) handleStreamData(
 
 		// 检查错误
 		if err := p.checkResponseError(response); err != nil {
+			err = withGatewayCancelCause(ctx, err)
 			if errors.IsCanceled(err) {
 				err = errors.NormalizeCanceled(err)
 			}
@@ -375,6 +394,7 @@ func (p *Request) handleStreamData(
 			requestLog.CompletionTokens = response.Usage.OutputTokens
 			requestLog.PromptTokens = response.Usage.InputTokens
 			requestLog.TotalTokens = response.Usage.TotalTokens
+			recordUsageBreakdown(requestLog, response.Usage.Raw)
 
 			log.DebugContext(ctx, "更新 Token 使用情况",
 				"prompt_tokens", response.Usage.InputTokens,
@@ -392,8 +412,14 @@ func (p *Request) handleStreamData(
 			}
 		}
 
+		// 跨模型回退时在事件中标明实际服务的模型
+		if requestLog.IsFallback {
+			response.Model = requestLog.ModelName
+		}
+
 		// 发送响应
 		if err := p.sendResponse(ctx, output, response, requestLog); err != nil {
+			err = withGatewayCancelCause(ctx, err)
 			if errors.IsCanceled(err) {
 				err = errors.NormalizeCanceled(err)
 			}
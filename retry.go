@@ -9,11 +9,14 @@ import (
 )
 
 // channelFunc 获取通道的函数类型
-type channelFunc = func(ctx context.Context) (*routing.Channel, error)
+//
+// opts 为重试状态附加的选择约束（如排除已失败的密钥），实现需将其传给路由。
+type channelFunc = func(ctx context.Context, opts ...routing.SelectOption) (*routing.Channel, error)
 
 // retryNonStream 非流式重试通用函数
 //
 // 封装了获取通道 → 创建 logger → 会话包装 → 执行请求 → 重试决策 → 健康标记的完整流程。
+// 重试受 RetryPolicy 约束（尝试次数、总耗时、单次超时、重试间隔与按错误类别的覆盖）。
 //
 // 参数：
 //   - ctx: 上下文
@@ -29,12 +32,13 @@ func retryNonStream[T any](
 	onChannelErr func(err error) (T, error, bool),
 ) (T, error) {
 	var result T
+	state := p.newRetryState(ctx)
 	for {
 		if ctx.Err() != nil {
 			return result, normalizeNonStreamCanceledError(ctx, ctx.Err())
 		}
 
-		channel, err := getChannel(ctx)
+		channel, err := getChannel(ctx, state.selectOptions()...)
 		if err != nil {
 			if ctx.Err() != nil || errors.IsCanceled(err) {
 				cancelErr := err
@@ -59,15 +63,26 @@ func retryNonStream[T any](
 		)
 		channelLogger.DebugContext(ctx, "channel_selected")
 
-		err = p.session.WithSession(ctx, func(reqCtx context.Context, reqCancel context.CancelFunc) error {
-			defer reqCancel()
-			var callErr error
-			result, callErr = execute(reqCtx, channel)
-			return callErr
-		})
+		var attempt nonStreamAttempt[T]
+		if delay, ok := state.hedgeDelay(channel, false); ok {
+			attempt = hedgeNonStream(ctx, p, state, getChannel, channel, delay, execute)
+		} else {
+			attempt = runNonStreamAttempt(ctx, p, state, channel, execute)
+		}
+		result, err = attempt.result, attempt.err
+		timedOut := attempt.timedOut
+		if attempt.channel != channel {
+			// 对冲尝试胜出（或最后失败），后续健康标记与日志以其通道为准
+			channel = attempt.channel
+			channelLogger = p.logger.With(
+				"platform_id", channel.PlatformID,
+				"model_id", channel.ModelID,
+				"api_key_id", channel.APIKeyID,
+			)
+		}
 
 		if err != nil {
-			if ctx.Err() != nil || errors.IsCanceled(err) || errors.IsCode(err, errors.ErrCodeAborted) {
+			if !timedOut && (ctx.Err() != nil || errors.IsCanceled(err) || errors.IsCode(err, errors.ErrCodeAborted)) {
 				sourceErr := err
 				if ctx.Err() != nil {
 					sourceErr = ctx.Err()
@@ -77,18 +92,23 @@ func retryNonStream[T any](
 				return result, cancelErr
 			}
 
-			if errors.IsRetryable(err) {
-				if ctx.Err() != nil {
-					cancelErr := normalizeNonStreamCanceledError(ctx, ctx.Err())
+			if state.shouldRetry(ctx, channel, err) {
+				channelLogger.WarnContext(ctx, "request_retry_scheduled", "error", err, "attempt", state.attempts)
+				channel.MarkFailure(ctx, err)
+				if waitErr := state.wait(ctx); waitErr != nil {
+					cancelErr := normalizeNonStreamCanceledError(ctx, waitErr)
 					channelLogger.InfoContext(ctx, "request_canceled", "error", cancelErr)
 					return result, cancelErr
 				}
-
-				channelLogger.WarnContext(ctx, "request_retry_scheduled", "error", err)
-				channel.MarkFailure(ctx, err)
 				continue
 			}
 
+			if ctx.Err() != nil {
+				cancelErr := normalizeNonStreamCanceledError(ctx, ctx.Err())
+				channelLogger.InfoContext(ctx, "request_canceled", "error", cancelErr)
+				return result, cancelErr
+			}
+
 			channel.MarkFailure(ctx, err)
 			return result, err
 		}
@@ -98,6 +118,41 @@ func retryNonStream[T any](
 	}
 }
 
+// nonStreamAttempt 一次非流式尝试的结果
+type nonStreamAttempt[T any] struct {
+	channel  *routing.Channel
+	result   T
+	err      error
+	timedOut bool // 是否因单次尝试超时而结束
+}
+
+// runNonStreamAttempt 在独立会话中执行一次非流式尝试
+//
+// 尝试受 RetryPolicy.AttemptTimeout 约束，超时的错误归一为网关超时；结束后释放通道在途计数。
+func runNonStreamAttempt[T any](
+	ctx context.Context,
+	p *Portal,
+	state *retryState,
+	channel *routing.Channel,
+	execute func(reqCtx context.Context, ch *routing.Channel) (T, error),
+) nonStreamAttempt[T] {
+	attempt := nonStreamAttempt[T]{channel: channel}
+	attempt.err = p.session.WithSession(ctx, func(reqCtx context.Context, reqCancel context.CancelFunc) error {
+		defer reqCancel()
+		attemptCtx, attemptCancel := state.attemptContext(reqCtx)
+		defer attemptCancel()
+		var callErr error
+		attempt.result, callErr = execute(attemptCtx, channel)
+		if callErr != nil && attemptTimedOut(reqCtx, attemptCtx) {
+			attempt.timedOut = true
+			callErr = newAttemptTimeoutError(callErr)
+		}
+		return callErr
+	})
+	channel.Release()
+	return attempt
+}
+
 // normalizeNonStreamCanceledError 归一化非流式取消类错误。
 //
 // 语义规则：
@@ -158,12 +213,13 @@ func retryNativeStream[T any](
 	go func() {
 		defer close(out)
 
+		state := p.newRetryState(ctx)
 		for {
 			if ctx.Err() != nil {
 				return
 			}
 
-			channel, err := getChannel(ctx)
+			channel, err := getChannel(ctx, state.selectOptions()...)
 			if err != nil {
 				if ctx.Err() != nil || errors.IsCanceled(err) {
 					return
@@ -182,21 +238,22 @@ func retryNativeStream[T any](
 			)
 			channelLogger.DebugContext(ctx, "channel_selected")
 
-			nativeOutput := make(chan any)
-			done := make(chan struct{})
-			var doneOnce sync.Once
-			closeDone := func() {
-				doneOnce.Do(func() {
-					close(done)
-				})
+			// 启用对冲时主尝试需标记对冲组，以便落选时以网关取消结束并关联请求日志
+			delay, hedging := state.hedgeDelay(channel, true)
+			var (
+				groupID       string
+				attemptCtx    context.Context
+				attemptCancel context.CancelCauseFunc
+			)
+			if hedging {
+				groupID = newHedgeGroupID()
+				attemptCtx, attemptCancel = newHedgeAttemptContext(ctx, groupID, false)
+			} else {
+				attemptCtx, attemptCancel = context.WithCancelCause(ctx)
 			}
 
-			err = p.session.WithSessionStream(ctx, done, func(reqCtx context.Context) error {
-				return execute(reqCtx, channel, nativeOutput)
-			})
-
+			attempt, err := startNativeStreamAttempt(ctx, attemptCtx, attemptCancel, p, channel, execute)
 			if err != nil {
-				closeDone()
 				if ctx.Err() != nil || errors.IsCanceled(err) || errors.IsCode(err, errors.ErrCodeAborted) {
 					cancelErr := normalizeStreamCanceledError(ctx, err)
 					status, cancelSource := streamCanceledStatus(cancelErr)
@@ -210,9 +267,11 @@ func retryNativeStream[T any](
 					return
 				}
 
-				if errors.IsRetryable(err) {
-					if ctx.Err() != nil {
-						cancelErr := normalizeStreamCanceledError(ctx, ctx.Err())
+				if state.shouldRetry(ctx, channel, err) {
+					channelLogger.WarnContext(ctx, "request_retry_scheduled", "error", err, "attempt", state.attempts)
+					channel.MarkFailure(ctx, err)
+					if waitErr := state.wait(ctx); waitErr != nil {
+						cancelErr := normalizeStreamCanceledError(ctx, waitErr)
 						status, cancelSource := streamCanceledStatus(cancelErr)
 						channelLogger.InfoContext(ctx, "stream_finished",
 							"status", status,
@@ -223,12 +282,22 @@ func retryNativeStream[T any](
 						)
 						return
 					}
-
-					channelLogger.WarnContext(ctx, "request_retry_scheduled", "error", err)
-					channel.MarkFailure(ctx, err)
 					continue
 				}
 
+				if ctx.Err() != nil {
+					cancelErr := normalizeStreamCanceledError(ctx, ctx.Err())
+					status, cancelSource := streamCanceledStatus(cancelErr)
+					channelLogger.InfoContext(ctx, "stream_finished",
+						"status", status,
+						"completion_state", "not_completed",
+						"connection_status", "disconnected",
+						"cancel_source", cancelSource,
+						"error", cancelErr,
+					)
+					return
+				}
+
 				channel.MarkFailure(ctx, err)
 				channelLogger.WarnContext(ctx, "stream_finished",
 					"status", "failed",
@@ -239,6 +308,21 @@ func retryNativeStream[T any](
 				return
 			}
 
+			var first streamEvent
+			if hedging {
+				attempt, first = hedgeNativeStream(ctx, p, state, getChannel, attempt, groupID, delay, execute)
+				if attempt.channel != channel {
+					channel = attempt.channel
+					channelLogger = p.logger.With(
+						"platform_id", channel.PlatformID,
+						"model_id", channel.ModelID,
+						"api_key_id", channel.APIKeyID,
+					)
+				}
+			}
+			nativeOutput := attempt.output
+			closeDone := attempt.closeDone
+
 			streamDrained := false
 			hasForwardedOutput := false
 			for {
@@ -247,6 +331,27 @@ func retryNativeStream[T any](
 					ok    bool
 				)
 
+				// 对冲阶段已读取的首个事件优先处理
+				if first.received {
+					event, ok = first.value, first.ok
+					first = streamEvent{}
+					if !ok {
+						streamDrained = true
+						break
+					}
+					evt, isT := event.(T)
+					if !isT {
+						continue
+					}
+					select {
+					case out <- evt:
+						hasForwardedOutput = true
+						continue
+					case <-ctx.Done():
+						// 客户端已断开，交由下方常规读取路径按取消处理
+					}
+				}
+
 				// 优先尝试消费 nativeOutput，避免在“nativeOutput 已关闭”与“ctx.Done 可读”同时发生时误判为 canceled。
 				select {
 				case event, ok = <-nativeOutput:
@@ -395,6 +500,76 @@ func retryNativeStream[T any](
 	return out
 }
 
+// streamEvent 已从尝试输出中读取的流事件
+type streamEvent struct {
+	value    any
+	ok       bool // false 表示输出通道已关闭
+	received bool // 是否已读取
+}
+
+// nativeStreamAttempt 一次已启动的原生流式尝试
+type nativeStreamAttempt struct {
+	ctx        context.Context // 外层请求上下文
+	attemptCtx context.Context // 本次尝试的上下文
+	cancel     context.CancelCauseFunc
+	channel    *routing.Channel
+	output     chan any
+	closeDone  func() // 结束会话并释放通道（幂等）
+}
+
+// startNativeStreamAttempt 在独立会话中启动一次原生流式尝试
+//
+// attemptCtx 为本次尝试的上下文，cancel 用于结束该尝试。启动失败时已结束会话并释放通道。
+func startNativeStreamAttempt(
+	ctx context.Context,
+	attemptCtx context.Context,
+	cancel context.CancelCauseFunc,
+	p *Portal,
+	channel *routing.Channel,
+	execute func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error,
+) (*nativeStreamAttempt, error) {
+	attempt := &nativeStreamAttempt{
+		ctx:        ctx,
+		attemptCtx: attemptCtx,
+		cancel:     cancel,
+		channel:    channel,
+		output:     make(chan any),
+	}
+
+	done := make(chan struct{})
+	var doneOnce sync.Once
+	attempt.closeDone = func() {
+		doneOnce.Do(func() {
+			close(done)
+			channel.Release()
+			cancel(nil)
+		})
+	}
+
+	err := p.session.WithSessionStream(attemptCtx, done, func(reqCtx context.Context) error {
+		return execute(reqCtx, channel, attempt.output)
+	})
+	if err != nil {
+		attempt.closeDone()
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// abandon 以对冲落选原因结束尝试并丢弃其剩余输出
+func (a *nativeStreamAttempt) abandon() {
+	cancelHedgeLoser(a.cancel)
+	a.closeDone()
+	if cause := context.Cause(a.attemptCtx); errors.IsGatewayCanceled(cause) {
+		a.channel.MarkFailure(a.ctx, cause)
+	}
+	// 适配层在结束时会关闭输出通道
+	go func() {
+		for range a.output {
+		}
+	}()
+}
+
 func normalizeStreamCanceledError(ctx context.Context, err error) error {
 	if ctx != nil && ctx.Err() != nil {
 		source := errors.GetErrorFrom(err)
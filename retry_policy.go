@@ -0,0 +1,342 @@
+package portal
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing"
+)
+
+// RetryAction 定义命中重试规则后的处理方式
+type RetryAction string
+
+const (
+	// RetryActionDefault 按 errors.IsRetryable 判断是否重试
+	RetryActionDefault RetryAction = ""
+	// RetryActionRetry 无论错误是否被标记为可重试都进行重试
+	RetryActionRetry RetryAction = "retry"
+	// RetryActionNever 不重试，直接返回错误
+	RetryActionNever RetryAction = "never"
+	// RetryActionDifferentKey 仅在更换密钥后重试，失败的密钥在本次请求内不再使用
+	RetryActionDifferentKey RetryAction = "different_key"
+)
+
+// RetryRule 按错误类别覆盖重试行为
+//
+// Codes、HTTPStatuses、Match 中任一条件命中即视为匹配。
+type RetryRule struct {
+	Codes        []errors.ErrorCode   // 匹配的错误码
+	HTTPStatuses []int                // 匹配的 HTTP 状态码
+	Match        func(err error) bool // 自定义匹配函数（可选）
+
+	Action RetryAction // 命中后的处理方式
+
+	// MaxAttempts 该类错误在一次请求内最多出现的次数（与 RetryPolicy.MaxAttempts 一样含首次失败）：
+	// 第 MaxAttempts 次出现后不再重试，即最多因该类错误重试 MaxAttempts-1 次，1 表示不重试；
+	// <= 0 表示仅受策略整体限制
+	MaxAttempts int
+}
+
+// matches 判断错误是否命中该规则
+func (r RetryRule) matches(err error) bool {
+	code := errors.GetCode(err)
+	for _, c := range r.Codes {
+		if c == code {
+			return true
+		}
+	}
+	if len(r.HTTPStatuses) > 0 {
+		status := errors.GetHTTPStatus(err)
+		for _, s := range r.HTTPStatuses {
+			if s == status {
+				return true
+			}
+		}
+	}
+	return r.Match != nil && r.Match(err)
+}
+
+// RetryPolicy 定义单个逻辑请求的重试策略
+//
+// 零值表示不限制尝试次数与总耗时、重试之间不等待。
+type RetryPolicy struct {
+	MaxAttempts int           // 最大尝试次数（含首次请求），<= 0 表示不限制
+	MaxElapsed  time.Duration // 总耗时上限（含重试等待），<= 0 表示不限制
+
+	// AttemptTimeout 非流式请求的单次尝试超时，<= 0 表示不限制。
+	// 超时的尝试按网关超时处理（可恢复失败）并进入重试。流式请求不受该限制。
+	AttemptTimeout time.Duration
+
+	BaseDelay time.Duration // 首次重试前的等待时长，后续按指数增长
+	MaxDelay  time.Duration // 单次重试等待上限，<= 0 表示不限制
+	Jitter    float64       // 抖动比例（0~1），实际等待在 [d×(1-Jitter), d×(1+Jitter)] 内均匀分布
+
+	Rules []RetryRule // 按错误类别覆盖，按顺序匹配，第一个命中的规则生效
+
+	// Hedge 对冲请求策略，零值表示不启用
+	Hedge HedgePolicy
+
+	// ReuseChannels 为 true 时，未尝试过的通道耗尽后允许回到本次请求已尝试过的通道；
+	// 默认每次重试都会更换密钥、平台或模型
+	ReuseChannels bool
+
+	// StreamContinuation 为 true 时，Contract 流式请求在已输出部分内容后中断，
+	// 会在另一通道上以已输出的文本与工具调用续写，并将续写结果拼接到原有事件序列上（计入尝试次数）。
+	// 启用后工具调用在参数输出完毕前暂存，中断时由续写重新发起完整的调用。
+	// 默认已有输出的流式请求中断后不再重试，避免客户端收到重复内容
+	StreamContinuation bool
+}
+
+// DefaultRetryPolicy 返回默认重试策略
+//
+// 最多尝试 5 次、总耗时不超过 2 分钟，重试间隔从 50ms 开始指数增长至 2s 并带 20% 抖动；
+// 上游返回 429 时仅更换密钥重试。
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		MaxElapsed:  2 * time.Minute,
+		BaseDelay:   50 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.2,
+		Rules: []RetryRule{
+			{HTTPStatuses: []int{http.StatusTooManyRequests}, Action: RetryActionDifferentKey},
+		},
+	}
+}
+
+// Validate 校验重试策略配置
+func (rp RetryPolicy) Validate() error {
+	if rp.MaxElapsed < 0 || rp.AttemptTimeout < 0 || rp.BaseDelay < 0 || rp.MaxDelay < 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "重试策略的时长配置不能为负数")
+	}
+	if rp.Jitter < 0 || rp.Jitter > 1 {
+		return errors.New(errors.ErrCodeConfigInvalid, "重试抖动比例必须在 0 到 1 之间").
+			WithContext("jitter", rp.Jitter)
+	}
+	if err := rp.Hedge.Validate(); err != nil {
+		return err
+	}
+	for i, rule := range rp.Rules {
+		switch rule.Action {
+		case RetryActionDefault, RetryActionRetry, RetryActionNever, RetryActionDifferentKey:
+		default:
+			return errors.New(errors.ErrCodeConfigInvalid, "不支持的重试动作").
+				WithContext("rule_index", i).
+				WithContext("action", string(rule.Action))
+		}
+	}
+	return nil
+}
+
+// retryPolicyContextKey 单次调用重试策略的上下文键
+type retryPolicyContextKey struct{}
+
+// WithRetryPolicy 返回携带单次调用重试策略的上下文
+//
+// 对 Contract 与 Native 调用均生效，优先于 Config.RetryPolicy。
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyContextKey{}, policy)
+}
+
+// retryPolicyFromContext 从上下文中读取单次调用重试策略
+func retryPolicyFromContext(ctx context.Context) (RetryPolicy, bool) {
+	policy, ok := ctx.Value(retryPolicyContextKey{}).(RetryPolicy)
+	return policy, ok
+}
+
+// retryRand 重试抖动使用的随机数源
+var (
+	retryRandMu sync.Mutex
+	retryRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// retryState 单个逻辑请求的重试状态
+type retryState struct {
+	policy       RetryPolicy
+	start        time.Time
+	attempts     int         // 已失败的尝试次数
+	ruleHits     map[int]int // 各规则命中次数
+	excludedKeys []uint      // 本次请求内不再使用的密钥
+	attempted    []string    // 本次请求已尝试过的通道 ID
+	splitKey     string      // 流量切分键，保证同一逻辑请求的重试落在同一分组
+	nextDelay    time.Duration
+}
+
+// newRetryState 创建重试状态，单次调用策略优先于 Portal 配置
+func (p *Portal) newRetryState(ctx context.Context) *retryState {
+	policy := p.retryPolicy
+	if override, ok := retryPolicyFromContext(ctx); ok {
+		policy = override
+	}
+	return &retryState{
+		policy:   policy,
+		start:    time.Now(),
+		splitKey: newHedgeGroupID(),
+	}
+}
+
+// selectOptions 返回重试状态对通道选择的附加约束
+func (s *retryState) selectOptions() []routing.SelectOption {
+	opts := []routing.SelectOption{routing.WithSplitKey(s.splitKey)}
+	if len(s.attempted) > 0 {
+		opts = append(opts, routing.WithExcludedChannels(s.attempted...))
+		if s.policy.ReuseChannels {
+			opts = append(opts, routing.WithExcludedChannelReuse())
+		}
+	}
+	if len(s.excludedKeys) > 0 {
+		opts = append(opts, routing.WithExcludedAPIKeys(s.excludedKeys...))
+	}
+	return opts
+}
+
+// attemptContext 返回带单次尝试超时的上下文
+func (s *retryState) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.policy.AttemptTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.policy.AttemptTimeout)
+}
+
+// attemptTimedOut 判断尝试是否因单次超时而结束
+//
+// 仅当尝试上下文超时且父上下文仍有效时成立，客户端取消与整体截止时间不在此列。
+func attemptTimedOut(parent, attemptCtx context.Context) bool {
+	return parent.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded
+}
+
+// newAttemptTimeoutError 将单次尝试超时归一为网关超时错误（可恢复失败，可重试）
+func newAttemptTimeoutError(cause error) error {
+	return errors.New(errors.ErrCodeDeadlineExceeded, "单次尝试超时").
+		WithHTTPStatus(http.StatusGatewayTimeout).
+		WithContext("error_from", string(errors.ErrorFromGateway)).
+		WithCause(cause)
+}
+
+// shouldRetry 在一次尝试失败后判断是否继续重试
+//
+// 返回 true 时已计算好下一次重试前的等待时长，调用方应随后调用 wait。
+func (s *retryState) shouldRetry(ctx context.Context, ch *routing.Channel, err error) bool {
+	s.recordFailure(ch)
+	if ctx.Err() != nil {
+		return false
+	}
+	if !s.classify(ch, err) {
+		return false
+	}
+
+	if s.policy.MaxAttempts > 0 && s.attempts >= s.policy.MaxAttempts {
+		return false
+	}
+
+	s.nextDelay = s.delay()
+	if s.policy.MaxElapsed > 0 && s.nextDelay >= s.policy.MaxElapsed-time.Since(s.start) {
+		return false
+	}
+	return true
+}
+
+// classify 按重试规则判断错误是否可重试（不考虑尝试次数与总耗时）
+//
+// 命中规则时计入该规则的出现次数并执行规则动作（如排除失败的密钥）。
+func (s *retryState) classify(ch *routing.Channel, err error) bool {
+	action := RetryActionDefault
+	for i, rule := range s.policy.Rules {
+		if !rule.matches(err) {
+			continue
+		}
+		if s.ruleHits == nil {
+			s.ruleHits = make(map[int]int)
+		}
+		s.ruleHits[i]++
+		if rule.MaxAttempts > 0 && s.ruleHits[i] >= rule.MaxAttempts {
+			return false
+		}
+		action = rule.Action
+		break
+	}
+
+	switch action {
+	case RetryActionNever:
+		return false
+	case RetryActionRetry:
+	case RetryActionDifferentKey:
+		if ch != nil {
+			s.excludedKeys = append(s.excludedKeys, ch.APIKeyID)
+		}
+	default:
+		if !errors.IsRetryable(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// recordFailure 记录一次失败的尝试：计入尝试次数，并在本次请求内排除该通道
+func (s *retryState) recordFailure(ch *routing.Channel) {
+	s.attempts++
+	if ch != nil {
+		s.attempted = append(s.attempted, ch.ID())
+	}
+}
+
+// recordAbandoned 将对冲落选的尝试计入尝试次数（不排除其通道）
+func (s *retryState) recordAbandoned() {
+	s.attempts++
+}
+
+// canHedge 判断重试预算是否允许在进行中的尝试之外再发起一次对冲尝试
+func (s *retryState) canHedge() bool {
+	if s.policy.MaxAttempts > 0 && s.attempts+2 > s.policy.MaxAttempts {
+		return false
+	}
+	return s.policy.MaxElapsed <= 0 || time.Since(s.start) < s.policy.MaxElapsed
+}
+
+// hedgeDelay 返回本轮尝试的对冲延迟，未启用对冲时返回 false
+func (s *retryState) hedgeDelay(ch *routing.Channel, stream bool) (time.Duration, bool) {
+	return s.policy.Hedge.delayFor(ch, stream)
+}
+
+// delay 计算下一次重试前的等待时长（指数增长 + 抖动）
+func (s *retryState) delay() time.Duration {
+	if s.policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	d := float64(s.policy.BaseDelay) * math.Pow(2, float64(s.attempts-1))
+	if s.policy.MaxDelay > 0 && d > float64(s.policy.MaxDelay) {
+		d = float64(s.policy.MaxDelay)
+	}
+	if s.policy.Jitter > 0 {
+		retryRandMu.Lock()
+		r := retryRand.Float64()
+		retryRandMu.Unlock()
+		d *= 1 + s.policy.Jitter*(2*r-1)
+	}
+	// 不限制单次等待上限时指数增长会超出 time.Duration 的范围
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// wait 等待重试间隔，上下文结束时返回其错误
+func (s *retryState) wait(ctx context.Context) error {
+	if s.nextDelay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(s.nextDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
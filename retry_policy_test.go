@@ -0,0 +1,314 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/logger"
+	"github.com/MeowSalty/portal/routing"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/session"
+)
+
+func newRetryPolicyTestPortal(policy RetryPolicy) *Portal {
+	return &Portal{
+		session:     session.New(),
+		logger:      logger.NewNopLogger(),
+		retryPolicy: policy,
+	}
+}
+
+func retryableTestError() error {
+	return errors.New(errors.ErrCodeUnavailable, "临时错误").
+		WithContext("error_from", string(errors.ErrorFromGateway))
+}
+
+func TestRetryNonStream_MaxAttempts(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{MaxAttempts: 3})
+
+	calls := 0
+	_, err := retryNonStream(context.Background(), p,
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+			return &routing.Channel{}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			calls++
+			return "", retryableTestError()
+		},
+		nil,
+	)
+
+	if !errors.IsCode(err, errors.ErrCodeUnavailable) {
+		t.Fatalf("达到最大尝试次数后应返回最后一次错误，actual=%v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("execute 调用次数期望 3，actual=%d", calls)
+	}
+}
+
+func TestRetryNonStream_RuleNeverRetry(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{
+		Rules: []RetryRule{{HTTPStatuses: []int{http.StatusBadRequest}, Action: RetryActionNever}},
+	})
+
+	calls := 0
+	_, err := retryNonStream(context.Background(), p,
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+			return &routing.Channel{}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			calls++
+			return "", errors.New(errors.ErrCodeInvalidArgument, "参数错误").
+				WithHTTPStatus(http.StatusBadRequest).
+				WithContext("error_from", string(errors.ErrorFromServer))
+		},
+		nil,
+	)
+
+	if err == nil || calls != 1 {
+		t.Fatalf("命中不重试规则时应只尝试一次，calls=%d err=%v", calls, err)
+	}
+}
+
+func TestRetryNonStream_RuleMaxAttemptsCountsOccurrences(t *testing.T) {
+	for maxAttempts, want := range map[int]int{1: 1, 2: 2, 3: 3} {
+		p := newRetryPolicyTestPortal(RetryPolicy{
+			Rules: []RetryRule{{Codes: []errors.ErrorCode{errors.ErrCodeUnavailable}, Action: RetryActionRetry, MaxAttempts: maxAttempts}},
+		})
+
+		calls := 0
+		_, err := retryNonStream(context.Background(), p,
+			func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+				return &routing.Channel{}, nil
+			},
+			func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+				calls++
+				return "", retryableTestError()
+			},
+			nil,
+		)
+
+		// 规则的 MaxAttempts 为该类错误最多出现的次数（含首次），即最多重试 MaxAttempts-1 次
+		if err == nil || calls != want {
+			t.Fatalf("MaxAttempts=%d 时 execute 调用次数期望 %d，actual=%d err=%v", maxAttempts, want, calls, err)
+		}
+	}
+}
+
+func TestRetryState_DelayDoesNotOverflow(t *testing.T) {
+	state := &retryState{policy: RetryPolicy{BaseDelay: time.Second, Jitter: 0.5}, start: time.Now()}
+	for _, attempts := range []int{40, 64, 100, 2000} {
+		state.attempts = attempts
+		if d := state.delay(); d <= 0 {
+			t.Fatalf("未限制等待上限时 attempts=%d 的等待时长不应溢出，actual=%s", attempts, d)
+		}
+	}
+
+	state.policy.MaxElapsed = time.Minute
+	state.attempts = 100
+	if state.shouldRetry(context.Background(), nil, retryableTestError()) {
+		t.Fatal("等待时长超过剩余总耗时时不应重试")
+	}
+}
+
+func TestRetryNonStream_DifferentKeyExcludesFailedKey(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{
+		Rules: []RetryRule{{HTTPStatuses: []int{http.StatusTooManyRequests}, Action: RetryActionDifferentKey}},
+	})
+
+	var optionCounts []int
+	keyID := uint(0)
+	result, err := retryNonStream(context.Background(), p,
+		func(ctx context.Context, opts ...routing.SelectOption) (*routing.Channel, error) {
+			optionCounts = append(optionCounts, len(opts))
+			keyID++
+			return &routing.Channel{APIKeyID: keyID}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			if ch.APIKeyID == 1 {
+				return "", errors.New(errors.ErrCodeRateLimitExceeded, "限流").
+					WithHTTPStatus(http.StatusTooManyRequests).
+					WithContext("error_from", string(errors.ErrorFromServer))
+			}
+			return "ok", nil
+		},
+		nil,
+	)
+
+	if err != nil || result != "ok" {
+		t.Fatalf("更换密钥后应成功，result=%q err=%v", result, err)
+	}
+	// 每次尝试都附带流量切分键，重试时额外附带通道与密钥排除约束
+	if len(optionCounts) != 2 || optionCounts[0] != 1 || optionCounts[1] != 3 {
+		t.Fatalf("重试时应附带通道与密钥排除约束，actual=%v", optionCounts)
+	}
+}
+
+func TestRetryNonStream_AttemptTimeout(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{MaxAttempts: 2, AttemptTimeout: 20 * time.Millisecond})
+
+	calls := 0
+	_, err := retryNonStream(context.Background(), p,
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+			return &routing.Channel{}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			calls++
+			<-reqCtx.Done()
+			return "", reqCtx.Err()
+		},
+		nil,
+	)
+
+	if calls != 2 {
+		t.Fatalf("单次超时应触发重试，calls=%d", calls)
+	}
+	if !errors.IsCode(err, errors.ErrCodeDeadlineExceeded) || errors.GetErrorFrom(err) != errors.ErrorFromGateway {
+		t.Fatalf("单次超时应归一为网关超时错误，actual=%v", err)
+	}
+}
+
+func TestRetryNonStream_CallOverridesPolicy(t *testing.T) {
+	p := newRetryPolicyTestPortal(RetryPolicy{MaxAttempts: 5})
+	ctx := WithRetryPolicy(context.Background(), RetryPolicy{MaxAttempts: 1})
+
+	calls := 0
+	_, _ = retryNonStream(ctx, p,
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
+			return &routing.Channel{}, nil
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			calls++
+			return "", retryableTestError()
+		},
+		nil,
+	)
+
+	if calls != 1 {
+		t.Fatalf("单次调用策略应覆盖默认策略，calls=%d", calls)
+	}
+}
+
+func TestRetryState_DelayAndElapsed(t *testing.T) {
+	state := &retryState{
+		policy: RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 250 * time.Millisecond},
+		start:  time.Now(),
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond}
+	for i, want := range expected {
+		if !state.shouldRetry(context.Background(), nil, retryableTestError()) {
+			t.Fatalf("第 %d 次失败应允许重试", i+1)
+		}
+		if state.nextDelay != want {
+			t.Fatalf("第 %d 次重试等待期望 %s，actual=%s", i+1, want, state.nextDelay)
+		}
+	}
+
+	state.policy.MaxElapsed = 100 * time.Millisecond
+	if state.shouldRetry(context.Background(), nil, retryableTestError()) {
+		t.Fatal("等待时长超出总耗时上限时不应重试")
+	}
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	if err := DefaultRetryPolicy().Validate(); err != nil {
+		t.Fatalf("默认策略应通过校验: %v", err)
+	}
+	invalid := []RetryPolicy{
+		{Jitter: 1.5},
+		{BaseDelay: -time.Second},
+		{Rules: []RetryRule{{Action: "sometimes"}}},
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+			t.Fatalf("策略 %+v 应校验失败，actual=%v", policy, err)
+		}
+	}
+}
+
+// discardHealthStorage 丢弃所有健康状态写入，使通道始终处于未知状态
+type discardHealthStorage struct {
+	*testHealthStorage
+}
+
+func (discardHealthStorage) Set(*health.Health) error { return nil }
+
+func TestRetryNonStream_NeverReusesAttemptedChannel(t *testing.T) {
+	models := []routing.ModelWithEndpoint{
+		{
+			Platform: routing.Platform{ID: 1},
+			Model:    routing.Model{ID: 10, Name: "gpt-4o", APIKeys: []routing.APIKey{{ID: 100}, {ID: 101}}},
+			Endpoint: routing.Endpoint{ID: 1, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	p, err := New(Config{
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     &testModelRepo{models: models},
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: discardHealthStorage{newTestHealthStorage()},
+		RetryPolicy:   &RetryPolicy{MaxAttempts: 5},
+	})
+	if err != nil {
+		t.Fatalf("创建 Portal 失败: %v", err)
+	}
+
+	// 失败不会写入退避，未知状态的通道原本会被反复选中
+	var attempted []string
+	_, err = retryNonStream(context.Background(), p,
+		func(ctx context.Context, opts ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannel(ctx, "gpt-4o", opts...)
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			attempted = append(attempted, ch.ID())
+			return "", retryableTestError()
+		},
+		nil,
+	)
+
+	if !errors.IsCode(err, errors.ErrCodeResourceExhausted) {
+		t.Fatalf("通道耗尽后应返回资源耗尽错误，actual=%v", err)
+	}
+	if len(attempted) != 2 || attempted[0] == attempted[1] {
+		t.Fatalf("每次重试都应更换通道，actual=%v", attempted)
+	}
+}
+
+func TestRetryNonStream_ReuseChannelsWhenPolicyAllows(t *testing.T) {
+	models := []routing.ModelWithEndpoint{
+		{
+			Platform: routing.Platform{ID: 1},
+			Model:    routing.Model{ID: 10, Name: "gpt-4o", APIKeys: []routing.APIKey{{ID: 100}}},
+			Endpoint: routing.Endpoint{ID: 1, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	p, err := New(Config{
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     &testModelRepo{models: models},
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: discardHealthStorage{newTestHealthStorage()},
+		RetryPolicy:   &RetryPolicy{MaxAttempts: 3, ReuseChannels: true},
+	})
+	if err != nil {
+		t.Fatalf("创建 Portal 失败: %v", err)
+	}
+
+	calls := 0
+	_, _ = retryNonStream(context.Background(), p,
+		func(ctx context.Context, opts ...routing.SelectOption) (*routing.Channel, error) {
+			return p.routing.GetChannel(ctx, "gpt-4o", opts...)
+		},
+		func(reqCtx context.Context, ch *routing.Channel) (string, error) {
+			calls++
+			return "", retryableTestError()
+		},
+		nil,
+	)
+
+	if calls != 3 {
+		t.Fatalf("策略允许复用时应回到已尝试的通道，calls=%d", calls)
+	}
+}
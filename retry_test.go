@@ -125,7 +125,7 @@ func TestRetryNonStream_RetryableThenSuccess(t *testing.T) {
 	executeCalls := 0
 
 	result, err := retryNonStream(context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			channelCalls++
 			return &routing.Channel{}, nil
 		},
@@ -166,7 +166,7 @@ func TestRetryNonStream_CanceledContextStopsImmediately(t *testing.T) {
 	channelCalls := 0
 
 	_, err := retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			channelCalls++
 			return &routing.Channel{}, nil
 		},
@@ -202,7 +202,7 @@ func TestRetryNonStream_DeadlineContextStopsImmediately(t *testing.T) {
 	channelCalls := 0
 
 	_, err := retryNonStream(ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			channelCalls++
 			return &routing.Channel{}, nil
 		},
@@ -236,7 +236,7 @@ func TestRetryNonStream_ContextCanceledWithoutClientCtxShouldBeServerCancel(t *t
 	executeCalls := 0
 
 	_, err := retryNonStream(context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			channelCalls++
 			return &routing.Channel{}, nil
 		},
@@ -274,7 +274,7 @@ func TestRetryNonStream_AbortedShouldNotRetry(t *testing.T) {
 	executeCalls := 0
 
 	_, err := retryNonStream(context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			channelCalls++
 			return &routing.Channel{}, nil
 		},
@@ -309,7 +309,7 @@ func TestRetryNativeStream_CanceledAfterRetryableStopsRetry(t *testing.T) {
 	executeCalls := 0
 
 	out := retryNativeStream[string](ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			channelCalls++
 			return &routing.Channel{}, nil
 		},
@@ -340,7 +340,7 @@ func TestRetryNativeStream_AsyncProducerNoPanicAndCompleteOutput(t *testing.T) {
 	}
 
 	out := retryNativeStream[string](context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -378,7 +378,7 @@ func TestRetryNativeStream_CloseOutOnlyAfterProducerFinished(t *testing.T) {
 	producerDone := make(chan struct{})
 
 	out := retryNativeStream[string](context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -454,7 +454,7 @@ func TestRetryNativeStream_CompletedLogsSingleCompletedWithoutCanceled(t *testin
 	}
 
 	out := retryNativeStream[string](context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -495,7 +495,7 @@ func TestRetryNativeStream_TerminalThenCleanupCancelStillCompleted(t *testing.T)
 	defer cancel()
 
 	out := retryNativeStream[string](ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -526,7 +526,7 @@ func TestRetryNativeStream_StartupCanceledLogsSingleCanceled(t *testing.T) {
 	}
 
 	out := retryNativeStream[string](context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -565,7 +565,7 @@ func TestRetryNativeStream_CanceledLogsSingleCanceledWithoutCompleted(t *testing
 
 	releaseProducer := make(chan struct{})
 	out := retryNativeStream[string](ctx, p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -617,7 +617,7 @@ func TestRetryNativeStream_StartupServerCanceledLogsServerSource(t *testing.T) {
 	}
 
 	out := retryNativeStream[string](context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
@@ -649,7 +649,7 @@ func TestRetryNativeStream_StartupDeadlineLogsTimedOut(t *testing.T) {
 	}
 
 	out := retryNativeStream[string](context.Background(), p,
-		func(ctx context.Context) (*routing.Channel, error) {
+		func(ctx context.Context, _ ...routing.SelectOption) (*routing.Channel, error) {
 			return &routing.Channel{}, nil
 		},
 		func(reqCtx context.Context, ch *routing.Channel, output chan<- any) error {
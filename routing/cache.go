@@ -0,0 +1,352 @@
+package routing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+)
+
+// CacheConfig 路由缓存配置
+//
+// 零值表示不缓存：每次选择通道都会查询模型仓库，并为每个候选通道读取三次健康状态存储。
+type CacheConfig struct {
+	// TTL 模型/端点查询结果的缓存时长，<= 0 表示不缓存
+	TTL time.Duration
+
+	// NegativeTTL 未知模型（查询结果为空）的缓存时长，<= 0 表示不缓存空结果
+	NegativeTTL time.Duration
+
+	// HealthTTL 健康状态读取的缓存时长，<= 0 表示不缓存。
+	// 本实例的健康状态写入会同步更新缓存，其他实例的写入最多延迟 HealthTTL 可见。
+	HealthTTL time.Duration
+}
+
+// Validate 校验缓存配置
+func (c CacheConfig) Validate() error {
+	if c.TTL < 0 || c.NegativeTTL < 0 || c.HealthTTL < 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "路由缓存时长不能为负数").
+			WithContext("ttl", c.TTL).
+			WithContext("negative_ttl", c.NegativeTTL).
+			WithContext("health_ttl", c.HealthTTL)
+	}
+	return nil
+}
+
+// Invalidation 描述缓存失效的范围
+//
+// 各字段之间为"或"关系，命中任一字段的缓存项都会失效；全部为零值时清空全部缓存。
+// 平台、模型 ID 或密钥失效时，未知模型的空结果缓存也会一并清除（新增的资源可能使其变为可用）。
+type Invalidation struct {
+	Model      string // 模型名称（请求名称、解析后的名称或别名）
+	ModelID    uint   // 模型 ID
+	PlatformID uint   // 平台 ID
+	APIKeyID   uint   // 密钥 ID
+}
+
+// isZero 判断是否为清空全部缓存
+func (inv Invalidation) isZero() bool {
+	return inv == Invalidation{}
+}
+
+// matches 判断查询结果是否命中失效范围
+func (inv Invalidation) matches(key lookupKey, models []ModelWithEndpoint) bool {
+	if inv.Model != "" && key.name == inv.Model {
+		return true
+	}
+	if len(models) == 0 {
+		return inv.ModelID != 0 || inv.PlatformID != 0 || inv.APIKeyID != 0
+	}
+	for _, mwe := range models {
+		if inv.Model != "" && (mwe.Model.Name == inv.Model || mwe.Model.Alias == inv.Model) {
+			return true
+		}
+		if inv.ModelID != 0 && mwe.Model.ID == inv.ModelID {
+			return true
+		}
+		if inv.PlatformID != 0 && mwe.Platform.ID == inv.PlatformID {
+			return true
+		}
+		if inv.APIKeyID != 0 {
+			for _, key := range mwe.Model.APIKeys {
+				if key.ID == inv.APIKeyID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Invalidate 使路由缓存失效，管理端修改平台、模型或密钥后调用可使修改立即生效
+func (r *Routing) Invalidate(inv Invalidation) {
+	r.probes.invalidate(inv)
+	if inv.isZero() {
+		r.cache.invalidate(inv)
+		r.healthCache.invalidateAll()
+		return
+	}
+
+	modelIDs := r.cache.invalidate(inv)
+	if inv.ModelID != 0 {
+		modelIDs = append(modelIDs, inv.ModelID)
+	}
+	for _, id := range modelIDs {
+		r.healthCache.invalidate(health.ResourceTypeModel, id)
+	}
+	if inv.PlatformID != 0 {
+		r.healthCache.invalidate(health.ResourceTypePlatform, inv.PlatformID)
+	}
+	if inv.APIKeyID != 0 {
+		r.healthCache.invalidate(health.ResourceTypeAPIKey, inv.APIKeyID)
+	}
+}
+
+// lookupTimeout 共享模型查询的超时时间
+const lookupTimeout = 30 * time.Second
+
+// lookupKey 模型查询缓存键，默认端点查询的端点类型与变体为空
+type lookupKey struct {
+	name            string
+	endpointType    string
+	endpointVariant string
+	alias           bool // 按别名查询模型
+}
+
+// lookupEntry 模型查询缓存项
+type lookupEntry struct {
+	models    []ModelWithEndpoint
+	expiresAt time.Time
+}
+
+// lookupCall 进行中的模型查询
+type lookupCall struct {
+	done   chan struct{}
+	models []ModelWithEndpoint
+	err    error
+}
+
+// lookupCache 模型/端点查询缓存
+//
+// 同一键的并发未命中只会触发一次仓库查询（singleflight）；查询错误不缓存。
+type lookupCache struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu         sync.Mutex
+	entries    map[lookupKey]lookupEntry
+	calls      map[lookupKey]*lookupCall
+	generation uint64 // 每次失效递增，避免失效前发起的查询写回旧结果
+}
+
+// newLookupCache 创建模型查询缓存
+func newLookupCache(cfg CacheConfig) *lookupCache {
+	return &lookupCache{
+		ttl:         cfg.TTL,
+		negativeTTL: cfg.NegativeTTL,
+		entries:     make(map[lookupKey]lookupEntry),
+		calls:       make(map[lookupKey]*lookupCall),
+	}
+}
+
+// enabled 判断是否启用缓存
+func (c *lookupCache) enabled() bool {
+	return c.ttl > 0 || c.negativeTTL > 0
+}
+
+// get 返回缓存的查询结果，未命中时通过 load 查询并按配置缓存
+//
+// 共享查询在脱离调用方取消的上下文上执行（最长 lookupTimeout），
+// 发起查询的请求被取消不会让等待同一查询的其他请求失败；每个调用方仍按自身上下文提前返回。
+func (c *lookupCache) get(
+	ctx context.Context,
+	key lookupKey,
+	load func(ctx context.Context) ([]ModelWithEndpoint, error),
+) ([]ModelWithEndpoint, error) {
+	if !c.enabled() {
+		return load(ctx)
+	}
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		if time.Now().Before(entry.expiresAt) {
+			c.mu.Unlock()
+			return entry.models, nil
+		}
+		delete(c.entries, key)
+	}
+	call, ok := c.calls[key]
+	if !ok {
+		call = &lookupCall{done: make(chan struct{})}
+		c.calls[key] = call
+		go c.load(context.WithoutCancel(ctx), key, call, c.generation, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.models, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load 执行共享查询并按配置缓存结果
+func (c *lookupCache) load(
+	ctx context.Context,
+	key lookupKey,
+	call *lookupCall,
+	generation uint64,
+	load func(ctx context.Context) ([]ModelWithEndpoint, error),
+) {
+	loadCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	call.models, call.err = load(loadCtx)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	if call.err == nil && generation == c.generation {
+		ttl := c.ttl
+		if len(call.models) == 0 {
+			ttl = c.negativeTTL
+		}
+		if ttl > 0 {
+			c.entries[key] = lookupEntry{models: call.models, expiresAt: time.Now().Add(ttl)}
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// invalidate 删除命中失效范围的缓存项，返回被删除项涉及的模型 ID
+func (c *lookupCache) invalidate(inv Invalidation) []uint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if inv.isZero() {
+		c.entries = make(map[lookupKey]lookupEntry)
+		return nil
+	}
+
+	var modelIDs []uint
+	for key, entry := range c.entries {
+		if !inv.matches(key, entry.models) {
+			continue
+		}
+		delete(c.entries, key)
+		if inv.Model != "" {
+			for _, mwe := range entry.models {
+				modelIDs = append(modelIDs, mwe.Model.ID)
+			}
+		}
+	}
+	return modelIDs
+}
+
+// healthCacheKey 健康状态缓存键
+type healthCacheKey struct {
+	resourceType health.ResourceType
+	resourceID   uint
+}
+
+// healthCacheEntry 健康状态缓存项，status 为 nil 表示存储中不存在
+type healthCacheEntry struct {
+	status    *health.Health
+	expiresAt time.Time
+}
+
+// cachedHealthStorage 带读缓存的健康状态存储
+//
+// 写入（Set/Delete）先写底层存储，成功后同步更新缓存；读取返回缓存项的副本。
+type cachedHealthStorage struct {
+	storage health.Storage
+	ttl     time.Duration
+
+	mu      sync.RWMutex
+	entries map[healthCacheKey]healthCacheEntry
+}
+
+// newCachedHealthStorage 创建带读缓存的健康状态存储
+func newCachedHealthStorage(storage health.Storage, ttl time.Duration) *cachedHealthStorage {
+	return &cachedHealthStorage{
+		storage: storage,
+		ttl:     ttl,
+		entries: make(map[healthCacheKey]healthCacheEntry),
+	}
+}
+
+// Get 获取指定资源的健康状态，缓存有效时不访问底层存储
+func (s *cachedHealthStorage) Get(resourceType health.ResourceType, resourceID uint) (*health.Health, error) {
+	key := healthCacheKey{resourceType: resourceType, resourceID: resourceID}
+
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return cloneHealth(entry.status), nil
+	}
+
+	status, err := s.storage.Get(resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	s.store(key, status)
+	return cloneHealth(status), nil
+}
+
+// Set 写入底层存储并更新缓存
+func (s *cachedHealthStorage) Set(status *health.Health) error {
+	if err := s.storage.Set(status); err != nil {
+		return err
+	}
+	s.store(healthCacheKey{resourceType: status.ResourceType, resourceID: status.ResourceID}, cloneHealth(status))
+	return nil
+}
+
+// Delete 删除底层存储中的记录并清除缓存
+func (s *cachedHealthStorage) Delete(resourceType health.ResourceType, resourceID uint) error {
+	if err := s.storage.Delete(resourceType, resourceID); err != nil {
+		return err
+	}
+	s.invalidate(resourceType, resourceID)
+	return nil
+}
+
+// store 写入缓存项
+func (s *cachedHealthStorage) store(key healthCacheKey, status *health.Health) {
+	s.mu.Lock()
+	s.entries[key] = healthCacheEntry{status: status, expiresAt: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+}
+
+// invalidate 清除指定资源的缓存，未启用缓存时为空操作
+func (s *cachedHealthStorage) invalidate(resourceType health.ResourceType, resourceID uint) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.entries, healthCacheKey{resourceType: resourceType, resourceID: resourceID})
+	s.mu.Unlock()
+}
+
+// invalidateAll 清除全部缓存，未启用缓存时为空操作
+func (s *cachedHealthStorage) invalidateAll() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.entries = make(map[healthCacheKey]healthCacheEntry)
+	s.mu.Unlock()
+}
+
+// cloneHealth 复制健康状态，避免调用方修改缓存中的对象
+func cloneHealth(status *health.Health) *health.Health {
+	if status == nil {
+		return nil
+	}
+	clone := *status
+	return &clone
+}
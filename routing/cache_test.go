@@ -0,0 +1,217 @@
+package routing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+// countingModelRepo 统计查询次数，可选阻塞以模拟并发未命中
+type countingModelRepo struct {
+	testModelRepo
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingModelRepo) FindModelsWithDefaultEndpoint(ctx context.Context, name string) ([]ModelWithEndpoint, error) {
+	r.calls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	return r.testModelRepo.FindModelsWithDefaultEndpoint(ctx, name)
+}
+
+// countingHealthStorage 统计健康状态读取次数
+type countingHealthStorage struct {
+	*testChannelStorage
+	gets int
+}
+
+func (s *countingHealthStorage) Get(resourceType health.ResourceType, resourceID uint) (*health.Health, error) {
+	s.gets++
+	return s.testChannelStorage.Get(resourceType, resourceID)
+}
+
+func newCachedTestRouting(t *testing.T, repo ModelRepository, storage health.Storage, cache CacheConfig) *Routing {
+	t.Helper()
+	r, err := New(context.Background(), Config{
+		Selector:      selector.NewLRUSelector(),
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     repo,
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: storage,
+		Cache:         cache,
+	})
+	if err != nil {
+		t.Fatalf("创建路由失败: %v", err)
+	}
+	return r
+}
+
+func cacheTestModels() []ModelWithEndpoint {
+	return []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+}
+
+func TestRoutingCache_HitAndInvalidate(t *testing.T) {
+	repo := &countingModelRepo{testModelRepo: testModelRepo{models: cacheTestModels()}}
+	r := newCachedTestRouting(t, repo, newTestChannelStorage(), CacheConfig{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		ch, err := r.GetChannel(context.Background(), "gpt-4o")
+		if err != nil {
+			t.Fatalf("获取通道失败: %v", err)
+		}
+		ch.Release()
+	}
+	if got := repo.calls.Load(); got != 1 {
+		t.Fatalf("缓存有效期内应只查询一次仓库，actual=%d", got)
+	}
+
+	r.Invalidate(Invalidation{PlatformID: 1})
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if got := repo.calls.Load(); got != 2 {
+		t.Fatalf("失效后应重新查询仓库，actual=%d", got)
+	}
+}
+
+func TestRoutingCache_NegativeCache(t *testing.T) {
+	repo := &countingModelRepo{}
+	r := newCachedTestRouting(t, repo, newTestChannelStorage(), CacheConfig{NegativeTTL: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.GetChannel(context.Background(), "unknown"); !errors.IsCode(err, errors.ErrCodeNotFound) {
+			t.Fatalf("未知模型应返回 NotFound，actual=%v", err)
+		}
+	}
+	if got := repo.calls.Load(); got != 1 {
+		t.Fatalf("未知模型应被负缓存，actual=%d", got)
+	}
+
+	// 新增模型后按名称失效，立即可用
+	repo.models = cacheTestModels()
+	repo.models[0].Model.Name = "unknown"
+	r.Invalidate(Invalidation{Model: "unknown"})
+	ch, err := r.GetChannel(context.Background(), "unknown")
+	if err != nil {
+		t.Fatalf("失效后应能查询到新增模型: %v", err)
+	}
+	ch.Release()
+}
+
+func TestRoutingCache_SingleflightConcurrentMisses(t *testing.T) {
+	repo := &countingModelRepo{testModelRepo: testModelRepo{models: cacheTestModels()}, release: make(chan struct{})}
+	r := newCachedTestRouting(t, repo, newTestChannelStorage(), CacheConfig{TTL: time.Minute})
+
+	const callers = 8
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := r.cache.get(context.Background(), lookupKey{name: "gpt-4o"}, func(ctx context.Context) ([]ModelWithEndpoint, error) {
+				return repo.FindModelsWithDefaultEndpoint(ctx, "gpt-4o")
+			}); err != nil {
+				t.Errorf("查询失败: %v", err)
+			}
+		}()
+	}
+
+	// 等待首个查询进入仓库后放行
+	deadline := time.Now().Add(time.Second)
+	for repo.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	if got := repo.calls.Load(); got != 1 {
+		t.Fatalf("并发未命中应合并为一次仓库查询，actual=%d", got)
+	}
+}
+
+func TestRoutingCache_LeaderCancelDoesNotFailFollowers(t *testing.T) {
+	repo := &countingModelRepo{testModelRepo: testModelRepo{models: cacheTestModels()}, release: make(chan struct{})}
+	r := newCachedTestRouting(t, repo, newTestChannelStorage(), CacheConfig{TTL: time.Minute})
+	load := func(ctx context.Context) ([]ModelWithEndpoint, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		models, err := repo.FindModelsWithDefaultEndpoint(ctx, "gpt-4o")
+		if err == nil {
+			err = ctx.Err()
+		}
+		return models, err
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := r.cache.get(leaderCtx, lookupKey{name: "gpt-4o"}, load)
+		leaderErr <- err
+	}()
+	deadline := time.Now().Add(time.Second)
+	for repo.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	followerErr := make(chan error, 1)
+	go func() {
+		models, err := r.cache.get(context.Background(), lookupKey{name: "gpt-4o"}, load)
+		if err == nil && len(models) != 1 {
+			t.Errorf("跟随者应得到查询结果，actual=%d", len(models))
+		}
+		followerErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 发起查询的请求被取消后立即返回，共享查询继续执行
+	cancel()
+	if err := <-leaderErr; !errors.IsContextCanceled(err) {
+		t.Fatalf("被取消的请求应返回 context.Canceled，actual=%v", err)
+	}
+	close(repo.release)
+	if err := <-followerErr; err != nil {
+		t.Fatalf("发起者取消不应导致跟随者失败: %v", err)
+	}
+	if got := repo.calls.Load(); got != 1 {
+		t.Fatalf("并发未命中应合并为一次仓库查询，actual=%d", got)
+	}
+}
+
+func TestRoutingCache_HealthReadsCachedAndWriteThrough(t *testing.T) {
+	storage := &countingHealthStorage{testChannelStorage: newTestChannelStorage()}
+	r := newCachedTestRouting(t, &testModelRepo{models: cacheTestModels()}, storage, CacheConfig{HealthTTL: time.Minute})
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.MarkFailure(context.Background(), errors.New(errors.ErrCodeUnavailable, "上游不可用").
+		WithContext("error_from", string(errors.ErrorFromServer)))
+	ch.Release()
+
+	before := storage.gets
+	if _, err := r.GetChannel(context.Background(), "gpt-4o"); !errors.IsCode(err, errors.ErrCodeResourceExhausted) {
+		t.Fatalf("缓存应反映本实例写入的退避状态，actual=%v", err)
+	}
+	if storage.gets != before {
+		t.Fatalf("缓存有效期内不应读取健康状态存储，reads=%d", storage.gets-before)
+	}
+}
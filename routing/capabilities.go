@@ -0,0 +1,99 @@
+package routing
+
+import (
+	"net/http"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// Capability 通道能力
+type Capability string
+
+const (
+	// CapabilityVision 图像输入
+	CapabilityVision Capability = "vision"
+
+	// CapabilityTools 工具调用
+	CapabilityTools Capability = "tools"
+
+	// CapabilityJSONSchema 结构化输出（json_schema 响应格式）
+	CapabilityJSONSchema Capability = "json_schema"
+
+	// CapabilityReasoning 推理（思考）配置
+	CapabilityReasoning Capability = "reasoning"
+
+	// CapabilityAudio 音频输入或输出
+	CapabilityAudio Capability = "audio"
+)
+
+// Capabilities 能力集合
+//
+// 为空表示未声明，视为支持全部能力，保证未配置能力的模型与端点行为不变。
+type Capabilities []Capability
+
+// Supports 判断是否支持指定能力
+func (c Capabilities) Supports(capability Capability) bool {
+	if len(c) == 0 {
+		return true
+	}
+	for _, declared := range c {
+		if declared == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRequiredCapabilities 设置本次请求所需的能力
+//
+// 模型或端点声明了能力集合且不包含所需能力的通道会在选择时被过滤，
+// 避免请求发往无法处理的上游后以 400 失败并触发重试。
+func WithRequiredCapabilities(capabilities ...Capability) SelectOption {
+	return func(o *selectOptions) {
+		o.capabilities = append(o.capabilities, capabilities...)
+	}
+}
+
+// Supports 判断通道是否支持全部指定能力（模型与端点须同时支持）
+func (c *Channel) Supports(capabilities ...Capability) bool {
+	for _, capability := range capabilities {
+		if !c.modelCapabilities.Supports(capability) || !c.endpointCapabilities.Supports(capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// missingCapabilities 返回没有任何候选通道支持的能力
+//
+// 每个能力单独都有通道支持、但没有通道同时支持全部能力时，返回全部所需能力。
+func missingCapabilities(modelsWithEndpoint []ModelWithEndpoint, required []Capability) []Capability {
+	var missing []Capability
+	for _, capability := range required {
+		supported := false
+		for _, mwe := range modelsWithEndpoint {
+			if len(mwe.Model.APIKeys) > 0 &&
+				mwe.Model.Capabilities.Supports(capability) &&
+				mwe.Endpoint.Capabilities.Supports(capability) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			missing = append(missing, capability)
+		}
+	}
+	if len(missing) == 0 {
+		return required
+	}
+	return missing
+}
+
+// newCapabilityError 创建没有通道支持所需能力的错误
+func newCapabilityError(modelsWithEndpoint []ModelWithEndpoint, required []Capability) error {
+	return errors.New(errors.ErrCodeNoHealthyChannel, "没有支持请求所需能力的通道").
+		WithHTTPStatus(http.StatusBadRequest).
+		WithContext("error_from", string(errors.ErrorFromGateway)).
+		WithContext("required_capabilities", required).
+		WithContext("missing_capabilities", missingCapabilities(modelsWithEndpoint, required))
+}
@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestRequiredCapabilities_FiltersChannels(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions", Capabilities: Capabilities{CapabilityTools}},
+		},
+		{
+			Platform: Platform{ID: 2, Priority: 1},
+			Model:    Model{ID: 20, Name: "gpt-4o", APIKeys: []APIKey{{ID: 200}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o", WithRequiredCapabilities(CapabilityTools, CapabilityVision))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if ch.PlatformID != 2 {
+		t.Fatalf("未声明 vision 的端点应被过滤，未声明能力的端点视为全部支持，actual=%d", ch.PlatformID)
+	}
+
+	ch, err = r.GetChannel(context.Background(), "gpt-4o", WithRequiredCapabilities(CapabilityTools))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if ch.PlatformID != 1 {
+		t.Fatalf("支持所需能力时应按优先级选择，actual=%d", ch.PlatformID)
+	}
+}
+
+func TestRequiredCapabilities_NoCapableChannel(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}, Capabilities: Capabilities{CapabilityTools}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, _ := newTestRouting(t, selector.NewLRUSelector(), models)
+
+	_, err := r.GetChannel(context.Background(), "gpt-4o", WithRequiredCapabilities(CapabilityTools, CapabilityVision))
+	if !errors.IsCode(err, errors.ErrCodeNoHealthyChannel) || errors.GetHTTPStatus(err) != 400 {
+		t.Fatalf("没有通道支持所需能力时应返回 NoHealthyChannel(400)，actual=%v", err)
+	}
+	missing := errors.GetContext(err)["missing_capabilities"]
+	if !reflect.DeepEqual(missing, []Capability{CapabilityVision}) {
+		t.Fatalf("错误上下文应列出缺失的能力，actual=%v", missing)
+	}
+}
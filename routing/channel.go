@@ -6,6 +6,7 @@ import (
 	stdErrors "errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/MeowSalty/portal/errors"
@@ -28,8 +29,125 @@ type Channel struct {
 
 	CustomHeaders map[string]string // 通道级别的自定义 HTTP 头部（优先级高于请求级别）
 
+	Labels map[string]string // 通道标签（平台标签与端点标签合并，端点覆盖同名标签）
+
+	Pricing *Pricing // 通道价格（端点价格优先于模型价格，未配置时为 nil）
+
+	FallbackFrom string // 跨模型回退时的原始请求模型名称（未回退时为空）
+	ResolvedFrom string // 模型名称解析器映射前的请求模型名称（解析器未改变名称时为空）
+	SplitArm     string // 流量切分分组（未命中流量切分规则时为空）
+
+	// 端点 ID，用于流量切分分组匹配
+	endpointID uint
+
+	// 模型与端点声明的能力，用于能力过滤
+	modelCapabilities    Capabilities
+	endpointCapabilities Capabilities
+
+	// 模型上下文窗口，用于上下文窗口过滤
+	maxContextTokens int
+	maxOutputTokens  int
+
+	// 选择策略所需的权重信息
+	platformWeight int
+	modelWeight    int
+	keyWeight      int
+
+	// 优先级层级（来自平台配置，数值越小越优先）
+	priority int
+
 	// 健康管理器引用，用于更新状态
 	healthService *health.Service
+
+	// 延迟统计器引用，用于反馈请求耗时
+	latency *latencyTracker
+
+	// 在途请求计数器引用，acquired 标记该通道是否持有在途计数
+	inflight *inflightTracker
+	acquired atomic.Bool
+
+	// 半开熔断器的试探名额，请求结束时随 Release 归还
+	trial *health.Trial
+
+	// 本地限流配置与限流器引用，reservedTokens 为选择时预扣的 Token 数
+	platformLimit  RateLimitConfig
+	keyLimit       RateLimitConfig
+	limiter        *rateLimiter
+	reservedTokens int
+	usageReported  atomic.Bool
+
+	// 探测目标登记表引用，失败时登记为主动探测的目标
+	probes *probeTargets
+}
+
+// ID 返回通道的唯一标识符（平台 ID-模型 ID-密钥 ID）
+func (c *Channel) ID() string {
+	return fmt.Sprintf("%d-%d-%d", c.PlatformID, c.ModelID, c.APIKeyID)
+}
+
+// ObserveLatency 反馈一次成功请求的耗时
+//
+// 该方法由请求日志路径在请求结束时调用，用于更新通道的延迟 EWMA 统计，
+// 供延迟感知的选择器使用。
+//
+// 参数：
+//   - duration: 请求总耗时
+//   - firstByte: 首字耗时（未测量时为 nil）
+func (c *Channel) ObserveLatency(duration time.Duration, firstByte *time.Duration) {
+	if c.latency == nil {
+		return
+	}
+	c.latency.observeSuccess(c.ID(), c.ModelName, duration, firstByte)
+}
+
+// LatencyPercentile 返回该通道所属模型最近成功请求耗时的 q 分位数
+//
+// firstByte 为 true 时返回流式请求首字耗时的分位数，否则返回非流式请求总耗时的分位数。
+// 样本不足时返回 false。
+func (c *Channel) LatencyPercentile(q float64, firstByte bool) (time.Duration, bool) {
+	if c.latency == nil {
+		return 0, false
+	}
+	return c.latency.percentile(c.ModelName, q, firstByte)
+}
+
+// acquire 为通道增加在途请求计数
+func (c *Channel) acquire() {
+	if c.inflight == nil {
+		return
+	}
+	if c.acquired.CompareAndSwap(false, true) {
+		c.inflight.acquire(c.PlatformID, c.ModelID, c.APIKeyID)
+	}
+}
+
+// Release 释放通道持有的在途请求计数
+//
+// 调用方应在请求（含流式请求）真正结束后调用该方法。
+// 该方法是幂等的，多次调用只会释放一次。
+// 通道持有半开熔断器的试探名额时一并归还。
+func (c *Channel) Release() {
+	c.trial.Release()
+	if c.inflight == nil {
+		return
+	}
+	if c.acquired.CompareAndSwap(true, false) {
+		c.inflight.release(c.PlatformID, c.ModelID, c.APIKeyID)
+	}
+}
+
+// ReportUsage 以实际 Token 用量校正本地 TPM 限流
+//
+// 选择通道时按预估 Token 数预扣 TPM 额度，请求结束后由请求日志路径调用该方法，
+// 多退少补。请求失败且没有用量信息时传入 0 以退还预扣额度。
+// 该方法只有首次调用生效。
+func (c *Channel) ReportUsage(totalTokens int) {
+	if c.limiter == nil {
+		return
+	}
+	if c.usageReported.CompareAndSwap(false, true) {
+		c.limiter.adjustTokens(c, totalTokens-c.reservedTokens)
+	}
 }
 
 // MarkSuccess 标记通道调用成功
@@ -67,6 +185,7 @@ func (c *Channel) MarkSuccess(ctx context.Context) {
 //
 // 根据错误分类决定健康影响程度：
 //   - client_cancel（ABORTED + client）：不降低健康，请求方主动取消不影响通道
+//   - gateway_cancel（CANCELED + gateway）：不降低健康，网关主动取消（如对冲落选）不影响通道
 //   - deadline（DEADLINE_EXCEEDED + gateway）：可恢复失败，超时通常为瞬时问题
 //   - server_cancel（CANCELED + server）：可恢复失败，服务端取消需记录但不完全降级
 //   - 其他错误：完全降级，计入错误计数并应用退避策略
@@ -82,9 +201,15 @@ func (c *Channel) MarkFailure(ctx context.Context, err error) {
 		return
 	}
 
+	// 失败以惩罚耗时计入延迟统计，使延迟感知的选择器避开故障通道
+	if c.latency != nil {
+		c.latency.observeFailure(c.ID())
+	}
+
 	snapshot := buildHealthErrorSnapshot(err)
 	snapshot.Impact = impact
 	resourceType, resourceID := c.resolveFailureResource(err)
+	c.probes.record(resourceType, resourceID, c)
 
 	// 更新健康状态
 	c.healthService.UpdateStatus(
@@ -115,6 +240,8 @@ func (c *Channel) resolveFailureResource(err error) (health.ResourceType, uint)
 // 规则：
 //   - client_cancel（ABORTED + error_from=client）：无健康影响。
 //     客户端主动取消（含 completed_then_disconnected）不应降低通道健康。
+//   - gateway_cancel（CANCELED + error_from=gateway）：无健康影响。
+//     网关出于自身策略取消请求（如对冲请求落选），与通道质量无关。
 //   - deadline（DEADLINE_EXCEEDED + error_from=gateway）：可恢复失败。
 //     网关超时通常为瞬时负载问题，不应完全降级通道。
 //   - server_cancel（CANCELED + error_from=server）：可恢复失败。
@@ -133,6 +260,11 @@ func classifyHealthImpact(err error) health.HealthImpact {
 		return health.HealthImpactNone
 	}
 
+	// gateway_cancel：CANCELED + gateway 来源
+	if code == errors.ErrCodeCanceled && errorFrom == errors.ErrorFromGateway {
+		return health.HealthImpactNone
+	}
+
 	// deadline：DEADLINE_EXCEEDED + gateway 来源
 	if code == errors.ErrCodeDeadlineExceeded && errorFrom == errors.ErrorFromGateway {
 		return health.HealthImpactRecoverable
@@ -48,6 +48,14 @@ func TestClassifyHealthImpact_ClientCancel_无健康影响(t *testing.T) {
 	}
 }
 
+func TestClassifyHealthImpact_GatewayCancel_无健康影响(t *testing.T) {
+	// CANCELED + gateway 来源 → gateway_cancel（如对冲落选）→ HealthImpactNone
+	impact := classifyHealthImpact(errors.NewGatewayCanceled("hedge_lost"))
+	if impact != health.HealthImpactNone {
+		t.Fatalf("gateway_cancel 期望 HealthImpactNone，actual=%v", impact)
+	}
+}
+
 func TestClassifyHealthImpact_Deadline_可恢复失败(t *testing.T) {
 	// DEADLINE_EXCEEDED + gateway 来源 → deadline → HealthImpactRecoverable
 	err := errors.NewWithHTTPStatus(errors.ErrCodeDeadlineExceeded, "请求超时", 504).
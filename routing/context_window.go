@@ -0,0 +1,45 @@
+package routing
+
+import (
+	"net/http"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// WithContextRequirement 设置本次请求的预估提示词 Token 数与最大输出 Token 数
+//
+// 模型配置了 MaxContextTokens/MaxOutputTokens 时，无法容纳提示词与输出的通道会在选择时被跳过，
+// 避免请求在小上下文部署上以上下文超长错误失败后再逐个通道重试。<= 0 表示未知，不参与判断。
+func WithContextRequirement(promptTokens, outputTokens int) SelectOption {
+	return func(o *selectOptions) {
+		o.promptTokens = max(promptTokens, 0)
+		o.outputTokens = max(outputTokens, 0)
+	}
+}
+
+// fitsContext 判断通道的上下文窗口能否容纳本次请求
+func (c *Channel) fitsContext(options *selectOptions) bool {
+	if c.maxOutputTokens > 0 && options.outputTokens > c.maxOutputTokens {
+		return false
+	}
+	if c.maxContextTokens > 0 && options.promptTokens+options.outputTokens > c.maxContextTokens {
+		return false
+	}
+	return true
+}
+
+// newContextWindowError 创建没有通道能容纳请求的错误，附带候选模型中最大的上下文窗口
+func newContextWindowError(modelsWithEndpoint []ModelWithEndpoint, options *selectOptions) error {
+	maxContext, maxOutput := 0, 0
+	for _, mwe := range modelsWithEndpoint {
+		maxContext = max(maxContext, mwe.Model.MaxContextTokens)
+		maxOutput = max(maxOutput, mwe.Model.MaxOutputTokens)
+	}
+	return errors.New(errors.ErrCodeOutOfRange, "请求超出所有通道的上下文窗口").
+		WithHTTPStatus(http.StatusBadRequest).
+		WithContext("error_from", string(errors.ErrorFromGateway)).
+		WithContext("prompt_tokens", options.promptTokens).
+		WithContext("output_tokens", options.outputTokens).
+		WithContext("max_context_tokens", maxContext).
+		WithContext("max_output_tokens", maxOutput)
+}
@@ -0,0 +1,72 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestContextRequirement_SkipsSmallWindows(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "llama", APIKeys: []APIKey{{ID: 100}}, MaxContextTokens: 8192},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+		{
+			Platform: Platform{ID: 2, Priority: 1},
+			Model:    Model{ID: 20, Name: "llama", APIKeys: []APIKey{{ID: 200}}, MaxContextTokens: 131072},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+
+	ch, err := r.GetChannel(context.Background(), "llama", WithContextRequirement(8000, 1024))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if ch.PlatformID != 2 {
+		t.Fatalf("上下文窗口不足的通道应被跳过，actual=%d", ch.PlatformID)
+	}
+
+	ch, err = r.GetChannel(context.Background(), "llama", WithContextRequirement(1000, 1024))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if ch.PlatformID != 1 {
+		t.Fatalf("窗口足够时应按优先级选择，actual=%d", ch.PlatformID)
+	}
+}
+
+func TestContextRequirement_NoChannelFits(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "llama", APIKeys: []APIKey{{ID: 100}}, MaxContextTokens: 8192, MaxOutputTokens: 2048},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, _ := newTestRouting(t, selector.NewLRUSelector(), models)
+
+	_, err := r.GetChannel(context.Background(), "llama", WithContextRequirement(1000, 4096))
+	if !errors.IsCode(err, errors.ErrCodeOutOfRange) || errors.GetHTTPStatus(err) != 400 {
+		t.Fatalf("没有通道能容纳请求时应返回 OutOfRange(400)，actual=%v", err)
+	}
+	if got := errors.GetContext(err)["max_context_tokens"]; got != 8192 {
+		t.Fatalf("错误上下文应包含最大上下文窗口，actual=%v", got)
+	}
+
+	ch, err := r.GetChannel(context.Background(), "llama")
+	if err != nil {
+		t.Fatalf("未设置上下文需求时不应过滤通道: %v", err)
+	}
+	ch.Release()
+}
@@ -0,0 +1,336 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+// ExclusionReason 候选通道未参与本次选择的原因
+type ExclusionReason string
+
+const (
+	// ExclusionNone 通道参与选择
+	ExclusionNone ExclusionReason = ""
+
+	// ExclusionKeyExcluded 密钥被 WithExcludedAPIKeys 排除
+	ExclusionKeyExcluded ExclusionReason = "key_excluded"
+
+	// ExclusionChannelExcluded 通道被 WithExcludedChannels 排除
+	ExclusionChannelExcluded ExclusionReason = "channel_excluded"
+
+	// ExclusionUnhealthy 平台、模型或密钥处于不可用或退避状态
+	ExclusionUnhealthy ExclusionReason = "unhealthy"
+
+	// ExclusionMissingCapability 模型或端点不支持请求所需的能力
+	ExclusionMissingCapability ExclusionReason = "missing_capability"
+
+	// ExclusionContextWindow 模型上下文窗口无法容纳预估提示词与最大输出
+	ExclusionContextWindow ExclusionReason = "context_window"
+
+	// ExclusionLabelMismatch 通道标签不满足标签约束
+	ExclusionLabelMismatch ExclusionReason = "label_mismatch"
+
+	// ExclusionSplitArm 不属于本次请求分配到的流量切分分组
+	ExclusionSplitArm ExclusionReason = "split_arm"
+
+	// ExclusionRateLimited 本地 RPM/TPM 限流已饱和
+	ExclusionRateLimited ExclusionReason = "rate_limited"
+
+	// ExclusionLowerPriority 存在更高优先级层级的可用通道
+	ExclusionLowerPriority ExclusionReason = "lower_priority"
+)
+
+// CandidateExplanation 单个候选通道的解释
+type CandidateExplanation struct {
+	ChannelID  string
+	PlatformID uint
+	ModelID    uint
+	APIKeyID   uint
+	ModelName  string
+	Provider   string // 端点类型
+	APIVariant string // 端点变体
+	Priority   int    // 平台优先级（数值越小越优先）
+
+	Status   health.ChannelStatus // 通道综合健康状态
+	Platform *health.Health       // 平台健康状态（nil 表示尚无记录）
+	Model    *health.Health       // 模型健康状态
+	APIKey   *health.Health       // 密钥健康状态
+
+	UnhealthyResources []health.ResourceType // 判定为不健康的资源
+	NextAvailableAt    *time.Time            // 不健康资源中最晚的退避结束时间（无退避时间时为 nil）
+	RetryAfter         time.Duration         // 本地限流预计等待时长
+
+	Excluded ExclusionReason // 未参与选择的原因，参与选择时为空
+}
+
+// SelectorExplanation 选择器对参与选择的通道的评分
+type SelectorExplanation struct {
+	Type   selector.SelectorType // 选择器类型（当前配置的选择器为空）
+	Name   string                // 选择器名称
+	Scores map[string]float64    // 通道 ID -> 得分，选择器未实现 selector.Scorer 时为 nil
+	Winner string                // 选择器将选中的通道 ID，无法预测时为空
+}
+
+// Explanation 一次通道选择的解释（dry-run）结果
+type Explanation struct {
+	Model           string
+	EndpointType    string // 为空表示使用默认端点
+	EndpointVariant string
+
+	Candidates []CandidateExplanation // 所有候选通道，顺序与实际选择时的遍历顺序一致
+	Tier       int                    // 参与选择的优先级层级
+	Winner     string                 // 将被选中的通道 ID，为空表示无可用通道或选择结果不可预测
+
+	ProbeUnknown   bool // 获胜通道健康状态未知，将被直接选中而不经过选择器（按亲和键选择时未知通道参与选择器评分）
+	ReusedExcluded bool // 未排除的通道已耗尽，按 WithExcludedChannelReuse 回到已排除的通道
+
+	SplitArm      string // 分配到的流量切分分组（未命中流量切分规则时为空）
+	SplitFallback bool   // 分组内没有可用通道，回退到全部通道
+
+	Selector     SelectorExplanation   // 当前配置的选择器
+	Alternatives []SelectorExplanation // 所有已注册选择器类型（新建实例）的评分，便于对比
+}
+
+// Explain 解释指定模型的通道选择过程，不会选中通道
+//
+// 返回 buildChannelsForModelWithEndpoint 构建的全部候选通道及其平台/模型/密钥健康状态、
+// 退避结束时间与排除原因，以及各选择器对参与选择的通道的评分和获胜通道。
+// endpointType 与 endpointVariant 均为空时使用默认端点。
+//
+// 该方法不更新最近尝试时间、不预扣限流额度、不增加在途计数，也不推进选择器的内部状态。
+func (r *Routing) Explain(
+	ctx context.Context,
+	modelName, endpointType, endpointVariant string,
+	opts ...SelectOption,
+) (*Explanation, error) {
+	if modelName == "" {
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "模型名称不能为空").WithHTTPStatus(http.StatusBadRequest)
+	}
+
+	var modelsWithEndpoint []ModelWithEndpoint
+	var err error
+	switch {
+	case endpointType == "" && endpointVariant == "":
+		modelsWithEndpoint, _, err = r.lookupDefaultEndpoint(ctx, modelName)
+	case endpointType == "":
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "端点类型不能为空").WithHTTPStatus(http.StatusBadRequest)
+	case endpointVariant == "":
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "端点变体不能为空").WithHTTPStatus(http.StatusBadRequest)
+	default:
+		modelsWithEndpoint, _, err = r.lookupEndpoint(ctx, modelName, endpointType, endpointVariant)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	options := applySelectOptions(opts)
+	options.model = modelName
+
+	var explanation *Explanation
+	assignment := r.assignSplit(modelsWithEndpoint, options)
+	if assignment != nil {
+		armed := *options
+		armed.split = assignment
+		explanation = r.explainCandidates(modelsWithEndpoint, &armed)
+		if !explanation.hasEligible() {
+			explanation = r.explainCandidates(modelsWithEndpoint, options)
+			explanation.SplitFallback = true
+		}
+		explanation.SplitArm = assignment.arm
+	} else {
+		explanation = r.explainCandidates(modelsWithEndpoint, options)
+	}
+	explanation.Model = modelName
+	explanation.EndpointType = endpointType
+	explanation.EndpointVariant = endpointVariant
+	return explanation, nil
+}
+
+// explainCandidates 按 selectChannel 的规则评估候选通道
+func (r *Routing) explainCandidates(modelsWithEndpoint []ModelWithEndpoint, options *selectOptions) *Explanation {
+	explanation := &Explanation{}
+	var channels []*Channel
+	var lastTries [][3]time.Time
+
+	channelExcluded := false
+	rateLimited := false
+	hasTier := false
+
+	for _, mwe := range modelsWithEndpoint {
+		for _, ch := range r.buildChannelsForModelWithEndpoint(mwe) {
+			inspection := r.healthService.InspectChannel(ch.PlatformID, ch.ModelID, ch.APIKeyID)
+			candidate := CandidateExplanation{
+				ChannelID:  ch.ID(),
+				PlatformID: ch.PlatformID,
+				ModelID:    ch.ModelID,
+				APIKeyID:   ch.APIKeyID,
+				ModelName:  ch.ModelName,
+				Provider:   ch.Provider,
+				APIVariant: ch.APIVariant,
+				Priority:   ch.priority,
+				Status:     inspection.Status,
+				Platform:   inspection.Platform,
+				Model:      inspection.Model,
+				APIKey:     inspection.APIKey,
+			}
+			candidate.UnhealthyResources, candidate.NextAvailableAt = unhealthyResources(inspection)
+
+			switch {
+			case !ch.Supports(options.capabilities...):
+				candidate.Excluded = ExclusionMissingCapability
+			case !ch.fitsContext(options):
+				candidate.Excluded = ExclusionContextWindow
+			case !ch.matchesLabels(options.labels):
+				candidate.Excluded = ExclusionLabelMismatch
+			case !options.split.allows(ch):
+				candidate.Excluded = ExclusionSplitArm
+			case options.isKeyExcluded(ch.APIKeyID):
+				candidate.Excluded = ExclusionKeyExcluded
+			case options.isChannelExcluded(candidate.ChannelID):
+				candidate.Excluded = ExclusionChannelExcluded
+				channelExcluded = true
+			case inspection.Status == health.ChannelStatusUnavailable:
+				candidate.Excluded = ExclusionUnhealthy
+			default:
+				if wait := r.limiter.check(ch, options.estimatedTokens); wait > 0 {
+					candidate.Excluded = ExclusionRateLimited
+					candidate.RetryAfter = wait
+					rateLimited = true
+				} else if !hasTier || ch.priority < explanation.Tier {
+					explanation.Tier = ch.priority
+					hasTier = true
+				}
+			}
+
+			now := time.Now()
+			channels = append(channels, ch)
+			lastTries = append(lastTries, [3]time.Time{
+				lastTryTime(inspection.Platform, now),
+				lastTryTime(inspection.Model, now),
+				lastTryTime(inspection.APIKey, now),
+			})
+			explanation.Candidates = append(explanation.Candidates, candidate)
+		}
+	}
+
+	// 未尝试过的通道已耗尽，策略允许时回到已尝试过的通道
+	if !hasTier && channelExcluded && !rateLimited && options.reuseExcluded {
+		relaxed := *options
+		relaxed.excludedChannels = nil
+		explanation = r.explainCandidates(modelsWithEndpoint, &relaxed)
+		explanation.ReusedExcluded = true
+		return explanation
+	}
+
+	// 收集最优层级内参与选择的通道
+	var channelInfos []selector.ChannelInfo
+	unknownAsCandidate := r.selectsUnknownByAffinity(options)
+	for i := range explanation.Candidates {
+		candidate := &explanation.Candidates[i]
+		if candidate.Excluded != ExclusionNone {
+			continue
+		}
+		if candidate.Priority > explanation.Tier {
+			candidate.Excluded = ExclusionLowerPriority
+			continue
+		}
+		switch candidate.Status {
+		case health.ChannelStatusAvailable:
+			lastTry := lastTries[i]
+			channelInfos = append(channelInfos, r.channelInfo(channels[i], lastTry[0], lastTry[1], lastTry[2]))
+		case health.ChannelStatusUnknown:
+			if unknownAsCandidate {
+				lastTry := lastTries[i]
+				channelInfos = append(channelInfos, r.channelInfo(channels[i], lastTry[0], lastTry[1], lastTry[2]))
+			} else if !explanation.ProbeUnknown {
+				explanation.Winner = candidate.ChannelID
+				explanation.ProbeUnknown = true
+			}
+		}
+	}
+
+	req := options.selectorRequest()
+
+	r.mu.Lock()
+	current := r.selector
+	r.mu.Unlock()
+	explanation.Selector = explainSelector(current, req, channelInfos)
+	if !explanation.ProbeUnknown {
+		explanation.Winner = explanation.Selector.Winner
+	}
+
+	for _, selectorType := range selector.Registered() {
+		alternative, err := selector.Create(selectorType)
+		if err != nil {
+			continue
+		}
+		scored := explainSelector(alternative, req, channelInfos)
+		scored.Type = selectorType
+		explanation.Alternatives = append(explanation.Alternatives, scored)
+	}
+
+	return explanation
+}
+
+// hasEligible 判断是否存在参与选择的通道
+func (e *Explanation) hasEligible() bool {
+	for _, candidate := range e.Candidates {
+		if candidate.Excluded == ExclusionNone {
+			return true
+		}
+	}
+	return false
+}
+
+// explainSelector 计算选择器对参与选择的通道的评分
+func explainSelector(sel selector.Selector, req selector.Request, channelInfos []selector.ChannelInfo) SelectorExplanation {
+	result := SelectorExplanation{Name: sel.Name()}
+	scorer, ok := sel.(selector.Scorer)
+	if !ok || len(channelInfos) == 0 {
+		return result
+	}
+
+	scores, winner := scorer.Score(req, channelInfos)
+	if len(scores) == len(channelInfos) {
+		result.Scores = make(map[string]float64, len(scores))
+		for i, info := range channelInfos {
+			result.Scores[info.ID] = scores[i]
+		}
+	}
+	result.Winner = winner
+	return result
+}
+
+// unhealthyResources 返回判定为不健康的资源及其中最晚的退避结束时间
+func unhealthyResources(inspection health.ChannelInspection) ([]health.ResourceType, *time.Time) {
+	var resources []health.ResourceType
+	var nextAvailableAt *time.Time
+	check := func(resourceType health.ResourceType, healthy bool, status *health.Health) {
+		if healthy {
+			return
+		}
+		resources = append(resources, resourceType)
+		if status != nil && status.NextAvailableAt != nil &&
+			(nextAvailableAt == nil || status.NextAvailableAt.After(*nextAvailableAt)) {
+			next := *status.NextAvailableAt
+			nextAvailableAt = &next
+		}
+	}
+	check(health.ResourceTypePlatform, inspection.PlatformHealthy, inspection.Platform)
+	check(health.ResourceTypeModel, inspection.ModelHealthy, inspection.Model)
+	check(health.ResourceTypeAPIKey, inspection.APIKeyHealthy, inspection.APIKey)
+	return resources, nextAvailableAt
+}
+
+// lastTryTime 返回资源的最近尝试时间，与 GetChannelHealthAndLastTryTimes 一致，无记录时视为 now
+func lastTryTime(status *health.Health, now time.Time) time.Time {
+	if status == nil {
+		return now
+	}
+	return status.LastCheckAt
+}
@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestExplain_ReportsCandidatesWithoutSideEffects(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1, Priority: 0},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100, Weight: 3}, {ID: 101}, {ID: 102}, {ID: 103}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+		{
+			Platform: Platform{ID: 2, Priority: 1},
+			Model:    Model{ID: 20, Name: "gpt-4o", APIKeys: []APIKey{{ID: 200}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	r, storage := newTestRouting(t, selector.NewWeightedSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 102, 103, 200)
+	next := time.Now().Add(time.Minute)
+	_ = storage.Set(&health.Health{
+		ResourceType:    health.ResourceTypeAPIKey,
+		ResourceID:      101,
+		Status:          health.HealthStatusUnavailable,
+		NextAvailableAt: &next,
+	})
+	lastCheck := storage.data[testChannelStorageKey{resourceType: health.ResourceTypeAPIKey, resourceID: 100}].LastCheckAt
+
+	var explanation *Explanation
+	for i := 0; i < 2; i++ {
+		var err error
+		explanation, err = r.Explain(context.Background(), "gpt-4o", "", "", WithExcludedAPIKeys(102))
+		if err != nil {
+			t.Fatalf("解释通道选择失败: %v", err)
+		}
+		if explanation.Winner != "1-10-100" {
+			t.Fatalf("高权重密钥应获胜且重复解释不应推进选择器状态，actual=%q", explanation.Winner)
+		}
+	}
+
+	reasons := make(map[string]ExclusionReason)
+	for _, candidate := range explanation.Candidates {
+		reasons[candidate.ChannelID] = candidate.Excluded
+		if candidate.ChannelID == "1-10-101" {
+			if candidate.NextAvailableAt == nil || !candidate.NextAvailableAt.Equal(next) {
+				t.Fatalf("不可用通道应返回退避结束时间，actual=%v", candidate.NextAvailableAt)
+			}
+			if len(candidate.UnhealthyResources) != 1 || candidate.UnhealthyResources[0] != health.ResourceTypeAPIKey {
+				t.Fatalf("不健康资源应为密钥，actual=%v", candidate.UnhealthyResources)
+			}
+		}
+	}
+	expected := map[string]ExclusionReason{
+		"1-10-100": ExclusionNone,
+		"1-10-101": ExclusionUnhealthy,
+		"1-10-102": ExclusionKeyExcluded,
+		"1-10-103": ExclusionNone,
+		"2-20-200": ExclusionLowerPriority,
+	}
+	for id, reason := range expected {
+		if reasons[id] != reason {
+			t.Fatalf("通道 %s 排除原因期望 %q，actual=%q", id, reason, reasons[id])
+		}
+	}
+
+	if len(explanation.Selector.Scores) != 2 || explanation.Selector.Scores["1-10-100"] != 3 {
+		t.Fatalf("当前选择器应为参与选择的通道评分，actual=%v", explanation.Selector.Scores)
+	}
+	if len(explanation.Alternatives) != len(selector.Registered()) {
+		t.Fatalf("应为每个已注册选择器给出评分，actual=%d", len(explanation.Alternatives))
+	}
+
+	after := storage.data[testChannelStorageKey{resourceType: health.ResourceTypeAPIKey, resourceID: 100}].LastCheckAt
+	if !after.Equal(lastCheck) {
+		t.Fatal("解释通道选择不应更新最近尝试时间")
+	}
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o", WithExcludedAPIKeys(102))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	defer ch.Release()
+	if ch.ID() != explanation.Winner {
+		t.Fatalf("实际选择结果应与解释一致，expected=%s actual=%s", explanation.Winner, ch.ID())
+	}
+}
+
+func TestExplain_UnknownChannelWinsWithoutSelector(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, _ := newTestRouting(t, selector.NewLRUSelector(), models)
+
+	explanation, err := r.Explain(context.Background(), "gpt-4o", "openai", "chat_completions")
+	if err != nil {
+		t.Fatalf("解释通道选择失败: %v", err)
+	}
+	if !explanation.ProbeUnknown || explanation.Winner != "1-10-100" {
+		t.Fatalf("未知状态通道应被直接选中，actual=%+v", explanation)
+	}
+	if explanation.Candidates[0].Status != health.ChannelStatusUnknown {
+		t.Fatalf("候选通道状态应为未知，actual=%v", explanation.Candidates[0].Status)
+	}
+}
@@ -0,0 +1,207 @@
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// DefaultHalfOpenTrials 半开状态默认同时放行的试探请求数
+const DefaultHalfOpenTrials = 1
+
+// BreakerState 资源熔断器状态
+//
+// 熔断器状态由健康状态推导：
+//   - 关闭：可用或未知状态，正常放行
+//   - 打开：警告/不可用状态且退避未结束（或不可用状态且不允许探测），拒绝请求
+//   - 半开：退避已结束，仅放行有限数量的试探请求，试探结果通过 UpdateStatus 决定恢复（关闭）或继续退避（打开）
+type BreakerState int8
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭
+	BreakerOpen                         // 打开
+	BreakerHalfOpen                     // 半开
+)
+
+// String 返回熔断器状态名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// trialSlots 半开资源的试探名额占用
+//
+// round 为本轮半开对应的退避结束时间（NextAvailableAt）：试探失败重新退避后进入新一轮半开，
+// 名额随之重新分配；generation 避免上一轮试探的归还影响新一轮的名额。
+type trialSlots struct {
+	generation uint64
+	round      time.Time
+	admitted   int
+}
+
+// breaker 维护半开资源的试探名额
+type breaker struct {
+	mu         sync.Mutex
+	maxTrials  int
+	slots      map[resourceKey]*trialSlots
+	generation uint64
+}
+
+// newBreaker 创建试探名额管理器
+func newBreaker(maxTrials int) *breaker {
+	return &breaker{
+		maxTrials: normalizeHalfOpenTrials(maxTrials),
+		slots:     make(map[resourceKey]*trialSlots),
+	}
+}
+
+// normalizeHalfOpenTrials 将未配置的试探请求数归一为默认值
+func normalizeHalfOpenTrials(trials int) int {
+	if trials <= 0 {
+		return DefaultHalfOpenTrials
+	}
+	return trials
+}
+
+// hasSlot 判断资源在本轮半开中是否还有空闲的试探名额
+func (b *breaker) hasSlot(key resourceKey, round time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	slots, ok := b.slots[key]
+	return !ok || !slots.round.Equal(round) || slots.admitted < b.maxTrials
+}
+
+// reset 清除资源的试探名额占用（熔断器关闭时调用）
+func (b *breaker) reset(key resourceKey) {
+	b.mu.Lock()
+	delete(b.slots, key)
+	b.mu.Unlock()
+}
+
+// Trial 通道获得的半开试探名额
+//
+// 请求结束后须调用 Release 归还名额；试探结果应通过 UpdateStatus 上报：
+// 成功时熔断器关闭，任何失败（包括可恢复失败）都会重新退避，熔断器再次打开。
+type Trial struct {
+	breaker     *breaker
+	keys        []resourceKey
+	generations []uint64
+	released    bool
+}
+
+// Release 归还试探名额，重复调用或对 nil 调用无效果
+func (t *Trial) Release() {
+	if t == nil {
+		return
+	}
+	t.breaker.mu.Lock()
+	defer t.breaker.mu.Unlock()
+	if t.released {
+		return
+	}
+	t.released = true
+	for i, key := range t.keys {
+		if slots, ok := t.breaker.slots[key]; ok && slots.generation == t.generations[i] && slots.admitted > 0 {
+			slots.admitted--
+		}
+	}
+}
+
+// SetHalfOpenTrials 在运行时调整半开状态同时放行的试探请求数（<= 0 时为 DefaultHalfOpenTrials）
+func (m *Service) SetHalfOpenTrials(trials int) {
+	m.breaker.mu.Lock()
+	m.breaker.maxTrials = normalizeHalfOpenTrials(trials)
+	m.breaker.mu.Unlock()
+}
+
+// BreakerState 返回资源当前的熔断器状态
+func (m *Service) BreakerState(resourceType ResourceType, resourceID uint) BreakerState {
+	status, err := m.storage.Get(resourceType, resourceID)
+	if err != nil {
+		return BreakerClosed
+	}
+	return m.breakerState(status, time.Now())
+}
+
+// breakerState 根据健康状态推导熔断器状态
+func (m *Service) breakerState(status *Health, now time.Time) BreakerState {
+	if status == nil {
+		return BreakerClosed
+	}
+
+	switch status.Status {
+	case HealthStatusAvailable, HealthStatusUnknown:
+		return BreakerClosed
+	case HealthStatusWarning:
+		if status.NextAvailableAt != nil && now.After(*status.NextAvailableAt) {
+			return BreakerHalfOpen
+		}
+		return BreakerOpen
+	case HealthStatusUnavailable:
+		_, allowProbing, _ := m.settings()
+		if allowProbing && status.NextAvailableAt != nil && now.After(*status.NextAvailableAt) {
+			return BreakerHalfOpen
+		}
+		return BreakerOpen
+	default:
+		return BreakerOpen
+	}
+}
+
+// AdmitChannel 为选中的通道申请半开试探名额
+//
+// 平台、模型、密钥中处于半开状态的资源各占用一个名额；任一半开资源名额已满时不放行，返回熔断器开启错误。
+// 没有半开资源时返回 nil Trial。放行后调用方须在请求结束时调用 Trial.Release。
+func (m *Service) AdmitChannel(platformID, modelID, apiKeyID uint) (*Trial, error) {
+	now := time.Now()
+	platformStatus, modelStatus, apiKeyStatus := m.getChannelStatuses(platformID, modelID, apiKeyID)
+
+	var (
+		halfOpen []resourceKey
+		rounds   []time.Time
+	)
+	for _, status := range []*Health{platformStatus, modelStatus, apiKeyStatus} {
+		if m.breakerState(status, now) == BreakerHalfOpen {
+			halfOpen = append(halfOpen, resourceKey{resourceType: status.ResourceType, resourceID: status.ResourceID})
+			rounds = append(rounds, *status.NextAvailableAt)
+		}
+	}
+	if len(halfOpen) == 0 {
+		return nil, nil
+	}
+
+	b := m.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, key := range halfOpen {
+		if slots, ok := b.slots[key]; ok && slots.round.Equal(rounds[i]) && slots.admitted >= b.maxTrials {
+			return nil, errors.New(errors.ErrCodeCircuitBreakerOpen, "熔断器半开试探名额已满").
+				WithContext("resource_type", key.resourceType).
+				WithContext("resource_id", key.resourceID)
+		}
+	}
+
+	trial := &Trial{breaker: b}
+	for i, key := range halfOpen {
+		slots, ok := b.slots[key]
+		if !ok || !slots.round.Equal(rounds[i]) {
+			// 首次试探或已进入新一轮半开，重新分配名额
+			b.generation++
+			slots = &trialSlots{generation: b.generation, round: rounds[i]}
+			b.slots[key] = slots
+		}
+		slots.admitted++
+		trial.keys = append(trial.keys, key)
+		trial.generations = append(trial.generations, slots.generation)
+	}
+	return trial, nil
+}
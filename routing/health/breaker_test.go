@@ -0,0 +1,136 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func TestBreaker_HalfOpenAdmitsLimitedTrials(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage, HalfOpenTrials: 2})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	future := time.Now().Add(time.Minute)
+	_ = storage.Set(&Health{ResourceType: ResourceTypeAPIKey, ResourceID: 3, Status: HealthStatusWarning, NextAvailableAt: &future})
+	if state := svc.BreakerState(ResourceTypeAPIKey, 3); state != BreakerOpen {
+		t.Fatalf("退避未结束时熔断器应打开，actual=%s", state)
+	}
+
+	past := time.Now().Add(-time.Second)
+	_ = storage.Set(&Health{ResourceType: ResourceTypeAPIKey, ResourceID: 3, Status: HealthStatusWarning, NextAvailableAt: &past})
+	if state := svc.BreakerState(ResourceTypeAPIKey, 3); state != BreakerHalfOpen {
+		t.Fatalf("退避结束后熔断器应半开，actual=%s", state)
+	}
+
+	first, err := svc.AdmitChannel(1, 2, 3)
+	if err != nil || first == nil {
+		t.Fatalf("半开状态应放行第一个试探请求，trial=%v err=%v", first, err)
+	}
+	second, err := svc.AdmitChannel(1, 2, 3)
+	if err != nil || second == nil {
+		t.Fatalf("半开状态应放行第二个试探请求，trial=%v err=%v", second, err)
+	}
+	if svc.IsHealthy(ResourceTypeAPIKey, 3, time.Time{}) {
+		t.Fatal("试探名额已满时资源应视为不健康")
+	}
+	if _, err := svc.AdmitChannel(1, 2, 3); !errors.IsCode(err, errors.ErrCodeCircuitBreakerOpen) {
+		t.Fatalf("试探名额已满时应返回熔断器开启错误，actual=%v", err)
+	}
+
+	first.Release()
+	first.Release()
+	if !svc.IsHealthy(ResourceTypeAPIKey, 3, time.Time{}) {
+		t.Fatal("归还试探名额后资源应可再次试探")
+	}
+	if trial, err := svc.AdmitChannel(1, 2, 3); err != nil || trial == nil {
+		t.Fatalf("归还后应再次放行，trial=%v err=%v", trial, err)
+	}
+}
+
+func TestBreaker_TrialResultDecidesTransition(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	_ = storage.Set(&Health{ResourceType: ResourceTypeModel, ResourceID: 2, Status: HealthStatusWarning, NextAvailableAt: &past})
+
+	trial, err := svc.AdmitChannel(1, 2, 3)
+	if err != nil || trial == nil {
+		t.Fatalf("半开状态应放行试探请求，trial=%v err=%v", trial, err)
+	}
+
+	// 试探失败：重新进入退避，熔断器打开
+	if err := svc.UpdateStatus(ResourceTypeModel, 2, false, ErrorSnapshot{Message: "请求失败", Impact: HealthImpactFull}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	trial.Release()
+	if state := svc.BreakerState(ResourceTypeModel, 2); state != BreakerOpen {
+		t.Fatalf("试探失败后熔断器应打开，actual=%s", state)
+	}
+
+	// 退避再次结束后，上一轮试探的归还不影响新一轮名额
+	_ = storage.Set(&Health{ResourceType: ResourceTypeModel, ResourceID: 2, Status: HealthStatusWarning, NextAvailableAt: &past})
+	trial, err = svc.AdmitChannel(1, 2, 3)
+	if err != nil || trial == nil {
+		t.Fatalf("新一轮半开应放行试探请求，trial=%v err=%v", trial, err)
+	}
+
+	// 试探成功：熔断器关闭，不再占用名额
+	if err := svc.UpdateStatus(ResourceTypeModel, 2, true, ErrorSnapshot{}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	if state := svc.BreakerState(ResourceTypeModel, 2); state != BreakerClosed {
+		t.Fatalf("试探成功后熔断器应关闭，actual=%s", state)
+	}
+	if trial, err := svc.AdmitChannel(1, 2, 3); err != nil || trial != nil {
+		t.Fatalf("熔断器关闭时不应占用试探名额，trial=%v err=%v", trial, err)
+	}
+	trial.Release()
+}
+
+func TestBreaker_RecoverableTrialFailureReopens(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	_ = storage.Set(&Health{ResourceType: ResourceTypePlatform, ResourceID: 1, Status: HealthStatusWarning, NextAvailableAt: &past})
+
+	trial, err := svc.AdmitChannel(1, 2, 3)
+	if err != nil || trial == nil {
+		t.Fatalf("半开状态应放行试探请求，trial=%v err=%v", trial, err)
+	}
+	defer trial.Release()
+
+	// 共享该平台的其他资源写入不影响试探名额
+	if err := svc.UpdateStatus(ResourceTypeModel, 5, true, ErrorSnapshot{}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	if _, err := svc.AdmitChannel(1, 5, 6); !errors.IsCode(err, errors.ErrCodeCircuitBreakerOpen) {
+		t.Fatalf("试探名额已满时应拒绝并发请求，actual=%v", err)
+	}
+
+	// 试探遇到可恢复失败：重新退避，并发请求仍被拒绝
+	if err := svc.UpdateStatus(ResourceTypePlatform, 1, false, ErrorSnapshot{Message: "请求超时", Impact: HealthImpactRecoverable}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	if state := svc.BreakerState(ResourceTypePlatform, 1); state != BreakerOpen {
+		t.Fatalf("可恢复的试探失败后熔断器应打开，actual=%s", state)
+	}
+	status, _ := svc.GetStatus(ResourceTypePlatform, 1)
+	if status.NextAvailableAt == nil || !status.NextAvailableAt.After(time.Now()) {
+		t.Fatalf("试探失败应设置新的下次可用时间，actual=%v", status.NextAvailableAt)
+	}
+	if result := svc.CheckChannelHealth(1, 5, 6); result.Status != ChannelStatusUnavailable {
+		t.Fatalf("熔断器重新打开后并发请求应被拒绝，actual=%v", result.Status)
+	}
+}
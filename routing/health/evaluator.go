@@ -0,0 +1,178 @@
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+const (
+	// DefaultErrorRateWindow 错误率统计的默认窗口
+	DefaultErrorRateWindow = time.Minute
+
+	// DefaultErrorRateBuckets 错误率统计窗口的默认分桶数
+	DefaultErrorRateBuckets = 10
+
+	// DefaultErrorRateThreshold 默认触发退避的错误率
+	DefaultErrorRateThreshold = 0.5
+
+	// DefaultErrorRateMinRequests 默认触发退避所需的窗口内最少请求数
+	DefaultErrorRateMinRequests = 20
+)
+
+// Evaluator 决定完全降级的失败是否触发退避
+//
+// 未配置时按连续失败次数（FailureThreshold）判定。实现者需要保证线程安全性。
+type Evaluator interface {
+	// Observe 记录一次成功或完全降级的失败，返回是否应对资源应用退避策略（success 为 true 时返回值被忽略）
+	//
+	// status 为已计入本次结果的健康状态，仅供读取。
+	Observe(status *Health, success bool, now time.Time) bool
+
+	// Record 记录一次可恢复失败，仅计入统计，不触发退避
+	Record(status *Health, now time.Time)
+}
+
+// SlidingWindowConfig 滑动窗口错误率判定配置
+type SlidingWindowConfig struct {
+	Window      time.Duration // 统计窗口（<= 0 时使用 DefaultErrorRateWindow）
+	Buckets     int           // 窗口分桶数，越大过期越平滑（<= 0 时使用 DefaultErrorRateBuckets）
+	ErrorRate   float64       // 触发退避的错误率，取值 (0, 1]（<= 0 时使用 DefaultErrorRateThreshold）
+	MinRequests int           // 窗口内请求数达到该值后才按错误率判定（<= 0 时使用 DefaultErrorRateMinRequests）
+}
+
+// SlidingWindowEvaluator 按滑动窗口内的错误率判定是否退避
+//
+// 每个资源在窗口内按时间分桶累计成功与失败次数（可恢复失败同样计入）：
+// 完全降级的失败发生时，窗口内请求数达到 MinRequests 且错误率达到 ErrorRate 则触发退避，
+// 触发后清空该资源的统计，恢复后重新累计。
+// 高 QPS 资源的偶发失败不会触发退避，而持续以较高比例失败的资源即使从未连续失败也会被退避。
+// 资源已处于退避中（RetryCount > 0，如半开试探失败）时，失败直接触发退避以继续升级退避时间。
+type SlidingWindowEvaluator struct {
+	bucketSize  time.Duration
+	buckets     int
+	errorRate   float64
+	minRequests int
+
+	mu      sync.Mutex
+	windows map[resourceKey][]windowBucket
+}
+
+// windowBucket 单个时间桶内的请求统计
+type windowBucket struct {
+	epoch     int64 // 桶序号（时间 / 桶时长）
+	successes int
+	failures  int
+}
+
+// NewSlidingWindowEvaluator 创建滑动窗口错误率判定器
+func NewSlidingWindowEvaluator(cfg SlidingWindowConfig) (*SlidingWindowEvaluator, error) {
+	if cfg.ErrorRate > 1 {
+		return nil, errors.New(errors.ErrCodeConfigInvalid, "错误率阈值不能大于 1").
+			WithContext("error_rate", cfg.ErrorRate)
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultErrorRateWindow
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = DefaultErrorRateBuckets
+	}
+	if cfg.ErrorRate <= 0 {
+		cfg.ErrorRate = DefaultErrorRateThreshold
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultErrorRateMinRequests
+	}
+
+	bucketSize := cfg.Window / time.Duration(cfg.Buckets)
+	if bucketSize <= 0 {
+		return nil, errors.New(errors.ErrCodeConfigInvalid, "统计窗口过短，无法按分桶数切分").
+			WithContext("window", cfg.Window).
+			WithContext("buckets", cfg.Buckets)
+	}
+	return &SlidingWindowEvaluator{
+		bucketSize:  bucketSize,
+		buckets:     cfg.Buckets,
+		errorRate:   cfg.ErrorRate,
+		minRequests: cfg.MinRequests,
+		windows:     make(map[resourceKey][]windowBucket),
+	}, nil
+}
+
+// Observe 记录一次请求结果，窗口内错误率达到阈值时返回 true
+func (e *SlidingWindowEvaluator) Observe(status *Health, success bool, now time.Time) bool {
+	key := resourceKey{resourceType: status.ResourceType, resourceID: status.ResourceID}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !success && status.RetryCount > 0 {
+		delete(e.windows, key)
+		return true
+	}
+
+	window, epoch := e.record(key, success, now)
+	if success {
+		return false
+	}
+
+	successes, failures := e.sum(window, epoch)
+	total := successes + failures
+	if total < e.minRequests || float64(failures) < e.errorRate*float64(total) {
+		return false
+	}
+	delete(e.windows, key)
+	return true
+}
+
+// Record 将可恢复失败计入窗口内的失败次数
+func (e *SlidingWindowEvaluator) Record(status *Health, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.record(resourceKey{resourceType: status.ResourceType, resourceID: status.ResourceID}, false, now)
+}
+
+// record 将一次请求结果计入当前时间桶，返回资源的窗口与当前桶序号
+func (e *SlidingWindowEvaluator) record(key resourceKey, success bool, now time.Time) ([]windowBucket, int64) {
+	window, ok := e.windows[key]
+	if !ok {
+		window = make([]windowBucket, e.buckets)
+		e.windows[key] = window
+	}
+	epoch := now.UnixNano() / int64(e.bucketSize)
+	bucket := &window[epoch%int64(e.buckets)]
+	if bucket.epoch != epoch {
+		*bucket = windowBucket{epoch: epoch}
+	}
+	if success {
+		bucket.successes++
+	} else {
+		bucket.failures++
+	}
+	return window, epoch
+}
+
+// Counts 返回资源在当前窗口内的成功与失败次数
+func (e *SlidingWindowEvaluator) Counts(resourceType ResourceType, resourceID uint, now time.Time) (successes, failures int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	window, ok := e.windows[resourceKey{resourceType: resourceType, resourceID: resourceID}]
+	if !ok {
+		return 0, 0
+	}
+	return e.sum(window, now.UnixNano()/int64(e.bucketSize))
+}
+
+// sum 累计窗口内未过期的桶
+func (e *SlidingWindowEvaluator) sum(window []windowBucket, epoch int64) (successes, failures int) {
+	oldest := epoch - int64(e.buckets) + 1
+	for _, bucket := range window {
+		if bucket.epoch >= oldest && bucket.epoch <= epoch {
+			successes += bucket.successes
+			failures += bucket.failures
+		}
+	}
+	return successes, failures
+}
@@ -0,0 +1,141 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func TestSlidingWindowEvaluator_ErrorRateAndVolume(t *testing.T) {
+	evaluator, err := NewSlidingWindowEvaluator(SlidingWindowConfig{Window: time.Minute, ErrorRate: 0.3, MinRequests: 10})
+	if err != nil {
+		t.Fatalf("创建判定器失败: %v", err)
+	}
+	now := time.Now()
+
+	// 高 QPS 资源的偶发失败不触发退避
+	busy := &Health{ResourceType: ResourceTypeAPIKey, ResourceID: 1}
+	for i := 0; i < 99; i++ {
+		evaluator.Observe(busy, true, now)
+	}
+	if evaluator.Observe(busy, false, now) {
+		t.Fatal("错误率 1% 时不应触发退避")
+	}
+
+	// 请求量不足时不按错误率判定
+	quiet := &Health{ResourceType: ResourceTypeAPIKey, ResourceID: 2}
+	for i := 0; i < 3; i++ {
+		if evaluator.Observe(quiet, false, now) {
+			t.Fatal("未达最少请求数时不应触发退避")
+		}
+	}
+
+	// 交替失败（从未连续失败两次）达到 40% 错误率时触发
+	flaky := &Health{ResourceType: ResourceTypeAPIKey, ResourceID: 3}
+	tripped := false
+	for i := 0; i < 20 && !tripped; i++ {
+		success := i%5 != 1 && i%5 != 3
+		tripped = evaluator.Observe(flaky, success, now) && !success
+	}
+	if !tripped {
+		t.Fatal("错误率 40% 且达到最少请求数时应触发退避")
+	}
+	if successes, failures := evaluator.Counts(ResourceTypeAPIKey, 3, now); successes != 0 || failures != 0 {
+		t.Fatalf("触发后应清空统计，successes=%d failures=%d", successes, failures)
+	}
+
+	// 已在退避中的资源失败时直接升级
+	flaky.RetryCount = 1
+	if !evaluator.Observe(flaky, false, now) {
+		t.Fatal("退避中的试探失败应继续触发退避")
+	}
+}
+
+func TestSlidingWindowEvaluator_OldBucketsExpire(t *testing.T) {
+	evaluator, err := NewSlidingWindowEvaluator(SlidingWindowConfig{Window: 10 * time.Second, Buckets: 10, ErrorRate: 0.5, MinRequests: 4})
+	if err != nil {
+		t.Fatalf("创建判定器失败: %v", err)
+	}
+	status := &Health{ResourceType: ResourceTypeModel, ResourceID: 1}
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		evaluator.Observe(status, false, start)
+	}
+	later := start.Add(11 * time.Second)
+	if evaluator.Observe(status, false, later) {
+		t.Fatal("窗口外的失败不应计入错误率")
+	}
+	if successes, failures := evaluator.Counts(ResourceTypeModel, 1, later); successes != 0 || failures != 1 {
+		t.Fatalf("窗口内应只剩最近一次失败，successes=%d failures=%d", successes, failures)
+	}
+}
+
+func TestUpdateStatus_EvaluatorReplacesFailureThreshold(t *testing.T) {
+	evaluator, err := NewSlidingWindowEvaluator(SlidingWindowConfig{ErrorRate: 0.5, MinRequests: 4})
+	if err != nil {
+		t.Fatalf("创建判定器失败: %v", err)
+	}
+	svc, err := New(Config{Storage: newTestHealthStorage(), Evaluator: evaluator})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	snapshot := ErrorSnapshot{Message: "请求失败", Impact: HealthImpactFull}
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, true, ErrorSnapshot{})
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, false, snapshot)
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, true, ErrorSnapshot{})
+	if !svc.IsHealthy(ResourceTypeAPIKey, 1, time.Time{}) {
+		t.Fatal("配置判定器后单次失败不应触发退避")
+	}
+
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, false, snapshot)
+	if svc.IsHealthy(ResourceTypeAPIKey, 1, time.Time{}) {
+		t.Fatal("窗口内错误率达到阈值后应进入退避")
+	}
+
+	svc.SetEvaluator(nil)
+	_ = svc.ResetHealth(ResourceTypeAPIKey, 2)
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 2, false, snapshot)
+	if svc.IsHealthy(ResourceTypeAPIKey, 2, time.Time{}) {
+		t.Fatal("移除判定器后应恢复按失败阈值判定")
+	}
+}
+
+func TestUpdateStatus_RecoverableFailuresCountTowardErrorRate(t *testing.T) {
+	evaluator, err := NewSlidingWindowEvaluator(SlidingWindowConfig{ErrorRate: 0.5, MinRequests: 4})
+	if err != nil {
+		t.Fatalf("创建判定器失败: %v", err)
+	}
+	svc, err := New(Config{Storage: newTestHealthStorage(), Evaluator: evaluator})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	recoverable := ErrorSnapshot{Message: "请求过多", Impact: HealthImpactRecoverable}
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, true, ErrorSnapshot{})
+	for i := 0; i < 3; i++ {
+		_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, false, recoverable)
+	}
+	if successes, failures := evaluator.Counts(ResourceTypeAPIKey, 1, time.Now()); successes != 1 || failures != 3 {
+		t.Fatalf("可恢复失败应计入统计窗口，successes=%d failures=%d", successes, failures)
+	}
+	if status, _ := svc.GetStatus(ResourceTypeAPIKey, 1); status.RetryCount != 0 || status.NextAvailableAt != nil {
+		t.Fatalf("可恢复失败本身不应触发退避，actual=%+v", status)
+	}
+
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 1, false, ErrorSnapshot{Message: "请求失败", Impact: HealthImpactFull})
+	if status, _ := svc.GetStatus(ResourceTypeAPIKey, 1); status.RetryCount != 1 || status.NextAvailableAt == nil {
+		t.Fatalf("计入可恢复失败后错误率达到阈值，完全降级的失败应触发退避，actual=%+v", status)
+	}
+}
+
+func TestNewSlidingWindowEvaluator_InvalidConfig(t *testing.T) {
+	if _, err := NewSlidingWindowEvaluator(SlidingWindowConfig{ErrorRate: 1.5}); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("错误率大于 1 应校验失败，actual=%v", err)
+	}
+	if _, err := NewSlidingWindowEvaluator(SlidingWindowConfig{Window: time.Nanosecond, Buckets: 10}); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("窗口无法分桶时应校验失败，actual=%v", err)
+	}
+}
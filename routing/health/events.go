@@ -0,0 +1,148 @@
+package health
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEventBuffer 订阅默认的事件缓冲区大小
+const DefaultEventBuffer = 64
+
+// EventReason 健康事件的触发原因
+type EventReason string
+
+const (
+	EventReasonUpdate  EventReason = "update"  // 请求或探测结果（UpdateStatus）
+	EventReasonReset   EventReason = "reset"   // 手动重置（ResetHealth）
+	EventReasonDisable EventReason = "disable" // 手动禁用（DisableHealth）
+)
+
+// Event 健康状态变更事件
+type Event struct {
+	ResourceType ResourceType // 资源类型
+	ResourceID   uint         // 资源 ID
+	Reason       EventReason  // 触发原因
+
+	OldStatus HealthStatus // 变更前状态
+	NewStatus HealthStatus // 变更后状态
+
+	Snapshot        ErrorSnapshot // 导致变更的错误摘要（成功、重置时为零值）
+	RetryCount      int           // 变更后的重试次数
+	ErrorCount      int           // 变更后的错误次数
+	Backoff         time.Duration // 变更后的退避时长
+	NextAvailableAt *time.Time    // 变更后的下次可用时间（手动禁用时为空）
+
+	At time.Time // 变更时间
+}
+
+// Subscription 健康事件订阅
+//
+// 事件以非阻塞方式投递：缓冲区已满时丢弃该订阅的事件并计入 Dropped，不会阻塞健康状态更新。
+// 不再需要时须调用 Close，关闭后 C 会被关闭。
+type Subscription struct {
+	C <-chan Event // 事件通道
+
+	ch      chan Event
+	bus     *eventBus
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Dropped 返回该订阅因缓冲区已满而丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close 取消订阅并关闭事件通道，重复调用无效果
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.unsubscribe(s)
+	})
+}
+
+// eventBus 健康事件总线
+type eventBus struct {
+	mu      sync.RWMutex
+	subs    map[*Subscription]struct{}
+	dropped atomic.Uint64
+}
+
+// newEventBus 创建健康事件总线
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[*Subscription]struct{})}
+}
+
+// subscribe 新增订阅
+func (b *eventBus) subscribe(buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultEventBuffer
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, bus: b}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// unsubscribe 移除订阅并关闭事件通道
+func (b *eventBus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	close(sub.ch)
+	b.mu.Unlock()
+}
+
+// publish 向所有订阅非阻塞地投递事件
+func (b *eventBus) publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// SubscribeEvents 订阅健康状态变更事件，buffer <= 0 时使用 DefaultEventBuffer
+//
+// UpdateStatus 仅在状态（HealthStatus）发生变化时产生事件；ResetHealth 与 DisableHealth 总是产生事件。
+func (m *Service) SubscribeEvents(buffer int) *Subscription {
+	return m.events.subscribe(buffer)
+}
+
+// DroppedEvents 返回所有订阅累计丢弃的事件数
+func (m *Service) DroppedEvents() uint64 {
+	return m.events.dropped.Load()
+}
+
+// emitEvent 在状态写入后产生健康事件
+func (m *Service) emitEvent(reason EventReason, oldStatus HealthStatus, status *Health, snapshot ErrorSnapshot) {
+	if reason == EventReasonUpdate && oldStatus == status.Status {
+		return
+	}
+
+	var nextAvailableAt *time.Time
+	if status.NextAvailableAt != nil {
+		next := *status.NextAvailableAt
+		nextAvailableAt = &next
+	}
+	m.events.publish(Event{
+		ResourceType:    status.ResourceType,
+		ResourceID:      status.ResourceID,
+		Reason:          reason,
+		OldStatus:       oldStatus,
+		NewStatus:       status.Status,
+		Snapshot:        snapshot,
+		RetryCount:      status.RetryCount,
+		ErrorCount:      status.ErrorCount,
+		Backoff:         time.Duration(status.BackoffDuration) * time.Second,
+		NextAvailableAt: nextAvailableAt,
+		At:              status.UpdatedAt,
+	})
+}
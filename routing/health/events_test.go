@@ -0,0 +1,100 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeEvents_EmitsTransitions(t *testing.T) {
+	svc, err := New(Config{Storage: newTestHealthStorage(), FailureThreshold: 2})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+	sub := svc.SubscribeEvents(8)
+	defer sub.Close()
+
+	httpStatus := 503
+	snapshot := ErrorSnapshot{Message: "上游不可用", Code: "UNAVAILABLE", HTTPStatus: &httpStatus, Impact: HealthImpactFull}
+
+	// 未达失败阈值：状态从未知变为未知，不产生事件
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 5, false, snapshot)
+	// 达到阈值：进入退避
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 5, false, snapshot)
+
+	event := receiveEvent(t, sub)
+	if event.Reason != EventReasonUpdate || event.OldStatus != HealthStatusUnknown || event.NewStatus == HealthStatusUnknown {
+		t.Fatalf("进入退避应产生状态变更事件，actual=%+v", event)
+	}
+	if event.ResourceType != ResourceTypeAPIKey || event.ResourceID != 5 || event.Snapshot.Message != "上游不可用" {
+		t.Fatalf("事件应携带资源与错误摘要，actual=%+v", event)
+	}
+	if event.RetryCount != 1 || event.Backoff <= 0 || event.NextAvailableAt == nil {
+		t.Fatalf("事件应携带重试次数与退避信息，actual=%+v", event)
+	}
+
+	_ = svc.DisableHealth(ResourceTypeAPIKey, 5, "人工下线")
+	event = receiveEvent(t, sub)
+	if event.Reason != EventReasonDisable || event.NewStatus != HealthStatusUnavailable || event.Snapshot.Message != "人工下线" {
+		t.Fatalf("手动禁用应产生事件，actual=%+v", event)
+	}
+
+	_ = svc.ResetHealth(ResourceTypeAPIKey, 5)
+	event = receiveEvent(t, sub)
+	if event.Reason != EventReasonReset || event.OldStatus != HealthStatusUnavailable || event.NewStatus != HealthStatusAvailable {
+		t.Fatalf("手动重置应产生事件，actual=%+v", event)
+	}
+
+	// 状态未变化的更新不产生事件
+	_ = svc.UpdateStatus(ResourceTypeAPIKey, 5, true, ErrorSnapshot{})
+	select {
+	case event := <-sub.C:
+		t.Fatalf("状态未变化时不应产生事件，actual=%+v", event)
+	default:
+	}
+}
+
+func TestSubscribeEvents_NonBlockingDelivery(t *testing.T) {
+	svc, err := New(Config{Storage: newTestHealthStorage()})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+	slow := svc.SubscribeEvents(1)
+	fast := svc.SubscribeEvents(8)
+
+	for i := uint(1); i <= 3; i++ {
+		_ = svc.DisableHealth(ResourceTypePlatform, i, "维护")
+	}
+
+	if slow.Dropped() != 2 || fast.Dropped() != 0 {
+		t.Fatalf("缓冲区已满的订阅应丢弃事件，slow=%d fast=%d", slow.Dropped(), fast.Dropped())
+	}
+	if svc.DroppedEvents() != 2 {
+		t.Fatalf("累计丢弃事件数期望 2，actual=%d", svc.DroppedEvents())
+	}
+	if len(fast.C) != 3 {
+		t.Fatalf("未满的订阅应收到全部事件，actual=%d", len(fast.C))
+	}
+
+	slow.Close()
+	slow.Close()
+	if _, ok := <-slow.C; !ok {
+		t.Fatal("关闭前缓冲的事件应仍可读取")
+	}
+	if _, ok := <-slow.C; ok {
+		t.Fatal("关闭订阅后事件通道应被关闭")
+	}
+	_ = svc.DisableHealth(ResourceTypePlatform, 4, "维护")
+	fast.Close()
+}
+
+// receiveEvent 读取一个事件，超时则测试失败
+func receiveEvent(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event := <-sub.C:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("未收到健康事件")
+		return Event{}
+	}
+}
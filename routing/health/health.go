@@ -2,6 +2,7 @@
 package health
 
 import (
+	"sync"
 	"time"
 
 	"github.com/MeowSalty/portal/errors"
@@ -9,12 +10,32 @@ import (
 
 // Service 管理所有资源的健康状态
 type Service struct {
-	storage Storage         // 存储接口
+	storage Storage // 存储接口
+
+	mu      sync.RWMutex    // 保护以下可在运行时调整的配置
 	backoff BackoffStrategy // 退避策略
-	filter  Filter          // 健康状态过滤器
-	// allowProbing 控制 Unavailable 状态在退避结束后是否允许探测。
-	// 该配置与 filter 保持一致，用于避免重复存储读取时在 Service 层复用同一判定语义。
+	// allowProbing 控制 Unavailable 状态在退避结束后是否允许探测（进入半开状态）。
 	allowProbing bool
+	// failureThreshold 连续失败达到该次数后才应用退避策略
+	failureThreshold int
+	// evaluator 失败是否触发退避的判定器，为空时按 failureThreshold 判定
+	evaluator Evaluator
+
+	degradedMu sync.Mutex
+	// degraded 本实例观察到的处于警告或不可用状态的资源，供主动探测使用
+	degraded map[resourceKey]struct{}
+
+	// breaker 半开资源的试探名额
+	breaker *breaker
+
+	// events 健康状态变更事件总线
+	events *eventBus
+}
+
+// resourceKey 资源标识
+type resourceKey struct {
+	resourceType ResourceType
+	resourceID   uint
 }
 
 // Config 管理器配置
@@ -22,6 +43,17 @@ type Config struct {
 	Storage      Storage         // 存储接口（必需）
 	Backoff      BackoffStrategy // 退避策略（可选）
 	AllowProbing bool            // 是否允许对 Unavailable 状态的资源进行探测（可选，默认 false）
+
+	// FailureThreshold 连续失败达到该次数后才应用退避策略（可选，<= 0 时为 1，即首次失败即退避）。
+	// 未达阈值的失败仅计入错误计数，不改变资源状态。
+	FailureThreshold int
+
+	// HalfOpenTrials 退避结束（熔断器半开）后同时放行的试探请求数（可选，<= 0 时为 DefaultHalfOpenTrials）。
+	// 避免退避结束的瞬间所有并发请求同时涌向正在恢复的上游。
+	HalfOpenTrials int
+
+	// Evaluator 失败是否触发退避的判定器（可选，如 SlidingWindowEvaluator），配置后 FailureThreshold 不再生效。
+	Evaluator Evaluator
 }
 
 // New 创建一个新的健康状态管理器
@@ -38,23 +70,110 @@ func New(cfg Config) (*Service, error) {
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "存储接口不能为空")
 	}
 
+	if cfg.FailureThreshold < 0 {
+		return nil, errors.New(errors.ErrCodeConfigInvalid, "失败阈值不能为负数").
+			WithContext("failure_threshold", cfg.FailureThreshold)
+	}
+
 	if cfg.Backoff == nil {
 		cfg.Backoff = DefaultBackoffStrategy()
 	}
 
-	// 创建过滤器
-	filter := NewFilter(cfg.Storage, cfg.AllowProbing)
+	if cfg.HalfOpenTrials < 0 {
+		return nil, errors.New(errors.ErrCodeConfigInvalid, "半开试探请求数不能为负数").
+			WithContext("half_open_trials", cfg.HalfOpenTrials)
+	}
 
 	m := &Service{
-		storage:      cfg.Storage,
-		backoff:      cfg.Backoff,
-		filter:       filter,
-		allowProbing: cfg.AllowProbing,
+		storage:          cfg.Storage,
+		backoff:          cfg.Backoff,
+		breaker:          newBreaker(cfg.HalfOpenTrials),
+		allowProbing:     cfg.AllowProbing,
+		failureThreshold: normalizeFailureThreshold(cfg.FailureThreshold),
+		evaluator:        cfg.Evaluator,
+		degraded:         make(map[resourceKey]struct{}),
+		events:           newEventBus(),
 	}
 
 	return m, nil
 }
 
+// normalizeFailureThreshold 将未配置的失败阈值归一为 1
+func normalizeFailureThreshold(threshold int) int {
+	if threshold <= 0 {
+		return 1
+	}
+	return threshold
+}
+
+// SetBackoff 在运行时替换退避策略，为 nil 时恢复默认策略
+//
+// 仅影响之后的状态更新，已写入的退避时间不会重新计算。
+func (m *Service) SetBackoff(backoff BackoffStrategy) {
+	if backoff == nil {
+		backoff = DefaultBackoffStrategy()
+	}
+	m.mu.Lock()
+	m.backoff = backoff
+	m.mu.Unlock()
+}
+
+// SetAllowProbing 在运行时调整是否允许对 Unavailable 状态的资源进行探测
+func (m *Service) SetAllowProbing(allow bool) {
+	m.mu.Lock()
+	m.allowProbing = allow
+	m.mu.Unlock()
+}
+
+// SetFailureThreshold 在运行时调整应用退避前允许的连续失败次数
+func (m *Service) SetFailureThreshold(threshold int) error {
+	if threshold < 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "失败阈值不能为负数").
+			WithContext("failure_threshold", threshold)
+	}
+	m.mu.Lock()
+	m.failureThreshold = normalizeFailureThreshold(threshold)
+	m.mu.Unlock()
+	return nil
+}
+
+// SetEvaluator 在运行时替换失败判定器，为 nil 时恢复按失败阈值判定
+func (m *Service) SetEvaluator(evaluator Evaluator) {
+	m.mu.Lock()
+	m.evaluator = evaluator
+	m.mu.Unlock()
+}
+
+// evaluate 将本次结果计入判定器，返回是否应用退避策略（成功时总是 false）
+func (m *Service) evaluate(status *Health, success bool, now time.Time) bool {
+	m.mu.RLock()
+	evaluator, failureThreshold := m.evaluator, m.failureThreshold
+	m.mu.RUnlock()
+
+	if evaluator != nil {
+		return evaluator.Observe(status, success, now) && !success
+	}
+	return !success && status.ErrorCount >= failureThreshold
+}
+
+// record 将可恢复失败计入判定器的统计窗口（未配置判定器时无操作）
+func (m *Service) record(status *Health, now time.Time) {
+	m.mu.RLock()
+	evaluator := m.evaluator
+	m.mu.RUnlock()
+
+	if evaluator != nil {
+		evaluator.Record(status, now)
+	}
+}
+
+// settings 返回当前的退避策略、探测开关与失败阈值
+func (m *Service) settings() (BackoffStrategy, bool, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.backoff, m.allowProbing, m.failureThreshold
+}
+
 // GetStatus 获取指定资源的健康状态
 //
 // 如果存储中不存在该资源的健康状态，则会创建一个新的健康状态对象
@@ -120,9 +239,13 @@ func (m *Service) UpdateStatus(
 	if err != nil {
 		return err
 	}
+	oldStatus := status.Status
+	backoff, _, _ := m.settings()
 
 	// 更新基础信息
 	now := time.Now()
+	// 半开状态下的结果即试探结果，失败时须重新退避
+	trialFailed := !success && m.breakerState(status, now) == BreakerHalfOpen
 	status.LastCheckAt = now
 	status.UpdatedAt = now
 
@@ -140,7 +263,9 @@ func (m *Service) UpdateStatus(
 		status.ErrorCount = 0
 
 		// 使用退避策略重置状态
-		m.backoff.Reset(status)
+		backoff.Reset(status)
+		// 成功同样计入判定器的统计窗口
+		m.evaluate(status, true, now)
 	} else if snapshot.Impact == HealthImpactRecoverable {
 		// 可恢复失败：记录错误信息但不增加错误计数，仅标记为警告
 		status.LastErrorMessage = snapshot.Message
@@ -161,6 +286,12 @@ func (m *Service) UpdateStatus(
 		if status.Status != HealthStatusUnavailable {
 			status.Status = HealthStatusWarning
 		}
+		// 可恢复失败仍计入判定器的统计窗口
+		m.record(status, now)
+		// 半开试探失败：重新退避，熔断器再次打开
+		if trialFailed {
+			backoff.Apply(status)
+		}
 	} else {
 		// 完全降级失败：计入错误计数并应用退避策略
 		status.ErrorCount++
@@ -178,16 +309,46 @@ func (m *Service) UpdateStatus(
 			status.LastErrorCode = 0
 		}
 
-		// 使用退避策略更新状态
-		m.backoff.Apply(status)
+		// 连续失败达到阈值（或判定器触发）后使用退避策略更新状态，半开试探失败总是重新退避
+		if m.evaluate(status, false, now) || trialFailed {
+			backoff.Apply(status)
+		}
 	}
 
 	// 保存到存储
-	return m.storage.Set(status)
+	if success {
+		snapshot = ErrorSnapshot{}
+	}
+	return m.setStatus(EventReasonUpdate, oldStatus, status, snapshot)
+}
+
+// setStatus 保存健康状态，记录资源是否处于降级状态并产生健康事件
+func (m *Service) setStatus(reason EventReason, oldStatus HealthStatus, status *Health, snapshot ErrorSnapshot) error {
+	if err := m.storage.Set(status); err != nil {
+		return err
+	}
+	m.emitEvent(reason, oldStatus, status, snapshot)
+
+	// 熔断器关闭后清除试探名额；重新退避进入的新一轮半开由 NextAvailableAt 区分，无需在此清除
+	key := resourceKey{resourceType: status.ResourceType, resourceID: status.ResourceID}
+	if m.breakerState(status, time.Now()) == BreakerClosed {
+		m.breaker.reset(key)
+	}
+
+	m.degradedMu.Lock()
+	defer m.degradedMu.Unlock()
+	if status.Status == HealthStatusWarning || status.Status == HealthStatusUnavailable {
+		m.degraded[key] = struct{}{}
+	} else {
+		delete(m.degraded, key)
+	}
+	return nil
 }
 
 // IsHealthy 检查指定资源是否健康
 //
+// 熔断器关闭时健康；打开时不健康；半开时仅在仍有空闲试探名额时健康（不占用名额）。
+//
 // 参数：
 //   - resourceType: 资源类型
 //   - resourceID: 资源 ID
@@ -199,7 +360,12 @@ func (m *Service) IsHealthy(resourceType ResourceType, resourceID uint, now time
 	if now.IsZero() {
 		now = time.Now()
 	}
-	return m.filter.IsHealthy(resourceType, resourceID, now)
+	status, err := m.storage.Get(resourceType, resourceID)
+	if err != nil {
+		// 读取失败视为未知状态（可以尝试）
+		return true
+	}
+	return m.isResourceHealthyByStatus(status, now)
 }
 
 // ChannelStatus 通道健康状态
@@ -213,8 +379,9 @@ const (
 
 // ChannelHealthResult 通道健康检查结果
 type ChannelHealthResult struct {
-	Status      ChannelStatus // 通道状态
-	LastCheckAt time.Time     // 最后检查时间（从平台、密钥、模型中取最新值）
+	Status          ChannelStatus // 通道状态
+	LastCheckAt     time.Time     // 最后检查时间（从平台、密钥、模型中取最新值）
+	TrialsExhausted bool          // 通道不可用是否仅因半开资源的试探名额已满（而非退避未结束）
 }
 
 // CheckChannelHealth 检查通道的可用性
@@ -270,6 +437,37 @@ func (m *Service) GetChannelHealthAndLastTryTimes(
 	return result, platformLastTry, modelLastTry, keyLastTry
 }
 
+// ChannelInspection 通道健康检查明细
+type ChannelInspection struct {
+	ChannelHealthResult
+
+	Platform *Health // 平台健康状态（nil 表示存储中不存在或读取失败）
+	Model    *Health // 模型健康状态
+	APIKey   *Health // 密钥健康状态
+
+	PlatformHealthy bool // 平台是否判定为健康（含可探测）
+	ModelHealthy    bool // 模型是否判定为健康
+	APIKeyHealthy   bool // 密钥是否判定为健康
+}
+
+// InspectChannel 获取通道健康检查结果及平台/模型/密钥各自的健康状态
+//
+// 判定规则与 CheckChannelHealth 一致。该方法只读取存储，不会更新任何状态或最近尝试时间，
+// 可用于诊断与路由解释。
+func (m *Service) InspectChannel(platformID, modelID, apiKeyID uint) ChannelInspection {
+	now := time.Now()
+	platformStatus, modelStatus, apiKeyStatus := m.getChannelStatuses(platformID, modelID, apiKeyID)
+	return ChannelInspection{
+		ChannelHealthResult: m.evaluateChannelHealth(now, platformStatus, modelStatus, apiKeyStatus),
+		Platform:            platformStatus,
+		Model:               modelStatus,
+		APIKey:              apiKeyStatus,
+		PlatformHealthy:     m.isResourceHealthyByStatus(platformStatus, now),
+		ModelHealthy:        m.isResourceHealthyByStatus(modelStatus, now),
+		APIKeyHealthy:       m.isResourceHealthyByStatus(apiKeyStatus, now),
+	}
+}
+
 // getChannelStatuses 获取平台/模型/密钥状态。
 // 如果存储层读取失败，则将对应状态视为 nil（未知）。
 func (m *Service) getChannelStatuses(platformID, modelID, apiKeyID uint) (*Health, *Health, *Health) {
@@ -305,6 +503,9 @@ func (m *Service) evaluateChannelHealth(now time.Time, platformStatus, modelStat
 		return ChannelHealthResult{
 			Status:      ChannelStatusUnavailable,
 			LastCheckAt: lastCheckAt,
+			TrialsExhausted: (platformHealthy || m.breakerState(platformStatus, now) == BreakerHalfOpen) &&
+				(modelHealthy || m.breakerState(modelStatus, now) == BreakerHalfOpen) &&
+				(apiKeyHealthy || m.breakerState(apiKeyStatus, now) == BreakerHalfOpen),
 		}
 	}
 
@@ -325,18 +526,11 @@ func (m *Service) evaluateChannelHealth(now time.Time, platformStatus, modelStat
 
 // isResourceHealthyByStatus 在已获取资源状态的前提下执行健康判定。
 func (m *Service) isResourceHealthyByStatus(status *Health, now time.Time) bool {
-	if status == nil {
-		// 如果存储中不存在或读取失败，视为未知状态（可以尝试）
-		return true
-	}
-
-	switch status.Status {
-	case HealthStatusAvailable, HealthStatusUnknown:
+	switch m.breakerState(status, now) {
+	case BreakerClosed:
 		return true
-	case HealthStatusWarning:
-		return status.NextAvailableAt != nil && now.After(*status.NextAvailableAt)
-	case HealthStatusUnavailable:
-		return m.allowProbing && status.NextAvailableAt != nil && now.After(*status.NextAvailableAt)
+	case BreakerHalfOpen:
+		return m.breaker.hasSlot(resourceKey{resourceType: status.ResourceType, resourceID: status.ResourceID}, *status.NextAvailableAt)
 	default:
 		return false
 	}
@@ -474,10 +668,12 @@ func (m *Service) ResetHealth(resourceType ResourceType, resourceID uint) error
 	if err != nil {
 		return err
 	}
+	oldStatus := status.Status
 	now := time.Now()
 	status.UpdatedAt = now
-	m.backoff.Reset(status)
-	return m.storage.Set(status)
+	backoff, _, _ := m.settings()
+	backoff.Reset(status)
+	return m.setStatus(EventReasonReset, oldStatus, status, ErrorSnapshot{})
 }
 
 // DisableHealth 手动将指定资源设置为不可用状态
@@ -496,11 +692,12 @@ func (m *Service) DisableHealth(resourceType ResourceType, resourceID uint, reas
 	if err != nil {
 		return err
 	}
+	oldStatus := status.Status
 	now := time.Now()
 	status.Status = HealthStatusUnavailable
 	status.LastError = reason
 	status.LastCheckAt = now
 	status.UpdatedAt = now
 	status.NextAvailableAt = nil // 手动禁用不设置自动恢复时间
-	return m.storage.Set(status)
+	return m.setStatus(EventReasonDisable, oldStatus, status, ErrorSnapshot{Message: reason, Impact: HealthImpactFull})
 }
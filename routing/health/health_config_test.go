@@ -0,0 +1,71 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func TestUpdateStatus_FailureThresholdDelaysBackoff(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage, FailureThreshold: 3})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	snapshot := ErrorSnapshot{Message: "请求失败", Impact: HealthImpactFull}
+	for i := 0; i < 2; i++ {
+		if err := svc.UpdateStatus(ResourceTypeAPIKey, 1, false, snapshot); err != nil {
+			t.Fatalf("UpdateStatus 失败: %v", err)
+		}
+	}
+	if !svc.IsHealthy(ResourceTypeAPIKey, 1, time.Time{}) {
+		t.Fatal("未达失败阈值时不应退避")
+	}
+
+	if err := svc.UpdateStatus(ResourceTypeAPIKey, 1, false, snapshot); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	if svc.IsHealthy(ResourceTypeAPIKey, 1, time.Time{}) {
+		t.Fatal("达到失败阈值后应进入退避")
+	}
+}
+
+func TestService_RuntimeSettings(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	_ = storage.Set(&Health{ResourceType: ResourceTypePlatform, ResourceID: 1, Status: HealthStatusUnavailable, NextAvailableAt: &past})
+	if svc.IsHealthy(ResourceTypePlatform, 1, time.Time{}) {
+		t.Fatal("未允许探测时不可用资源应保持不健康")
+	}
+
+	svc.SetAllowProbing(true)
+	if !svc.IsHealthy(ResourceTypePlatform, 1, time.Time{}) {
+		t.Fatal("允许探测后退避结束的不可用资源应可被探测")
+	}
+
+	svc.SetBackoff(NewLinearBackoff(time.Second, time.Minute))
+	if err := svc.UpdateStatus(ResourceTypeModel, 2, false, ErrorSnapshot{Impact: HealthImpactFull}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	status, _ := svc.GetStatus(ResourceTypeModel, 2)
+	if status.BackoffDuration != 1 {
+		t.Fatalf("替换后的退避策略应立即生效，backoff=%ds", status.BackoffDuration)
+	}
+
+	if err := svc.SetFailureThreshold(-1); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("负数失败阈值应校验失败，actual=%v", err)
+	}
+}
+
+func TestNew_InvalidFailureThreshold(t *testing.T) {
+	if _, err := New(Config{Storage: newTestHealthStorage(), FailureThreshold: -1}); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("负数失败阈值应校验失败，actual=%v", err)
+	}
+}
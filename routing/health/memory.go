@@ -0,0 +1,154 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ListableStorage 支持枚举全部记录的健康状态存储
+//
+// 快照持久化（SnapshotStorage）需要通过该接口读取所有记录。
+type ListableStorage interface {
+	Storage
+
+	// List 返回所有健康状态记录的副本（按资源类型与 ID 排序）
+	List() ([]*Health, error)
+}
+
+// memoryEntry 内存存储中的一条记录
+type memoryEntry struct {
+	status    *Health
+	expiresAt time.Time // 零值表示不过期
+}
+
+// MemoryStorage 并发安全的内存健康状态存储
+//
+// 读写均复制记录，调用方修改返回值不会影响存储内容。
+// 配置 TTL 后，记录在最后一次写入 TTL 之后过期，读取时视为不存在并被淘汰；
+// 退避尚未结束的记录至少保留到 NextAvailableAt，手动禁用的记录不过期，避免过期导致提前恢复。
+type MemoryStorage struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[resourceKey]memoryEntry
+}
+
+// NewMemoryStorage 创建内存健康状态存储，ttl <= 0 表示记录不过期
+func NewMemoryStorage(ttl time.Duration) *MemoryStorage {
+	return &MemoryStorage{
+		ttl:     max(ttl, 0),
+		entries: make(map[resourceKey]memoryEntry),
+	}
+}
+
+// Get 获取指定资源的健康状态，不存在或已过期时返回 nil
+func (s *MemoryStorage) Get(resourceType ResourceType, resourceID uint) (*Health, error) {
+	key := resourceKey{resourceType: resourceType, resourceID: resourceID}
+
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	if entry.expired(time.Now()) {
+		s.mu.Lock()
+		// 重新检查，避免删除并发写入的新记录
+		if current, ok := s.entries[key]; ok && current.expired(time.Now()) {
+			delete(s.entries, key)
+		}
+		s.mu.Unlock()
+		return nil, nil
+	}
+	return cloneHealth(entry.status), nil
+}
+
+// Set 写入指定资源的健康状态
+func (s *MemoryStorage) Set(status *Health) error {
+	if status == nil {
+		return nil
+	}
+	status = cloneHealth(status)
+	key := resourceKey{resourceType: status.ResourceType, resourceID: status.ResourceID}
+
+	s.mu.Lock()
+	s.entries[key] = memoryEntry{status: status, expiresAt: s.expiresAt(status, time.Now())}
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete 删除指定资源的健康状态
+func (s *MemoryStorage) Delete(resourceType ResourceType, resourceID uint) error {
+	s.mu.Lock()
+	delete(s.entries, resourceKey{resourceType: resourceType, resourceID: resourceID})
+	s.mu.Unlock()
+	return nil
+}
+
+// List 返回所有未过期记录的副本（按资源类型与 ID 排序）
+func (s *MemoryStorage) List() ([]*Health, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	records := make([]*Health, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if !entry.expired(now) {
+			records = append(records, cloneHealth(entry.status))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].ResourceType != records[j].ResourceType {
+			return records[i].ResourceType < records[j].ResourceType
+		}
+		return records[i].ResourceID < records[j].ResourceID
+	})
+	return records, nil
+}
+
+// EvictExpired 淘汰所有已过期的记录，返回淘汰数量
+//
+// 读取时会惰性淘汰过期记录，资源数量较多且很少被读取时可定期调用该方法释放内存。
+func (s *MemoryStorage) EvictExpired() int {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	evicted := 0
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// expiresAt 计算记录的过期时间
+func (s *MemoryStorage) expiresAt(status *Health, now time.Time) time.Time {
+	// 手动禁用的记录没有恢复时间，不过期
+	if s.ttl <= 0 || (status.Status == HealthStatusUnavailable && status.NextAvailableAt == nil) {
+		return time.Time{}
+	}
+	expiresAt := now.Add(s.ttl)
+	if status.NextAvailableAt != nil && status.NextAvailableAt.After(expiresAt) {
+		expiresAt = *status.NextAvailableAt
+	}
+	return expiresAt
+}
+
+// expired 判断记录是否已过期
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// cloneHealth 复制健康状态记录
+func cloneHealth(status *Health) *Health {
+	if status == nil {
+		return nil
+	}
+	clone := *status
+	return &clone
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorage_CopiesRecords(t *testing.T) {
+	storage := NewMemoryStorage(0)
+
+	status := &Health{ResourceType: ResourceTypeAPIKey, ResourceID: 1, Status: HealthStatusWarning, ErrorCount: 1}
+	if err := storage.Set(status); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	status.ErrorCount = 99
+
+	got, err := storage.Get(ResourceTypeAPIKey, 1)
+	if err != nil || got == nil || got.ErrorCount != 1 {
+		t.Fatalf("写入后修改原对象不应影响存储，actual=%+v err=%v", got, err)
+	}
+	got.ErrorCount = 42
+	if again, _ := storage.Get(ResourceTypeAPIKey, 1); again.ErrorCount != 1 {
+		t.Fatalf("修改读取结果不应影响存储，actual=%d", again.ErrorCount)
+	}
+
+	if err := storage.Delete(ResourceTypeAPIKey, 1); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if got, _ := storage.Get(ResourceTypeAPIKey, 1); got != nil {
+		t.Fatalf("删除后应返回 nil，actual=%+v", got)
+	}
+}
+
+func TestMemoryStorage_TTLEviction(t *testing.T) {
+	storage := NewMemoryStorage(20 * time.Millisecond)
+
+	backoffUntil := time.Now().Add(time.Minute)
+	_ = storage.Set(&Health{ResourceType: ResourceTypeModel, ResourceID: 1, Status: HealthStatusAvailable})
+	_ = storage.Set(&Health{ResourceType: ResourceTypeModel, ResourceID: 2, Status: HealthStatusWarning, NextAvailableAt: &backoffUntil})
+	_ = storage.Set(&Health{ResourceType: ResourceTypeModel, ResourceID: 3, Status: HealthStatusUnavailable})
+	_ = storage.Set(&Health{ResourceType: ResourceTypeModel, ResourceID: 4, Status: HealthStatusAvailable})
+
+	time.Sleep(30 * time.Millisecond)
+
+	if got, _ := storage.Get(ResourceTypeModel, 1); got != nil {
+		t.Fatalf("过期记录应视为不存在，actual=%+v", got)
+	}
+	if got, _ := storage.Get(ResourceTypeModel, 2); got == nil {
+		t.Fatal("退避未结束的记录不应过期")
+	}
+	if got, _ := storage.Get(ResourceTypeModel, 3); got == nil {
+		t.Fatal("手动禁用的记录不应过期")
+	}
+	if evicted := storage.EvictExpired(); evicted != 1 {
+		t.Fatalf("应淘汰 1 条未读取的过期记录，actual=%d", evicted)
+	}
+	records, _ := storage.List()
+	if len(records) != 2 || records[0].ResourceID != 2 || records[1].ResourceID != 3 {
+		t.Fatalf("List 应按 ID 返回未过期记录，actual=%+v", records)
+	}
+}
+
+func TestMemoryStorage_ConcurrentAccess(t *testing.T) {
+	storage := NewMemoryStorage(time.Minute)
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id uint) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = svc.UpdateStatus(ResourceTypeAPIKey, id%3, j%2 == 0, ErrorSnapshot{Impact: HealthImpactFull})
+				svc.IsHealthy(ResourceTypeAPIKey, id%3, time.Time{})
+				_, _ = storage.List()
+			}
+		}(uint(i))
+	}
+	wg.Wait()
+
+	records, _ := storage.List()
+	if len(records) != 3 {
+		t.Fatalf("并发写入后应有 3 条记录，actual=%d", len(records))
+	}
+}
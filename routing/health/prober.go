@@ -0,0 +1,250 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+const (
+	// DefaultProbeInterval 默认探测周期
+	DefaultProbeInterval = 30 * time.Second
+
+	// DefaultProbeConcurrency 默认探测并发数
+	DefaultProbeConcurrency = 4
+
+	// DefaultProbeTimeout 默认单次探测超时
+	DefaultProbeTimeout = 10 * time.Second
+)
+
+// ProbeFunc 对资源发送一次探测请求
+//
+// 返回值 probed 表示是否找到可用于探测的通道，为 false 时本轮跳过该资源且不更新健康状态；
+// probed 为 true 时 err 为 nil 表示探测成功。
+type ProbeFunc func(ctx context.Context, resourceType ResourceType, resourceID uint) (probed bool, err error)
+
+// ProberConfig 主动探测配置
+type ProberConfig struct {
+	Interval    time.Duration // 探测周期（<= 0 时使用 DefaultProbeInterval）
+	Concurrency int           // 同时进行的探测数（<= 0 时使用 DefaultProbeConcurrency）
+	Timeout     time.Duration // 单次探测超时（<= 0 时使用 DefaultProbeTimeout）
+
+	// Snapshot 将探测错误转换为健康错误摘要（可选，默认仅记录错误码与错误文本）
+	Snapshot func(err error) ErrorSnapshot
+}
+
+// Prober 后台主动探测器
+//
+// 周期性地对处于警告或不可用状态、且退避时间已过的资源发送探测请求，并通过 UpdateStatus 更新健康状态：
+// 探测成功时资源恢复可用，失败时按完全降级处理并继续退避。手动禁用（DisableHealth）的资源没有恢复时间，不会被探测。
+//
+// 只有本实例观察到降级的资源会被探测。
+type Prober struct {
+	service     *Service
+	probe       ProbeFunc
+	snapshot    func(err error) ErrorSnapshot
+	interval    time.Duration
+	concurrency int
+	timeout     time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewProber 创建主动探测器，调用 Start 后开始周期性探测
+func NewProber(service *Service, probe ProbeFunc, cfg ProberConfig) (*Prober, error) {
+	if service == nil {
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "健康服务不能为空")
+	}
+	if probe == nil {
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "探测函数不能为空")
+	}
+
+	p := &Prober{
+		service:     service,
+		probe:       probe,
+		snapshot:    cfg.Snapshot,
+		interval:    cfg.Interval,
+		concurrency: cfg.Concurrency,
+		timeout:     cfg.Timeout,
+	}
+	if p.snapshot == nil {
+		p.snapshot = defaultProbeSnapshot
+	}
+	if p.interval <= 0 {
+		p.interval = DefaultProbeInterval
+	}
+	if p.concurrency <= 0 {
+		p.concurrency = DefaultProbeConcurrency
+	}
+	if p.timeout <= 0 {
+		p.timeout = DefaultProbeTimeout
+	}
+	return p, nil
+}
+
+// Start 启动后台探测，重复调用无效果
+func (p *Prober) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.run(ctx, p.done)
+}
+
+// Stop 停止后台探测并等待进行中的探测结束
+func (p *Prober) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done = nil, nil
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// run 按探测周期循环执行探测
+func (p *Prober) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.ProbeOnce(ctx)
+		}
+	}
+}
+
+// ProbeOnce 立即对所有到期的资源执行一轮探测，返回实际发送的探测数
+func (p *Prober) ProbeOnce(ctx context.Context) int {
+	candidates := p.service.probeCandidates(time.Now())
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		probed int
+	)
+	sem := make(chan struct{}, p.concurrency)
+	for _, key := range candidates {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return probed
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(key resourceKey) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if p.probeResource(ctx, key) {
+				mu.Lock()
+				probed++
+				mu.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+	return probed
+}
+
+// probeResource 探测单个资源并更新健康状态
+func (p *Prober) probeResource(ctx context.Context, key resourceKey) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	probed, err := p.probe(probeCtx, key.resourceType, key.resourceID)
+	if !probed {
+		return false
+	}
+	// 探测器停止导致的取消不代表资源状态
+	if ctx.Err() != nil {
+		return true
+	}
+
+	if err == nil {
+		_ = p.service.UpdateStatus(key.resourceType, key.resourceID, true, ErrorSnapshot{})
+		return true
+	}
+	snapshot := p.snapshot(err)
+	snapshot.Impact = HealthImpactFull
+	_ = p.service.UpdateStatus(key.resourceType, key.resourceID, false, snapshot)
+	return true
+}
+
+// probeCandidates 返回处于警告或不可用状态、且退避时间已过的资源（按资源类型与 ID 排序）
+//
+// 存储中已恢复（或已不存在）的资源会被移出跟踪集合。
+func (m *Service) probeCandidates(now time.Time) []resourceKey {
+	m.degradedMu.Lock()
+	keys := make([]resourceKey, 0, len(m.degraded))
+	for key := range m.degraded {
+		keys = append(keys, key)
+	}
+	m.degradedMu.Unlock()
+
+	var candidates []resourceKey
+	for _, key := range keys {
+		status, err := m.storage.Get(key.resourceType, key.resourceID)
+		if err != nil {
+			continue
+		}
+		if status == nil || (status.Status != HealthStatusWarning && status.Status != HealthStatusUnavailable) {
+			m.degradedMu.Lock()
+			delete(m.degraded, key)
+			m.degradedMu.Unlock()
+			continue
+		}
+		if status.NextAvailableAt == nil || now.Before(*status.NextAvailableAt) {
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].resourceType != candidates[j].resourceType {
+			return candidates[i].resourceType < candidates[j].resourceType
+		}
+		return candidates[i].resourceID < candidates[j].resourceID
+	})
+	return candidates
+}
+
+// defaultProbeSnapshot 将探测错误转换为健康错误摘要
+func defaultProbeSnapshot(err error) ErrorSnapshot {
+	var httpStatus *int
+	if errors.HasHTTPStatus(err) {
+		status := errors.GetHTTPStatus(err)
+		httpStatus = &status
+	}
+	message := errors.GetMessage(err)
+	if message == "" {
+		message = err.Error()
+	}
+	return ErrorSnapshot{
+		Message:    message,
+		Code:       string(errors.GetCode(err)),
+		HTTPStatus: httpStatus,
+		ErrorFrom:  string(errors.GetErrorFrom(err)),
+	}
+}
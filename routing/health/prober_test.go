@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func TestProber_ProbesExpiredBackoff(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+
+	snapshot := ErrorSnapshot{Message: "请求失败", Impact: HealthImpactFull}
+	for _, id := range []uint{1, 2, 3} {
+		if err := svc.UpdateStatus(ResourceTypePlatform, id, false, snapshot); err != nil {
+			t.Fatalf("UpdateStatus 失败: %v", err)
+		}
+	}
+	// 平台 1、2 退避已结束，平台 3 仍在退避中
+	past := time.Now().Add(-time.Second)
+	storage.data[testHealthStorageKey{resourceType: ResourceTypePlatform, resourceID: 1}].NextAvailableAt = &past
+	storage.data[testHealthStorageKey{resourceType: ResourceTypePlatform, resourceID: 2}].NextAvailableAt = &past
+
+	var probedIDs []uint
+	prober, err := NewProber(svc, func(_ context.Context, resourceType ResourceType, resourceID uint) (bool, error) {
+		probedIDs = append(probedIDs, resourceID)
+		if resourceID == 2 {
+			return true, errors.New(errors.ErrCodeUnavailable, "仍不可用")
+		}
+		return true, nil
+	}, ProberConfig{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("创建探测器失败: %v", err)
+	}
+
+	if n := prober.ProbeOnce(context.Background()); n != 2 {
+		t.Fatalf("应探测 2 个资源，actual=%d (%v)", n, probedIDs)
+	}
+	if status, _ := svc.GetStatus(ResourceTypePlatform, 1); status.Status != HealthStatusAvailable {
+		t.Fatalf("探测成功后资源应恢复可用，actual=%v", status.Status)
+	}
+	status, _ := svc.GetStatus(ResourceTypePlatform, 2)
+	if status.NextAvailableAt == nil || !status.NextAvailableAt.After(time.Now()) || status.LastErrorMessage != "仍不可用" {
+		t.Fatalf("探测失败后资源应继续退避，actual=%+v", status)
+	}
+
+	if n := prober.ProbeOnce(context.Background()); n != 0 {
+		t.Fatalf("已恢复或仍在退避中的资源不应被探测，actual=%d", n)
+	}
+}
+
+func TestProber_SkipsDisabledAndUntargeted(t *testing.T) {
+	storage := newTestHealthStorage()
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+	if err := svc.DisableHealth(ResourceTypeAPIKey, 1, "手动禁用"); err != nil {
+		t.Fatalf("DisableHealth 失败: %v", err)
+	}
+	if err := svc.UpdateStatus(ResourceTypeAPIKey, 2, false, ErrorSnapshot{Impact: HealthImpactFull}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	past := time.Now().Add(-time.Second)
+	storage.data[testHealthStorageKey{resourceType: ResourceTypeAPIKey, resourceID: 2}].NextAvailableAt = &past
+
+	calls := 0
+	prober, _ := NewProber(svc, func(context.Context, ResourceType, uint) (bool, error) {
+		calls++
+		return false, nil
+	}, ProberConfig{})
+
+	if n := prober.ProbeOnce(context.Background()); n != 0 || calls != 1 {
+		t.Fatalf("手动禁用的资源不应被探测，没有探测目标的资源应跳过，probed=%d calls=%d", n, calls)
+	}
+	if status, _ := svc.GetStatus(ResourceTypeAPIKey, 2); status.ErrorCount != 1 {
+		t.Fatalf("跳过探测时不应更新健康状态，actual=%+v", status)
+	}
+
+	prober.Start()
+	prober.Stop()
+}
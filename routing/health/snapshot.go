@@ -0,0 +1,199 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+const (
+	// DefaultSnapshotInterval 默认快照周期
+	DefaultSnapshotInterval = 30 * time.Second
+
+	// snapshotVersion 快照文件格式版本
+	snapshotVersion = 1
+)
+
+// SnapshotConfig 快照持久化配置
+type SnapshotConfig struct {
+	Path     string        // 快照文件路径
+	Interval time.Duration // 快照周期（<= 0 时使用 DefaultSnapshotInterval）
+}
+
+// snapshotFile 快照文件内容
+type snapshotFile struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	Records []*Health `json:"records"`
+}
+
+// SnapshotStorage 将健康状态周期性持久化到 JSON 文件的存储包装
+//
+// 读写直接委托给底层存储；创建时从快照文件恢复全部记录，使退避状态在重启后得以保留。
+// 调用 Start 后按快照周期写入快照，Stop 停止周期写入并写入最后一次快照。
+// 快照先写入同目录的临时文件再重命名，写入中断不会损坏已有快照。
+type SnapshotStorage struct {
+	ListableStorage
+
+	path     string
+	interval time.Duration
+
+	saveMu sync.Mutex // 串行化快照写入
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSnapshotStorage 创建快照持久化存储，并从快照文件恢复记录
+//
+// 快照文件不存在时视为首次启动；文件无法解析时返回错误，避免覆盖用户的已有快照。
+func NewSnapshotStorage(storage ListableStorage, cfg SnapshotConfig) (*SnapshotStorage, error) {
+	if storage == nil {
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "健康状态存储不能为空")
+	}
+	if cfg.Path == "" {
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "快照文件路径不能为空")
+	}
+
+	s := &SnapshotStorage{
+		ListableStorage: storage,
+		path:            cfg.Path,
+		interval:        cfg.Interval,
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultSnapshotInterval
+	}
+	if _, err := s.Restore(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Restore 从快照文件恢复记录到底层存储，返回恢复的记录数
+//
+// 快照文件不存在时返回 0。
+func (s *SnapshotStorage) Restore() (int, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCodeInternal, "读取健康状态快照失败", err).
+			WithContext("path", s.path)
+	}
+
+	var snapshot snapshotFile
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return 0, errors.Wrap(errors.ErrCodeDataLoss, "解析健康状态快照失败", err).
+			WithContext("path", s.path)
+	}
+	if snapshot.Version != snapshotVersion {
+		return 0, errors.New(errors.ErrCodeDataLoss, "不支持的健康状态快照版本").
+			WithContext("path", s.path).
+			WithContext("version", snapshot.Version)
+	}
+
+	restored := 0
+	for _, record := range snapshot.Records {
+		if record == nil {
+			continue
+		}
+		if err := s.ListableStorage.Set(record); err != nil {
+			return restored, errors.Wrap(errors.ErrCodeInternal, "恢复健康状态失败", err).
+				WithContext("resource_type", record.ResourceType).
+				WithContext("resource_id", record.ResourceID)
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// Save 立即将底层存储的全部记录写入快照文件
+func (s *SnapshotStorage) Save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	records, err := s.List()
+	if err != nil {
+		return errors.Wrap(errors.ErrCodeInternal, "读取健康状态失败", err)
+	}
+	data, err := json.Marshal(snapshotFile{
+		Version: snapshotVersion,
+		SavedAt: time.Now(),
+		Records: records,
+	})
+	if err != nil {
+		return errors.Wrap(errors.ErrCodeInternal, "序列化健康状态快照失败", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(errors.ErrCodeInternal, "创建健康状态快照临时文件失败", err).
+			WithContext("path", s.path)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tmp.Name(), s.path)
+	}
+	if writeErr != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(errors.ErrCodeInternal, "写入健康状态快照失败", writeErr).
+			WithContext("path", s.path)
+	}
+	return nil
+}
+
+// Start 启动周期性快照，重复调用无效果
+func (s *SnapshotStorage) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.run(ctx, s.done)
+}
+
+// Stop 停止周期性快照并写入最后一次快照
+func (s *SnapshotStorage) Stop() error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return s.Save()
+}
+
+// run 按快照周期循环写入快照
+func (s *SnapshotStorage) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 写入失败时保留上一次的快照，下个周期重试
+			_ = s.Save()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func TestSnapshotStorage_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+
+	storage, err := NewSnapshotStorage(NewMemoryStorage(0), SnapshotConfig{Path: path})
+	if err != nil {
+		t.Fatalf("创建快照存储失败: %v", err)
+	}
+	svc, err := New(Config{Storage: storage})
+	if err != nil {
+		t.Fatalf("创建健康服务失败: %v", err)
+	}
+	if err := svc.UpdateStatus(ResourceTypePlatform, 7, false, ErrorSnapshot{Message: "上游错误", Impact: HealthImpactFull}); err != nil {
+		t.Fatalf("UpdateStatus 失败: %v", err)
+	}
+	storage.Start()
+	if err := storage.Stop(); err != nil {
+		t.Fatalf("停止时写入快照失败: %v", err)
+	}
+
+	// 模拟重启：新的内存存储从快照恢复
+	restored, err := NewSnapshotStorage(NewMemoryStorage(0), SnapshotConfig{Path: path})
+	if err != nil {
+		t.Fatalf("恢复快照失败: %v", err)
+	}
+	status, _ := restored.Get(ResourceTypePlatform, 7)
+	if status == nil || status.NextAvailableAt == nil || !status.NextAvailableAt.After(time.Now()) {
+		t.Fatalf("重启后应保留退避状态，actual=%+v", status)
+	}
+	if status.LastErrorMessage != "上游错误" || status.ErrorCount != 1 {
+		t.Fatalf("重启后应保留错误信息，actual=%+v", status)
+	}
+
+	svc, _ = New(Config{Storage: restored})
+	if svc.IsHealthy(ResourceTypePlatform, 7, time.Time{}) {
+		t.Fatal("重启后退避中的资源应保持不健康")
+	}
+}
+
+func TestSnapshotStorage_PeriodicSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	storage, err := NewSnapshotStorage(NewMemoryStorage(0), SnapshotConfig{Path: path, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("创建快照存储失败: %v", err)
+	}
+	_ = storage.Set(&Health{ResourceType: ResourceTypeAPIKey, ResourceID: 1, Status: HealthStatusAvailable})
+
+	storage.Start()
+	defer storage.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("启动后应周期性写入快照")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewSnapshotStorage_InvalidSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	if _, err := NewSnapshotStorage(NewMemoryStorage(0), SnapshotConfig{Path: path}); !errors.IsCode(err, errors.ErrCodeDataLoss) {
+		t.Fatalf("无法解析的快照应返回 DataLoss，actual=%v", err)
+	}
+	if _, err := NewSnapshotStorage(NewMemoryStorage(0), SnapshotConfig{}); !errors.IsCode(err, errors.ErrCodeInvalidArgument) {
+		t.Fatalf("缺少快照路径应返回 InvalidArgument，actual=%v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"sync"
+
+	"github.com/MeowSalty/portal/routing/health"
+)
+
+// InFlightStats 表示各资源在途请求数的快照
+type InFlightStats struct {
+	Platforms map[uint]int64 // 平台 ID -> 在途请求数
+	Models    map[uint]int64 // 模型 ID -> 在途请求数
+	APIKeys   map[uint]int64 // 密钥 ID -> 在途请求数
+}
+
+// inflightTracker 维护平台/模型/密钥三个维度的在途请求计数
+//
+// 通道被路由交出时计数加一，请求（含流式请求）结束并释放通道时计数减一。
+type inflightTracker struct {
+	mu        sync.Mutex
+	platforms map[uint]int64
+	models    map[uint]int64
+	keys      map[uint]int64
+}
+
+// newInflightTracker 创建在途请求计数器
+func newInflightTracker() *inflightTracker {
+	return &inflightTracker{
+		platforms: make(map[uint]int64),
+		models:    make(map[uint]int64),
+		keys:      make(map[uint]int64),
+	}
+}
+
+// acquire 为通道对应的平台/模型/密钥增加在途计数
+func (t *inflightTracker) acquire(platformID, modelID, apiKeyID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.platforms[platformID]++
+	t.models[modelID]++
+	t.keys[apiKeyID]++
+}
+
+// release 为通道对应的平台/模型/密钥减少在途计数，计数归零时移除条目
+func (t *inflightTracker) release(platformID, modelID, apiKeyID uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	decrementCounter(t.platforms, platformID)
+	decrementCounter(t.models, modelID)
+	decrementCounter(t.keys, apiKeyID)
+}
+
+// channelCounts 返回通道对应的平台/模型/密钥在途计数
+func (t *inflightTracker) channelCounts(platformID, modelID, apiKeyID uint) (int64, int64, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.platforms[platformID], t.models[modelID], t.keys[apiKeyID]
+}
+
+// count 返回单个资源的在途计数
+func (t *inflightTracker) count(resourceType health.ResourceType, resourceID uint) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch resourceType {
+	case health.ResourceTypePlatform:
+		return t.platforms[resourceID]
+	case health.ResourceTypeModel:
+		return t.models[resourceID]
+	case health.ResourceTypeAPIKey:
+		return t.keys[resourceID]
+	default:
+		return 0
+	}
+}
+
+// snapshot 返回所有资源在途计数的副本
+func (t *inflightTracker) snapshot() InFlightStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return InFlightStats{
+		Platforms: copyCounters(t.platforms),
+		Models:    copyCounters(t.models),
+		APIKeys:   copyCounters(t.keys),
+	}
+}
+
+// decrementCounter 计数减一，归零时删除条目以避免 map 无限增长
+func decrementCounter(counters map[uint]int64, id uint) {
+	if counters[id] <= 1 {
+		delete(counters, id)
+		return
+	}
+	counters[id]--
+}
+
+// copyCounters 复制计数 map
+func copyCounters(counters map[uint]int64) map[uint]int64 {
+	result := make(map[uint]int64, len(counters))
+	for id, n := range counters {
+		result[id] = n
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestGetChannel_TracksInFlightUntilRelease(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	r, storage := newTestRouting(t, selector.NewLeastInFlightSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 101)
+
+	first, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	second, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if first.APIKeyID == second.APIKeyID {
+		t.Fatalf("并发请求应分散到在途数更少的密钥，actual=%d", second.APIKeyID)
+	}
+
+	if got := r.InFlight(health.ResourceTypePlatform, 1); got != 2 {
+		t.Fatalf("平台在途数期望 2，actual=%d", got)
+	}
+	if got := r.InFlight(health.ResourceTypeAPIKey, first.APIKeyID); got != 1 {
+		t.Fatalf("密钥在途数期望 1，actual=%d", got)
+	}
+
+	first.Release()
+	first.Release()
+	stats := r.InFlightStats()
+	if stats.Platforms[1] != 1 || stats.Models[10] != 1 {
+		t.Fatalf("重复释放应只扣减一次，actual=%+v", stats)
+	}
+	if _, ok := stats.APIKeys[first.APIKeyID]; ok {
+		t.Fatalf("已归零的密钥计数应被移除，actual=%+v", stats.APIKeys)
+	}
+
+	second.Release()
+	if got := r.InFlight(health.ResourceTypePlatform, 1); got != 0 {
+		t.Fatalf("全部释放后平台在途数期望 0，actual=%d", got)
+	}
+}
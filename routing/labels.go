@@ -0,0 +1,75 @@
+package routing
+
+import (
+	"net/http"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// WithLabelConstraints 设置本次选择的标签约束
+//
+// 通道的标签（平台标签与端点标签合并，端点覆盖同名标签）须包含全部键值对才会被选中，
+// 用于数据驻留与合规路由（如 region=eu、zdr=true）。多次调用时合并约束，同名键以后者为准。
+func WithLabelConstraints(constraints map[string]string) SelectOption {
+	return func(o *selectOptions) {
+		if len(constraints) == 0 {
+			return
+		}
+		merged := make(map[string]string, len(o.labels)+len(constraints))
+		for k, v := range o.labels {
+			merged[k] = v
+		}
+		for k, v := range constraints {
+			merged[k] = v
+		}
+		o.labels = merged
+	}
+}
+
+// matchesLabels 判断通道标签是否满足全部约束
+func (c *Channel) matchesLabels(constraints map[string]string) bool {
+	for k, v := range constraints {
+		if label, ok := c.Labels[k]; !ok || label != v {
+			return false
+		}
+	}
+	return true
+}
+
+// unmetLabelConstraints 返回没有任何候选通道满足的约束
+//
+// 每个约束单独都有通道满足、但没有通道同时满足全部约束时，返回全部约束。
+func (r *Routing) unmetLabelConstraints(modelsWithEndpoint []ModelWithEndpoint, constraints map[string]string) map[string]string {
+	unmet := make(map[string]string, len(constraints))
+	for k, v := range constraints {
+		unmet[k] = v
+	}
+	for _, mwe := range modelsWithEndpoint {
+		if len(mwe.Model.APIKeys) == 0 {
+			continue
+		}
+		for k, v := range mergeLabels(mwe.Platform.Labels, mwe.Endpoint.Labels) {
+			if unmet[k] == v {
+				delete(unmet, k)
+			}
+		}
+	}
+	if len(unmet) == 0 {
+		return constraints
+	}
+	return unmet
+}
+
+// mergeLabels 合并平台与端点标签，端点标签覆盖同名平台标签
+func mergeLabels(platform, endpoint map[string]string) map[string]string {
+	return mergeCustomHeaders(platform, endpoint)
+}
+
+// newLabelConstraintError 创建没有通道满足标签约束的错误
+func (r *Routing) newLabelConstraintError(modelsWithEndpoint []ModelWithEndpoint, constraints map[string]string) error {
+	return errors.New(errors.ErrCodeNoHealthyChannel, "没有满足标签约束的通道").
+		WithHTTPStatus(http.StatusServiceUnavailable).
+		WithContext("error_from", string(errors.ErrorFromGateway)).
+		WithContext("label_constraints", constraints).
+		WithContext("unmet_constraints", r.unmetLabelConstraints(modelsWithEndpoint, constraints))
+}
@@ -0,0 +1,65 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func labelTestModels() []ModelWithEndpoint {
+	return []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1, Labels: map[string]string{"region": "us"}},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+		{
+			Platform: Platform{ID: 2, Labels: map[string]string{"region": "eu"}, Priority: 1},
+			Model:    Model{ID: 20, Name: "gpt-4o", APIKeys: []APIKey{{ID: 200}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions", Labels: map[string]string{"zdr": "true"}},
+		},
+	}
+}
+
+func TestLabelConstraints_OnlyMatchingChannelsSelected(t *testing.T) {
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), labelTestModels())
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o", WithLabelConstraints(map[string]string{"region": "eu", "zdr": "true"}))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	defer ch.Release()
+	if ch.PlatformID != 2 {
+		t.Fatalf("应只选择满足标签约束的通道（即使优先级更低），actual=%d", ch.PlatformID)
+	}
+	if ch.Labels["region"] != "eu" || ch.Labels["zdr"] != "true" {
+		t.Fatalf("通道应合并平台与端点标签，actual=%v", ch.Labels)
+	}
+
+	explanation, err := r.Explain(context.Background(), "gpt-4o", "", "", WithLabelConstraints(map[string]string{"region": "eu"}))
+	if err != nil {
+		t.Fatalf("解释通道选择失败: %v", err)
+	}
+	if explanation.Candidates[0].Excluded != ExclusionLabelMismatch {
+		t.Fatalf("不满足标签约束的通道应标记排除原因，actual=%q", explanation.Candidates[0].Excluded)
+	}
+}
+
+func TestLabelConstraints_NoMatchReturnsUnmetConstraints(t *testing.T) {
+	r, _ := newTestRouting(t, selector.NewLRUSelector(), labelTestModels())
+
+	_, err := r.GetChannel(context.Background(), "gpt-4o", WithLabelConstraints(map[string]string{"region": "eu", "tier": "gold"}))
+	if !errors.IsCode(err, errors.ErrCodeNoHealthyChannel) {
+		t.Fatalf("没有通道满足标签约束时应返回 NoHealthyChannel，actual=%v", err)
+	}
+	unmet, ok := errors.GetContext(err)["unmet_constraints"].(map[string]string)
+	if !ok || len(unmet) != 1 || unmet["tier"] != "gold" {
+		t.Fatalf("错误上下文应包含未满足的约束，actual=%v", errors.GetContext(err))
+	}
+}
@@ -0,0 +1,192 @@
+package routing
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLatencyDecay EWMA 的衰减时间常数
+	defaultLatencyDecay = 10 * time.Second
+	// defaultLatencyFailurePenalty 失败时计入的惩罚耗时
+	defaultLatencyFailurePenalty = 10 * time.Second
+	// latencyWindowSize 每个模型保留的最近成功样本数（用于分位数估计）
+	latencyWindowSize = 128
+	// latencyWindowMinSamples 计算分位数所需的最少样本数
+	latencyWindowMinSamples = 10
+)
+
+// latencyTracker 维护每个通道的峰值敏感（Peak）EWMA 延迟统计
+//
+// 统计数据来源于请求日志中已测量的总耗时与首字耗时，
+// 并在选路时写入 selector.ChannelInfo 供延迟感知的选择器使用。
+type latencyTracker struct {
+	mu             sync.Mutex
+	decay          time.Duration
+	failurePenalty time.Duration
+	entries        map[string]*latencyEntry  // 通道 ID -> 延迟统计
+	windows        map[string]*latencyWindow // 模型名称 -> 最近成功样本
+}
+
+// latencyWindow 单个模型最近成功请求的耗时样本（环形缓冲）
+type latencyWindow struct {
+	duration  sampleRing
+	firstByte sampleRing
+}
+
+// sampleRing 固定容量的环形样本缓冲
+type sampleRing struct {
+	samples []time.Duration
+	next    int
+}
+
+// latencyEntry 单个通道的延迟统计
+type latencyEntry struct {
+	duration  peakEWMA // 总耗时
+	firstByte peakEWMA // 首字耗时
+}
+
+// peakEWMA 峰值敏感的指数加权移动平均
+//
+// 新样本高于当前值时立即跳升到样本值，低于当前值时按时间衰减逐步回落，
+// 从而对延迟恶化快速响应、对延迟改善保守响应。
+type peakEWMA struct {
+	value     float64 // 纳秒
+	updatedAt time.Time
+}
+
+// newLatencyTracker 创建延迟统计器
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		decay:          defaultLatencyDecay,
+		failurePenalty: defaultLatencyFailurePenalty,
+		entries:        make(map[string]*latencyEntry),
+		windows:        make(map[string]*latencyWindow),
+	}
+}
+
+// add 写入一个样本，容量满时覆盖最旧的样本
+func (r *sampleRing) add(sample time.Duration) {
+	if len(r.samples) < latencyWindowSize {
+		r.samples = append(r.samples, sample)
+		return
+	}
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % latencyWindowSize
+}
+
+// percentile 返回样本的 q 分位数，样本不足时返回 false
+func (r *sampleRing) percentile(q float64) (time.Duration, bool) {
+	if len(r.samples) < latencyWindowMinSamples {
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), r.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx], true
+}
+
+// observe 更新 EWMA 值
+func (e *peakEWMA) observe(sample float64, now time.Time, decay time.Duration) {
+	if e.updatedAt.IsZero() || sample > e.value {
+		e.value = sample
+		e.updatedAt = now
+		return
+	}
+
+	elapsed := now.Sub(e.updatedAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	w := math.Exp(-float64(elapsed) / float64(decay))
+	e.value = e.value*w + sample*(1-w)
+	e.updatedAt = now
+}
+
+// observeSuccess 记录一次成功请求的耗时样本
+//
+// firstByte 为 nil 表示本次请求未测量首字耗时（如非流式请求）。
+// 样本同时写入模型维度的窗口，用于估计分位数耗时。
+func (t *latencyTracker) observeSuccess(channelID, modelName string, duration time.Duration, firstByte *time.Duration) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.getOrCreate(channelID)
+	entry.duration.observe(float64(duration), now, t.decay)
+	if firstByte != nil {
+		entry.firstByte.observe(float64(*firstByte), now, t.decay)
+	}
+
+	window, ok := t.windows[modelName]
+	if !ok {
+		window = &latencyWindow{}
+		t.windows[modelName] = window
+	}
+	if firstByte != nil {
+		// 流式请求的总耗时受输出长度影响，不计入总耗时窗口
+		window.firstByte.add(*firstByte)
+	} else {
+		window.duration.add(duration)
+	}
+}
+
+// percentile 返回模型最近成功请求耗时的 q 分位数
+//
+// firstByte 为 true 时返回首字耗时（流式请求）的分位数，否则返回非流式请求总耗时的分位数。
+// 样本不足时返回 false。
+func (t *latencyTracker) percentile(modelName string, q float64, firstByte bool) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window, ok := t.windows[modelName]
+	if !ok {
+		return 0, false
+	}
+	if firstByte {
+		return window.firstByte.percentile(q)
+	}
+	return window.duration.percentile(q)
+}
+
+// observeFailure 记录一次失败请求，以惩罚耗时作为样本
+func (t *latencyTracker) observeFailure(channelID string) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.getOrCreate(channelID)
+	entry.duration.observe(float64(t.failurePenalty), now, t.decay)
+}
+
+// snapshot 返回通道当前的总耗时与首字耗时 EWMA，尚无样本时返回 0
+func (t *latencyTracker) snapshot(channelID string) (time.Duration, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[channelID]
+	if !ok {
+		return 0, 0
+	}
+	return time.Duration(entry.duration.value), time.Duration(entry.firstByte.value)
+}
+
+// getOrCreate 获取或创建通道延迟统计（调用方需持有锁）
+func (t *latencyTracker) getOrCreate(channelID string) *latencyEntry {
+	entry, ok := t.entries[channelID]
+	if !ok {
+		entry = &latencyEntry{}
+		t.entries[channelID] = entry
+	}
+	return entry
+}
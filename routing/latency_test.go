@@ -0,0 +1,109 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestPeakEWMA_JumpsOnPeakAndDecaysSlowly(t *testing.T) {
+	var e peakEWMA
+	base := time.Now()
+	decay := 10 * time.Second
+
+	e.observe(float64(100*time.Millisecond), base, decay)
+	e.observe(float64(2*time.Second), base.Add(time.Second), decay)
+	if time.Duration(e.value) != 2*time.Second {
+		t.Fatalf("更高样本应立即跳升，actual=%v", time.Duration(e.value))
+	}
+
+	e.observe(float64(100*time.Millisecond), base.Add(2*time.Second), decay)
+	got := time.Duration(e.value)
+	if got <= 100*time.Millisecond || got >= 2*time.Second {
+		t.Fatalf("更低样本应按时间逐步回落，actual=%v", got)
+	}
+}
+
+func TestChannelLatencyFeedback_ThreadedIntoChannelInfo(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	sel := &recordingSelector{inner: selector.NewEWMASelector()}
+	r, storage := newTestRouting(t, sel, models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 101)
+
+	slow := r.buildChannelsForModelWithEndpoint(models[0])[0]
+	firstByte := 400 * time.Millisecond
+	slow.ObserveLatency(2*time.Second, &firstByte)
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.APIKeyID != 101 {
+		t.Fatalf("应选择延迟更低的通道，actual=%d", ch.APIKeyID)
+	}
+
+	info := sel.last[0]
+	if info.LatencyEWMA != 2*time.Second || info.FirstByteEWMA != firstByte {
+		t.Fatalf("延迟统计未正确传递，actual=%+v", info)
+	}
+}
+
+func TestChannelMarkFailure_PenalizesLatencyUnlessClientCancel(t *testing.T) {
+	r, _ := newTestRouting(t, selector.NewEWMASelector(), nil)
+	ch := r.buildChannelsForModelWithEndpoint(ModelWithEndpoint{
+		Platform: Platform{ID: 1},
+		Model:    Model{ID: 10, APIKeys: []APIKey{{ID: 100}}},
+	})[0]
+
+	cancelErr := errors.New(errors.ErrCodeAborted, "请求已取消").WithContext("error_from", "client")
+	ch.MarkFailure(context.Background(), cancelErr)
+	if got, _ := r.latency.snapshot(ch.ID()); got != 0 {
+		t.Fatalf("客户端取消不应计入延迟惩罚，actual=%v", got)
+	}
+
+	ch.MarkFailure(context.Background(), errors.New(errors.ErrCodeUnavailable, "上游错误"))
+	if got, _ := r.latency.snapshot(ch.ID()); got != defaultLatencyFailurePenalty {
+		t.Fatalf("失败应计入惩罚耗时，actual=%v", got)
+	}
+}
+
+func TestChannelLatencyPercentile_PerModelWindow(t *testing.T) {
+	r, _ := newTestRouting(t, selector.NewEWMASelector(), nil)
+	ch := r.buildChannelsForModelWithEndpoint(ModelWithEndpoint{
+		Platform: Platform{ID: 1},
+		Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+	})[0]
+
+	if _, ok := ch.LatencyPercentile(0.95, false); ok {
+		t.Fatal("样本不足时不应返回分位数")
+	}
+
+	for i := 1; i <= 20; i++ {
+		ch.ObserveLatency(time.Duration(i)*100*time.Millisecond, nil)
+	}
+	firstByte := 300 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		ch.ObserveLatency(5*time.Second, &firstByte)
+	}
+
+	p95, ok := ch.LatencyPercentile(0.95, false)
+	if !ok || p95 != 1900*time.Millisecond {
+		t.Fatalf("非流式总耗时 p95 期望 1.9s，actual=%s ok=%v", p95, ok)
+	}
+	if ttft, ok := ch.LatencyPercentile(0.95, true); !ok || ttft != firstByte {
+		t.Fatalf("首字耗时 p95 期望 %s，actual=%s ok=%v", firstByte, ttft, ok)
+	}
+}
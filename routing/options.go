@@ -0,0 +1,143 @@
+package routing
+
+import "github.com/MeowSalty/portal/routing/selector"
+
+// SelectOption 定义通道选择的可选配置函数
+type SelectOption func(*selectOptions)
+
+// selectOptions 存储单次通道选择的所有可选配置
+type selectOptions struct {
+	affinityKey     string // 会话亲和键
+	estimatedTokens int    // 预估 Token 数（用于 TPM 限流）
+	excludedKeys    []uint // 本次选择需排除的密钥
+
+	labels       map[string]string // 标签约束
+	capabilities []Capability      // 所需能力
+	promptTokens int               // 预估提示词 Token 数
+	outputTokens int               // 最大输出 Token 数
+
+	excludedChannels []string // 本次选择需排除的通道 ID
+	reuseExcluded    bool     // 未排除的通道耗尽时是否允许回到已排除的通道
+
+	splitKey string           // 流量切分的请求键
+	model    string           // 请求的模型名称（由路由填充）
+	split    *splitAssignment // 分配到的流量切分分组（由路由填充）
+
+	tripped []string // 半开试探名额已满而被拒绝的通道 ID（由路由填充）
+}
+
+// applySelectOptions 应用所有选项并返回配置
+func applySelectOptions(opts []SelectOption) *selectOptions {
+	options := &selectOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(options)
+		}
+	}
+	return options
+}
+
+// WithAffinityKey 设置会话亲和键
+//
+// 支持请求感知的选择器（如一致性哈希选择器）会将相同亲和键的请求
+// 稳定地路由到同一通道，以命中供应商侧的提示词缓存。
+func WithAffinityKey(key string) SelectOption {
+	return func(o *selectOptions) {
+		o.affinityKey = key
+	}
+}
+
+// WithEstimatedTokens 设置本次请求的预估 Token 数
+//
+// 用于本地 TPM 限流的预扣，请求结束后会按实际用量校正。
+func WithEstimatedTokens(tokens int) SelectOption {
+	return func(o *selectOptions) {
+		if tokens > 0 {
+			o.estimatedTokens = tokens
+		}
+	}
+}
+
+// WithExcludedAPIKeys 排除指定密钥
+//
+// 用于重试时避开已失败的密钥（例如上游返回 429 后仅更换密钥重试）。
+func WithExcludedAPIKeys(ids ...uint) SelectOption {
+	return func(o *selectOptions) {
+		o.excludedKeys = append(o.excludedKeys, ids...)
+	}
+}
+
+// WithExcludedChannels 排除指定通道（Channel.ID()）
+//
+// 用于同一逻辑请求内的重试：已尝试过的通道不再被选中，
+// 即使其健康状态尚未写入退避或仍处于未知状态。
+func WithExcludedChannels(ids ...string) SelectOption {
+	return func(o *selectOptions) {
+		o.excludedChannels = append(o.excludedChannels, ids...)
+	}
+}
+
+// WithExcludedChannelReuse 允许在未排除的通道耗尽时回到已排除的通道
+//
+// 仅放宽 WithExcludedChannels 的约束，WithExcludedAPIKeys 排除的密钥始终不会被使用。
+func WithExcludedChannelReuse() SelectOption {
+	return func(o *selectOptions) {
+		o.reuseExcluded = true
+	}
+}
+
+// WithSplitKey 设置流量切分的请求键
+//
+// 相同请求键总是被分配到同一分组，同一逻辑请求的重试应使用相同的键以保持在同一分组。
+// 设置了会话亲和键时优先使用亲和键。
+func WithSplitKey(key string) SelectOption {
+	return func(o *selectOptions) {
+		o.splitKey = key
+	}
+}
+
+// selectorRequest 构建传给选择器的请求上下文
+func (o *selectOptions) selectorRequest() selector.Request {
+	return selector.Request{
+		AffinityKey:  o.affinityKey,
+		PromptTokens: o.promptTokens,
+		OutputTokens: o.outputTokens,
+	}
+}
+
+// isChannelExcluded 判断通道是否被排除
+func (o *selectOptions) isChannelExcluded(id string) bool {
+	for _, excluded := range o.excludedChannels {
+		if excluded == id {
+			return true
+		}
+	}
+	return false
+}
+
+// withTripped 返回追加了被拒绝通道的选项副本
+func (o *selectOptions) withTripped(ch *Channel) *selectOptions {
+	next := *o
+	next.tripped = append(append([]string(nil), o.tripped...), ch.ID())
+	return &next
+}
+
+// isTripped 判断通道是否因半开试探名额已满而被拒绝
+func (o *selectOptions) isTripped(id string) bool {
+	for _, tripped := range o.tripped {
+		if tripped == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyExcluded 判断密钥是否被排除
+func (o *selectOptions) isKeyExcluded(id uint) bool {
+	for _, excluded := range o.excludedKeys {
+		if excluded == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package routing
+
+// tokensPerPriceUnit 价格计量单位（每百万 Token）
+const tokensPerPriceUnit = 1_000_000
+
+// Pricing 模型价格（每百万 Token，货币单位由调用方约定）
+//
+// 同一模型在不同平台（转售商）上的价格可能相差很大，价格可配置在模型或端点上，端点价格优先。
+type Pricing struct {
+	Input       float64 // 输入价格
+	Output      float64 // 输出价格
+	CachedInput float64 // 缓存命中输入价格（<= 0 表示按 Input 计算）
+	Reasoning   float64 // 推理输出价格（<= 0 表示按 Output 计算）
+}
+
+// TokenUsage 计费用的 Token 用量
+//
+// 各项互不包含：Input 不含缓存命中部分，Output 不含推理部分。
+type TokenUsage struct {
+	Input       int // 未命中缓存的输入 Token 数
+	CachedInput int // 命中缓存的输入 Token 数
+	Output      int // 非推理输出 Token 数
+	Reasoning   int // 推理输出 Token 数
+}
+
+// Cost 按用量计算费用
+func (p *Pricing) Cost(usage TokenUsage) float64 {
+	if p == nil {
+		return 0
+	}
+	cachedPrice := p.CachedInput
+	if cachedPrice <= 0 {
+		cachedPrice = p.Input
+	}
+	reasoningPrice := p.Reasoning
+	if reasoningPrice <= 0 {
+		reasoningPrice = p.Output
+	}
+	cost := float64(usage.Input)*p.Input +
+		float64(usage.CachedInput)*cachedPrice +
+		float64(usage.Output)*p.Output +
+		float64(usage.Reasoning)*reasoningPrice
+	return cost / tokensPerPriceUnit
+}
+
+// inputPrice 返回输入价格，未配置时为 0
+func (p *Pricing) inputPrice() float64 {
+	if p == nil {
+		return 0
+	}
+	return p.Input
+}
+
+// outputPrice 返回输出价格，未配置时为 0
+func (p *Pricing) outputPrice() float64 {
+	if p == nil {
+		return 0
+	}
+	return p.Output
+}
+
+// effectivePricing 返回通道生效的价格，端点价格优先于模型价格
+func effectivePricing(model, endpoint *Pricing) *Pricing {
+	if endpoint != nil {
+		return endpoint
+	}
+	return model
+}
@@ -0,0 +1,42 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestPricing_Cost(t *testing.T) {
+	pricing := &Pricing{Input: 2, Output: 8, CachedInput: 0.5}
+	cost := pricing.Cost(TokenUsage{Input: 1_000_000, CachedInput: 2_000_000, Output: 500_000, Reasoning: 500_000})
+	// 输入 2 + 缓存 1 + 输出 4 + 推理（按输出价）4
+	if math.Abs(cost-11) > 1e-9 {
+		t.Fatalf("费用计算错误，actual=%v", cost)
+	}
+	if (*Pricing)(nil).Cost(TokenUsage{Input: 100}) != 0 {
+		t.Fatal("未配置价格时费用应为 0")
+	}
+}
+
+func TestPricing_EndpointOverridesModel(t *testing.T) {
+	endpointPricing := &Pricing{Input: 1, Output: 2}
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}, Pricing: &Pricing{Input: 5, Output: 15}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions", Pricing: endpointPricing},
+		},
+	}
+	r, _ := newTestRouting(t, selector.NewCostSelector(), models)
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if ch.Pricing != endpointPricing {
+		t.Fatalf("端点价格应覆盖模型价格，actual=%+v", ch.Pricing)
+	}
+}
@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MeowSalty/portal/routing/health"
+)
+
+// ChannelProbeFunc 通过通道发送一次探测请求，返回 nil 表示通道可用
+//
+// 探测请求不应写入用户请求日志，也不应更新健康状态（由探测器统一更新）。
+type ChannelProbeFunc func(ctx context.Context, ch *Channel) error
+
+// probeTargets 记录各资源最近一次失败时所在的通道，作为主动探测的目标
+//
+// 健康状态只记录资源 ID，探测请求需要完整的平台、模型与密钥信息，因此在通道失败时登记。
+type probeTargets struct {
+	mu      sync.RWMutex
+	targets map[probeKey]*Channel
+}
+
+// probeKey 探测目标键
+type probeKey struct {
+	resourceType health.ResourceType
+	resourceID   uint
+}
+
+// newProbeTargets 创建探测目标登记表
+func newProbeTargets() *probeTargets {
+	return &probeTargets{targets: make(map[probeKey]*Channel)}
+}
+
+// record 登记资源的探测目标
+func (t *probeTargets) record(resourceType health.ResourceType, resourceID uint, ch *Channel) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.targets[probeKey{resourceType: resourceType, resourceID: resourceID}] = ch.probeCopy()
+	t.mu.Unlock()
+}
+
+// get 获取资源的探测目标
+func (t *probeTargets) get(resourceType health.ResourceType, resourceID uint) (*Channel, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ch, ok := t.targets[probeKey{resourceType: resourceType, resourceID: resourceID}]
+	return ch, ok
+}
+
+// invalidate 移除与失效范围相关的探测目标，避免使用已修改的配置探测
+func (t *probeTargets) invalidate(inv Invalidation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, ch := range t.targets {
+		if inv.isZero() ||
+			(inv.Model != "" && ch.ModelName == inv.Model) ||
+			(inv.ModelID != 0 && ch.ModelID == inv.ModelID) ||
+			(inv.PlatformID != 0 && ch.PlatformID == inv.PlatformID) ||
+			(inv.APIKeyID != 0 && ch.APIKeyID == inv.APIKeyID) {
+			delete(t.targets, key)
+		}
+	}
+}
+
+// probeCopy 复制通道的连接信息，不持有在途计数、限流与健康状态引用
+func (c *Channel) probeCopy() *Channel {
+	return &Channel{
+		PlatformID:        c.PlatformID,
+		ModelID:           c.ModelID,
+		APIKeyID:          c.APIKeyID,
+		Provider:          c.Provider,
+		BaseURL:           c.BaseURL,
+		ModelName:         c.ModelName,
+		APIKey:            c.APIKey,
+		APIVariant:        c.APIVariant,
+		APIEndpointConfig: c.APIEndpointConfig,
+		CustomHeaders:     c.CustomHeaders,
+		Labels:            c.Labels,
+		Pricing:           c.Pricing,
+	}
+}
+
+// NewProber 创建后台主动探测器
+//
+// 探测器周期性地对退避时间已过的警告/不可用资源，使用其最近一次失败时所在的通道调用 probe，
+// 并按结果更新健康状态。尚未在本实例失败过的资源没有探测目标，会被跳过。
+// 调用方负责调用 Start 与 Stop。
+func (r *Routing) NewProber(probe ChannelProbeFunc, cfg health.ProberConfig) (*health.Prober, error) {
+	if cfg.Snapshot == nil {
+		cfg.Snapshot = buildHealthErrorSnapshot
+	}
+	probeResource := func(ctx context.Context, resourceType health.ResourceType, resourceID uint) (bool, error) {
+		if probe == nil {
+			return false, nil
+		}
+		ch, ok := r.probes.get(resourceType, resourceID)
+		if !ok {
+			return false, nil
+		}
+		return true, probe(ctx, ch)
+	}
+	return health.NewProber(r.healthService, probeResource, cfg)
+}
@@ -0,0 +1,59 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestNewProber_ProbesFailedChannel(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1, BaseURL: "https://example.com"},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100, Value: "sk-test"}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.MarkFailure(context.Background(), errors.New(errors.ErrCodeNotFound, "模型不存在").WithHTTPStatus(404))
+	ch.Release()
+
+	status, _ := storage.Get(health.ResourceTypeModel, 10)
+	if status == nil || status.NextAvailableAt == nil {
+		t.Fatalf("失败后模型应进入退避，actual=%+v", status)
+	}
+	past := time.Now().Add(-time.Second)
+	status.NextAvailableAt = &past
+
+	var probed *Channel
+	prober, err := r.NewProber(func(_ context.Context, ch *Channel) error {
+		probed = ch
+		return nil
+	}, health.ProberConfig{})
+	if err != nil {
+		t.Fatalf("创建探测器失败: %v", err)
+	}
+	if n := prober.ProbeOnce(context.Background()); n != 1 {
+		t.Fatalf("应探测失败的模型，actual=%d", n)
+	}
+	if probed == nil || probed.APIKey != "sk-test" || probed.BaseURL != "https://example.com" {
+		t.Fatalf("应使用失败时的通道探测，actual=%+v", probed)
+	}
+	if status, _ := storage.Get(health.ResourceTypeModel, 10); status.Status != health.HealthStatusAvailable {
+		t.Fatalf("探测成功后模型应恢复可用，actual=%v", status.Status)
+	}
+
+	r.Invalidate(Invalidation{ModelID: 10})
+	if _, ok := r.probes.get(health.ResourceTypeModel, 10); ok {
+		t.Fatal("缓存失效后应移除相关探测目标")
+	}
+}
@@ -0,0 +1,194 @@
+package routing
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimitKind 限流维度
+type rateLimitKind int8
+
+const (
+	rateLimitRequests rateLimitKind = iota + 1 // 每分钟请求数（RPM）
+	rateLimitTokens                            // 每分钟 Token 数（TPM）
+)
+
+// rateLimitScope 限流归属资源
+type rateLimitScope int8
+
+const (
+	rateLimitScopePlatform rateLimitScope = iota + 1 // 平台级
+	rateLimitScopeAPIKey                             // 密钥级
+)
+
+// rateBucketKey 限流桶的唯一标识
+type rateBucketKey struct {
+	scope rateLimitScope
+	kind  rateLimitKind
+	id    uint
+}
+
+// tokenBucket 按分钟额度匀速补充的令牌桶
+//
+// 桶容量等于每分钟额度，允许短时突发；令牌可以为负数，
+// 用于记录实际用量超出预估时产生的欠额，欠额会在后续补充中逐步偿还。
+type tokenBucket struct {
+	limit  float64   // 每分钟额度
+	tokens float64   // 当前可用令牌
+	last   time.Time // 上次补充时间
+}
+
+// refill 按经过的时间补充令牌，额度变化时同步调整
+func (b *tokenBucket) refill(now time.Time, limit float64) {
+	if b.limit != limit {
+		b.limit = limit
+		if b.tokens > limit {
+			b.tokens = limit
+		}
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.limit / 60
+		if b.tokens > b.limit {
+			b.tokens = b.limit
+		}
+	}
+	b.last = now
+}
+
+// wait 返回获取 n 个令牌需要等待的时长，0 表示可立即获取
+//
+// 单次需求超过桶容量时按容量计算，避免大请求永远无法被放行。
+func (b *tokenBucket) wait(n float64) time.Duration {
+	if n > b.limit {
+		n = b.limit
+	}
+	if b.tokens >= n {
+		return 0
+	}
+	deficit := n - b.tokens
+	return time.Duration(deficit / (b.limit / 60) * float64(time.Second))
+}
+
+// rateLimiter 本地限流器
+//
+// 按平台（以及配置了限流的密钥）维护 RPM 与 TPM 令牌桶，
+// 在通道选择前跳过已饱和的通道，避免在上游触发 429。
+// TPM 在选择时按预估 Token 数扣减，请求结束后按实际用量校正。
+type rateLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	buckets map[rateBucketKey]*tokenBucket
+}
+
+// newRateLimiter 创建本地限流器
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		now:     time.Now,
+		buckets: make(map[rateBucketKey]*tokenBucket),
+	}
+}
+
+// rateLimitDemand 单个限流桶上的一次需求
+type rateLimitDemand struct {
+	key    rateBucketKey
+	limit  int
+	amount float64
+}
+
+// channelDemands 列出通道在各限流桶上的需求，未配置限流的维度会被忽略
+func channelDemands(ch *Channel, tokens int) []rateLimitDemand {
+	demands := make([]rateLimitDemand, 0, 4)
+	add := func(scope rateLimitScope, id uint, cfg RateLimitConfig) {
+		if cfg.RPM > 0 {
+			demands = append(demands, rateLimitDemand{
+				key:    rateBucketKey{scope: scope, kind: rateLimitRequests, id: id},
+				limit:  cfg.RPM,
+				amount: 1,
+			})
+		}
+		if cfg.TPM > 0 {
+			demands = append(demands, rateLimitDemand{
+				key:    rateBucketKey{scope: scope, kind: rateLimitTokens, id: id},
+				limit:  cfg.TPM,
+				amount: float64(tokens),
+			})
+		}
+	}
+	add(rateLimitScopePlatform, ch.PlatformID, ch.platformLimit)
+	add(rateLimitScopeAPIKey, ch.APIKeyID, ch.keyLimit)
+	return demands
+}
+
+// bucket 获取（必要时创建）并补充限流桶，调用方需持有锁
+func (l *rateLimiter) bucket(key rateBucketKey, limit int, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{limit: float64(limit), tokens: float64(limit), last: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.refill(now, float64(limit))
+	return b
+}
+
+// check 检查通道能否立即承接一次请求
+//
+// 返回 0 表示未饱和；否则返回预计可用前需要等待的时长。
+func (l *rateLimiter) check(ch *Channel, tokens int) time.Duration {
+	demands := channelDemands(ch, tokens)
+	if len(demands) == 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	for _, d := range demands {
+		if w := l.bucket(d.key, d.limit, now).wait(d.amount); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// reserve 为选中的通道扣减一次请求及预估 Token
+func (l *rateLimiter) reserve(ch *Channel, tokens int) {
+	demands := channelDemands(ch, tokens)
+	if len(demands) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for _, d := range demands {
+		l.bucket(d.key, d.limit, now).tokens -= d.amount
+	}
+}
+
+// adjustTokens 按实际用量与预估的差值校正 TPM 令牌桶
+//
+// delta 为正表示实际用量超出预估（追加扣减），为负表示退还多扣的额度。
+func (l *rateLimiter) adjustTokens(ch *Channel, delta int) {
+	if delta == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for _, d := range channelDemands(ch, 0) {
+		if d.key.kind != rateLimitTokens {
+			continue
+		}
+		b := l.bucket(d.key, d.limit, now)
+		b.tokens -= float64(delta)
+		if b.tokens > b.limit {
+			b.tokens = b.limit
+		}
+	}
+}
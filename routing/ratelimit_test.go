@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestRateLimiter_RPMRefill(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newRateLimiter()
+	limiter.now = clock.Now
+
+	ch := &Channel{PlatformID: 1, APIKeyID: 1, platformLimit: RateLimitConfig{RPM: 2}}
+	for i := 0; i < 2; i++ {
+		if wait := limiter.check(ch, 0); wait != 0 {
+			t.Fatalf("第 %d 次请求不应被限流，wait=%s", i+1, wait)
+		}
+		limiter.reserve(ch, 0)
+	}
+
+	wait := limiter.check(ch, 0)
+	if wait != 30*time.Second {
+		t.Fatalf("RPM=2 时额度耗尽后应等待 30s，actual=%s", wait)
+	}
+
+	clock.now = clock.now.Add(30 * time.Second)
+	if wait := limiter.check(ch, 0); wait != 0 {
+		t.Fatalf("补充后应可立即请求，wait=%s", wait)
+	}
+}
+
+func TestRateLimiter_TPMReconcile(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newRateLimiter()
+	limiter.now = clock.Now
+
+	ch := &Channel{PlatformID: 1, APIKeyID: 7, keyLimit: RateLimitConfig{TPM: 1000}, limiter: limiter}
+	limiter.reserve(ch, 100)
+	ch.reservedTokens = 100
+
+	// 实际用量超出预估，追加扣减后剩余 100
+	ch.ReportUsage(900)
+	if wait := limiter.check(ch, 200); wait != 6*time.Second {
+		t.Fatalf("剩余 100 时申请 200 应等待 6s，actual=%s", wait)
+	}
+
+	// 重复上报不生效
+	ch.ReportUsage(0)
+	if wait := limiter.check(ch, 200); wait == 0 {
+		t.Fatal("重复上报不应退还额度")
+	}
+
+	// 超出容量的单次需求按容量计算，桶满后可放行
+	clock.now = clock.now.Add(time.Minute)
+	if wait := limiter.check(ch, 5000); wait != 0 {
+		t.Fatalf("桶满时超大请求应被放行，wait=%s", wait)
+	}
+}
+
+func TestGetChannel_SkipsRateLimitedChannels(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1, RateLimit: RateLimitConfig{RPM: 1}},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{ID: 1, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+		{
+			Platform: Platform{ID: 2},
+			Model:    Model{ID: 20, Name: "gpt-4o", APIKeys: []APIKey{{ID: 200, RateLimit: RateLimitConfig{RPM: 1}}}},
+			Endpoint: Endpoint{ID: 2, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	r.limiter.now = clock.Now
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+
+	ctx := context.Background()
+	first, err := r.GetChannel(ctx, "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	second, err := r.GetChannel(ctx, "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if first.PlatformID == second.PlatformID {
+		t.Fatalf("饱和通道应被跳过，两次均选择了平台 %d", first.PlatformID)
+	}
+
+	_, err = r.GetChannel(ctx, "gpt-4o")
+	if !errors.IsCode(err, errors.ErrCodeRateLimitExceeded) {
+		t.Fatalf("全部饱和时应返回限流错误，actual=%v", err)
+	}
+	if retryAfter, ok := errors.GetRetryAfter(err); !ok || retryAfter != time.Minute {
+		t.Fatalf("限流错误应携带重试等待提示，actual=%s ok=%v", retryAfter, ok)
+	}
+}
@@ -5,6 +5,8 @@ import (
 )
 
 // RateLimitConfig 定义了限流配置
+//
+// 路由在本地按令牌桶执行限流，饱和的通道在选择时会被跳过；<= 0 表示不限制。
 type RateLimitConfig struct {
 	RPM int // Requests Per Minute
 	TPM int // Tokens Per Minute
@@ -17,6 +19,12 @@ type Platform struct {
 	BaseURL       string
 	RateLimit     RateLimitConfig
 	CustomHeaders map[string]string // 平台级别的自定义 HTTP 头部
+	Weight        int               // 平台权重（用于加权选择，<= 0 时视为 1）
+	Labels        map[string]string // 平台标签（如 region=eu），用于标签约束路由
+
+	// Priority 平台优先级层级，数值越小越优先（0 为主层级）。
+	// 仅当所有更高层级的通道均不可用时，才会使用较低层级的通道。
+	Priority int
 }
 
 // Endpoint 表示平台的端点配置
@@ -27,6 +35,9 @@ type Endpoint struct {
 	EndpointVariant string            // 端点变体（如 "chat_completions", "responses"）
 	Path            string            // API 路径（如 "/v1/chat/completions"）
 	CustomHeaders   map[string]string // 端点级别的自定义 HTTP 头部
+	Labels          map[string]string // 端点标签，覆盖同名平台标签
+	Capabilities    Capabilities      // 端点支持的能力（为空表示不限制）
+	Pricing         *Pricing          // 端点价格（可选），覆盖模型价格
 }
 
 // Model 表示平台上的一个具体模型
@@ -35,13 +46,27 @@ type Model struct {
 	PlatformID uint
 	Name       string
 	Alias      string
+	Weight     int      // 模型权重（用于加权选择，<= 0 时视为 1）
 	APIKeys    []APIKey // 模型关联的密钥（多对多关系）
+
+	// Capabilities 模型支持的能力（为空表示不限制），与端点能力同时生效
+	Capabilities Capabilities
+
+	MaxContextTokens int // 上下文窗口大小（提示词 + 输出），<= 0 表示不限制
+	MaxOutputTokens  int // 单次请求最大输出 Token 数，<= 0 表示不限制
+
+	// Pricing 模型价格（可选），用于成本感知选择与请求费用估算
+	Pricing *Pricing
 }
 
 // APIKey 表示平台的 API 密钥
 type APIKey struct {
-	ID    uint
-	Value string
+	ID     uint
+	Value  string
+	Weight int // 密钥权重（用于加权选择，<= 0 时视为 1）
+
+	// RateLimit 密钥级限流（可选），与平台级限流同时生效
+	RateLimit RateLimitConfig
 }
 
 // ModelWithEndpoint 包含模型、平台和端点的完整信息
@@ -65,6 +90,9 @@ type ModelRepository interface {
 
 	// FindModelsWithEndpoint 通过模型名称 + 端点类型 + 变体查找
 	FindModelsWithEndpoint(ctx context.Context, name, endpointType, endpointVariant string) ([]ModelWithEndpoint, error)
+
+	// FindModelsByAlias 查找别名（Model.Alias）为 alias 的模型，没有模型使用该别名时返回空
+	FindModelsByAlias(ctx context.Context, alias string) ([]Model, error)
 }
 
 type KeyRepository interface {
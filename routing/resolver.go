@@ -0,0 +1,261 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"regexp"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// ModelResolver 模型名称解析接口
+//
+// 路由在查询模型仓库前调用解析器，将客户端请求的模型名称（可能是别名或通配名称）
+// 解析为一组具体的上游模型名称。不同平台可能使用不同的上游名称，
+// 解析结果中的每个名称都会分别查询模型仓库，结果合并后参与通道选择。
+type ModelResolver interface {
+	// Resolve 将请求的模型名称解析为候选上游模型名称列表
+	//
+	// 返回空列表时路由按原始名称查询。
+	Resolve(ctx context.Context, modelName string) ([]string, error)
+}
+
+// ModelResolverFunc 函数形式的模型名称解析器
+type ModelResolverFunc func(ctx context.Context, modelName string) ([]string, error)
+
+// Resolve 实现 ModelResolver 接口
+func (f ModelResolverFunc) Resolve(ctx context.Context, modelName string) ([]string, error) {
+	return f(ctx, modelName)
+}
+
+// ModelRuleType 模型映射规则类型
+type ModelRuleType string
+
+const (
+	// ModelRuleAlias 精确别名：请求名称与 Pattern 完全相同时映射到 Targets
+	ModelRuleAlias ModelRuleType = "alias"
+	// ModelRuleGlob 通配匹配：Pattern 使用 path.Match 语法（如 "claude-*"）
+	ModelRuleGlob ModelRuleType = "glob"
+	// ModelRuleRegex 正则改写：Pattern 为正则表达式，Targets 为替换模板（支持 $1 等引用）
+	ModelRuleRegex ModelRuleType = "regex"
+)
+
+// ModelRule 模型名称映射规则
+type ModelRule struct {
+	Type    ModelRuleType
+	Pattern string
+	// Targets 映射目标，按优先级排列；为空时保持请求名称不变
+	Targets []string
+}
+
+// RuleModelResolver 基于规则的模型名称解析器
+//
+// 规则按顺序匹配，第一个命中的规则生效；没有规则命中时返回原始名称。
+type RuleModelResolver struct {
+	rules []compiledModelRule
+}
+
+type compiledModelRule struct {
+	ModelRule
+	re *regexp.Regexp
+}
+
+// NewRuleModelResolver 创建基于规则的模型名称解析器
+func NewRuleModelResolver(rules ...ModelRule) (*RuleModelResolver, error) {
+	compiled := make([]compiledModelRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, errors.New(errors.ErrCodeConfigInvalid, "模型映射规则的匹配模式不能为空").
+				WithContext("rule_index", i)
+		}
+
+		item := compiledModelRule{ModelRule: rule}
+		switch rule.Type {
+		case ModelRuleAlias:
+		case ModelRuleGlob:
+			if _, err := path.Match(rule.Pattern, ""); err != nil {
+				return nil, errors.Wrap(errors.ErrCodeConfigInvalid, "无效的模型通配模式", err).
+					WithContext("rule_index", i).
+					WithContext("pattern", rule.Pattern)
+			}
+		case ModelRuleRegex:
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, errors.Wrap(errors.ErrCodeConfigInvalid, "无效的模型正则表达式", err).
+					WithContext("rule_index", i).
+					WithContext("pattern", rule.Pattern)
+			}
+			item.re = re
+		default:
+			return nil, errors.New(errors.ErrCodeConfigInvalid, "不支持的模型映射规则类型").
+				WithContext("rule_index", i).
+				WithContext("type", string(rule.Type))
+		}
+		compiled = append(compiled, item)
+	}
+	return &RuleModelResolver{rules: compiled}, nil
+}
+
+// Resolve 实现 ModelResolver 接口
+func (r *RuleModelResolver) Resolve(ctx context.Context, modelName string) ([]string, error) {
+	return r.resolveRules(modelName), nil
+}
+
+// resolveRules 按规则解析请求名称
+func (r *RuleModelResolver) resolveRules(modelName string) []string {
+	for _, rule := range r.rules {
+		switch rule.Type {
+		case ModelRuleAlias:
+			if modelName != rule.Pattern {
+				continue
+			}
+			return targetsOrSelf(rule.Targets, modelName)
+		case ModelRuleGlob:
+			if matched, _ := path.Match(rule.Pattern, modelName); !matched {
+				continue
+			}
+			return targetsOrSelf(rule.Targets, modelName)
+		case ModelRuleRegex:
+			if !rule.re.MatchString(modelName) {
+				continue
+			}
+			if len(rule.Targets) == 0 {
+				return []string{modelName}
+			}
+			names := make([]string, 0, len(rule.Targets))
+			for _, target := range rule.Targets {
+				names = append(names, rule.re.ReplaceAllString(modelName, target))
+			}
+			return names
+		}
+	}
+	return []string{modelName}
+}
+
+// targetsOrSelf 返回映射目标，目标为空时返回请求名称
+func targetsOrSelf(targets []string, modelName string) []string {
+	if len(targets) == 0 {
+		return []string{modelName}
+	}
+	return append([]string(nil), targets...)
+}
+
+// resolveModelNames 解析请求的模型名称并去重
+//
+// 先按解析器解析（未配置或结果为空时使用原始名称），再追加 Model.Alias 与请求名称相同的模型名称。
+func (r *Routing) resolveModelNames(ctx context.Context, modelName string) ([]string, error) {
+	var names []string
+	seen := make(map[string]struct{})
+	add := func(name string) {
+		if name == "" {
+			return
+		}
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	if r.resolver != nil {
+		resolved, err := r.resolver.Resolve(ctx, modelName)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrCodeInternal, "解析模型名称失败", err).
+				WithContext("model", modelName)
+		}
+		for _, name := range resolved {
+			add(name)
+		}
+	}
+	if len(names) == 0 {
+		add(modelName)
+	}
+
+	aliased, err := r.lookupAlias(ctx, modelName)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range aliased {
+		add(name)
+	}
+	return names, nil
+}
+
+// lookupAlias 通过模型仓库查找别名与请求名称相同的模型名称
+//
+// 查询结果与模型查询共用缓存，模型修改后通过 Invalidate 失效（按模型名称、别名或模型 ID 均可）。
+func (r *Routing) lookupAlias(ctx context.Context, modelName string) ([]string, error) {
+	found, err := r.cache.get(ctx, lookupKey{name: modelName, alias: true}, func(ctx context.Context) ([]ModelWithEndpoint, error) {
+		models, err := r.modelRepo.FindModelsByAlias(ctx, modelName)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]ModelWithEndpoint, 0, len(models))
+		for _, model := range models {
+			result = append(result, ModelWithEndpoint{Model: model})
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCodeInternal, "查询模型别名失败", err).
+			WithHTTPStatus(http.StatusInternalServerError).
+			WithContext("model", modelName)
+	}
+
+	names := make([]string, 0, len(found))
+	for _, mwe := range found {
+		if mwe.Model.Name != modelName {
+			names = append(names, mwe.Model.Name)
+		}
+	}
+	return names, nil
+}
+
+// findModelsWithEndpoint 按解析后的模型名称逐个查询仓库并合并结果，同时返回解析后的名称
+func (r *Routing) findModelsWithEndpoint(
+	ctx context.Context,
+	modelName string,
+	find func(ctx context.Context, name string) ([]ModelWithEndpoint, error),
+) ([]ModelWithEndpoint, []string, error) {
+	names, err := r.resolveModelNames(ctx, modelName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type modelEndpointKey struct {
+		modelID    uint
+		endpointID uint
+	}
+
+	var result []ModelWithEndpoint
+	seen := make(map[modelEndpointKey]struct{})
+	for _, name := range names {
+		found, err := find(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, mwe := range found {
+			key := modelEndpointKey{modelID: mwe.Model.ID, endpointID: mwe.Endpoint.ID}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, mwe)
+		}
+	}
+	return result, names, nil
+}
+
+// markResolved 解析器将请求名称映射为通道的模型名称时，在 ResolvedFrom 中记录请求名称
+func markResolved(ch *Channel, modelName string, names []string) {
+	if ch.ModelName == modelName {
+		return
+	}
+	for _, name := range names {
+		if name == ch.ModelName {
+			ch.ResolvedFrom = modelName
+			return
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package routing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func TestRuleModelResolver_Resolve(t *testing.T) {
+	resolver, err := NewRuleModelResolver(
+		ModelRule{Type: ModelRuleAlias, Pattern: "gpt-4o-latest", Targets: []string{"gpt-4o-2024-11-20", "openai/gpt-4o"}},
+		ModelRule{Type: ModelRuleRegex, Pattern: `^claude-(\d)-sonnet$`, Targets: []string{"claude-sonnet-$1"}},
+		ModelRule{Type: ModelRuleGlob, Pattern: "claude-*", Targets: []string{"claude-default"}},
+	)
+	if err != nil {
+		t.Fatalf("创建解析器失败: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		requested string
+		want      []string
+	}{
+		{name: "精确别名", requested: "gpt-4o-latest", want: []string{"gpt-4o-2024-11-20", "openai/gpt-4o"}},
+		{name: "正则改写优先于后续通配", requested: "claude-4-sonnet", want: []string{"claude-sonnet-4"}},
+		{name: "通配匹配", requested: "claude-haiku", want: []string{"claude-default"}},
+		{name: "未命中保持原名", requested: "gemini-pro", want: []string{"gemini-pro"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.requested)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("期望 %v，actual=%v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewRuleModelResolver_InvalidRule(t *testing.T) {
+	rules := []ModelRule{
+		{Type: ModelRuleRegex, Pattern: "("},
+		{Type: ModelRuleGlob, Pattern: "["},
+		{Type: "unknown", Pattern: "x"},
+		{Type: ModelRuleAlias},
+	}
+	for _, rule := range rules {
+		if _, err := NewRuleModelResolver(rule); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+			t.Fatalf("规则 %+v 应返回配置无效错误，actual=%v", rule, err)
+		}
+	}
+}
+
+func TestGetChannel_ResolvesModelNamesPerPlatform(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "claude-sonnet-4-20250514", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{ID: 1, EndpointType: "anthropic", EndpointVariant: "messages"},
+		},
+		{
+			Platform: Platform{ID: 2},
+			Model:    Model{ID: 20, Name: "anthropic/claude-sonnet-4", APIKeys: []APIKey{{ID: 200}}},
+			Endpoint: Endpoint{ID: 2, EndpointType: "anthropic", EndpointVariant: "messages"},
+		},
+	}
+
+	resolver, err := NewRuleModelResolver(ModelRule{
+		Type:    ModelRuleGlob,
+		Pattern: "claude-sonnet*",
+		Targets: []string{"claude-sonnet-4-20250514", "anthropic/claude-sonnet-4", "claude-sonnet-4-20250514"},
+	})
+	if err != nil {
+		t.Fatalf("创建解析器失败: %v", err)
+	}
+
+	sel := &recordingSelector{inner: selector.NewLRUSelector()}
+	storage := newTestChannelStorage()
+	r, err := New(context.Background(), Config{
+		Selector:      sel,
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     &testModelRepo{models: models},
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: storage,
+		ModelResolver: resolver,
+	})
+	if err != nil {
+		t.Fatalf("创建路由失败: %v", err)
+	}
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+
+	ch, err := r.GetChannelByProvider(context.Background(), "claude-sonnet-latest", "anthropic", "messages")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.ModelName != "claude-sonnet-4-20250514" && ch.ModelName != "anthropic/claude-sonnet-4" {
+		t.Fatalf("通道应使用上游模型名称，actual=%s", ch.ModelName)
+	}
+	if ch.ResolvedFrom != "claude-sonnet-latest" {
+		t.Fatalf("解析器映射后应记录请求模型名称，actual=%q", ch.ResolvedFrom)
+	}
+	if len(sel.last) != 2 {
+		t.Fatalf("两个平台的上游名称都应参与选择且去重，actual=%d", len(sel.last))
+	}
+
+	if _, err := r.GetChannel(context.Background(), "gpt-4o"); !errors.IsCode(err, errors.ErrCodeNotFound) {
+		t.Fatalf("未命中规则时应按原名查询并返回未找到，actual=%v", err)
+	}
+}
+
+func TestGetChannel_ResolvesModelAlias(t *testing.T) {
+	repo := &testModelRepo{models: []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o-2024-11-20", Alias: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{ID: 1, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}}
+	storage := newTestChannelStorage()
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+	r := newCachedTestRouting(t, repo, storage, CacheConfig{TTL: time.Minute, NegativeTTL: time.Minute})
+	ctx := context.Background()
+
+	ch, err := r.GetChannel(ctx, "gpt-4o")
+	if err != nil {
+		t.Fatalf("未配置解析器时也应按别名解析，error=%v", err)
+	}
+	if ch.ModelName != "gpt-4o-2024-11-20" || ch.ResolvedFrom != "gpt-4o" {
+		t.Fatalf("应解析到别名对应的模型，actual=%s resolved_from=%q", ch.ModelName, ch.ResolvedFrom)
+	}
+	ch.Release()
+
+	if _, err := r.GetChannel(ctx, "gpt-4o-mini"); !errors.IsCode(err, errors.ErrCodeNotFound) {
+		t.Fatalf("别名不存在时应返回未找到，actual=%v", err)
+	}
+
+	// 模型新增别名后失效缓存即可生效
+	repo.models = append(repo.models, ModelWithEndpoint{
+		Platform: Platform{ID: 2},
+		Model:    Model{ID: 20, Name: "gpt-4o-mini-2024-07-18", Alias: "gpt-4o-mini", APIKeys: []APIKey{{ID: 200}}},
+		Endpoint: Endpoint{ID: 2, EndpointType: "openai", EndpointVariant: "chat_completions"},
+	})
+	r.Invalidate(Invalidation{ModelID: 20})
+	ch, err = r.GetChannel(ctx, "gpt-4o-mini")
+	if err != nil {
+		t.Fatalf("模型更新后应解析到新别名，error=%v", err)
+	}
+	if ch.ModelName != "gpt-4o-mini-2024-07-18" {
+		t.Fatalf("应解析到新别名对应的模型，actual=%s", ch.ModelName)
+	}
+	ch.Release()
+}
@@ -2,8 +2,8 @@ package routing
 
 import (
 	"context"
-	"fmt"
 	"sync"
+	"time"
 
 	"net/http"
 
@@ -20,23 +20,75 @@ type Routing struct {
 	modelRepo     ModelRepository
 	keyRepo       KeyRepository
 	healthService *health.Service
-	mu            sync.Mutex // 保护并发通道选择的互斥锁
+	latency       *latencyTracker      // 通道延迟统计
+	inflight      *inflightTracker     // 在途请求计数
+	resolver      ModelResolver        // 模型名称解析器（可选）
+	limiter       *rateLimiter         // 本地 RPM/TPM 限流器
+	cache         *lookupCache         // 模型/端点查询缓存
+	healthCache   *cachedHealthStorage // 健康状态读缓存（未启用时为 nil）
+	splits        []TrafficSplit       // 模型级流量切分规则
+	probes        *probeTargets        // 主动探测目标
+	mu            sync.Mutex           // 保护并发通道选择的互斥锁
 }
 
 // Config 通道服务配置
 type Config struct {
 	Selector      selector.Selector
+	SelectorType  selector.SelectorType // 选择器类型（Selector 为空时通过 selector.Create 创建）
 	PlatformRepo  PlatformRepository
 	ModelRepo     ModelRepository
 	KeyRepo       KeyRepository
 	HealthStorage health.Storage // 健康状态存储
+	ModelResolver ModelResolver  // 模型名称解析器（可选，为空时按原始名称查询）
+	Cache         CacheConfig    // 路由缓存配置（可选，零值表示不缓存）
+	Health        HealthConfig   // 健康判定配置（可选）
+	TrafficSplits []TrafficSplit // 模型级流量切分规则（可选）
+}
+
+// HealthConfig 健康判定配置
+type HealthConfig struct {
+	Backoff          health.BackoffStrategy // 退避策略，为空时使用 health.DefaultBackoffStrategy()
+	AllowProbing     bool                   // 退避结束后是否允许探测处于不可用状态的资源
+	FailureThreshold int                    // 连续失败达到该次数后才开始退避，<= 0 时为 1
+	HalfOpenTrials   int                    // 退避结束（熔断器半开）后同时放行的试探请求数，<= 0 时为 1
+	Evaluator        health.Evaluator       // 失败是否触发退避的判定器（如 health.NewSlidingWindowEvaluator），为空时按 FailureThreshold 判定
+}
+
+// Validate 校验健康判定配置
+func (c HealthConfig) Validate() error {
+	if c.FailureThreshold < 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "失败阈值不能为负数").
+			WithContext("failure_threshold", c.FailureThreshold)
+	}
+	if c.HalfOpenTrials < 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "半开试探请求数不能为负数").
+			WithContext("half_open_trials", c.HalfOpenTrials)
+	}
+	return nil
+}
+
+// resolveSelector 返回配置的选择器实例，未提供实例时按类型创建
+func resolveSelector(sel selector.Selector, selectorType selector.SelectorType) (selector.Selector, error) {
+	if sel != nil {
+		return sel, nil
+	}
+	if selectorType == "" {
+		return nil, errors.New(errors.ErrCodeInvalidArgument, "选择器不能为空")
+	}
+	created, err := selector.Create(selectorType)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCodeConfigInvalid, "未注册的选择器类型", err).
+			WithContext("selector_type", string(selectorType))
+	}
+	return created, nil
 }
 
 // New 创建一个新的通道服务
 func New(ctx context.Context, cfg Config) (*Routing, error) {
 	// 验证配置
-	if cfg.Selector == nil {
-		return nil, errors.New(errors.ErrCodeInvalidArgument, "选择器不能为空")
+	sel, err := resolveSelector(cfg.Selector, cfg.SelectorType)
+	if err != nil {
+		return nil, err
 	}
 	if cfg.PlatformRepo == nil {
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "平台仓库不能为空")
@@ -51,9 +103,32 @@ func New(ctx context.Context, cfg Config) (*Routing, error) {
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "健康状态存储不能为空")
 	}
 
+	if err := cfg.Cache.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Health.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateTrafficSplits(cfg.TrafficSplits); err != nil {
+		return nil, err
+	}
+
+	// 启用健康状态缓存时包装存储，健康服务的读写均经过缓存
+	var healthCache *cachedHealthStorage
+	healthStorage := cfg.HealthStorage
+	if cfg.Cache.HealthTTL > 0 {
+		healthCache = newCachedHealthStorage(cfg.HealthStorage, cfg.Cache.HealthTTL)
+		healthStorage = healthCache
+	}
+
 	// 创建健康服务
 	healthConfig := health.Config{
-		Storage: cfg.HealthStorage,
+		Storage:          healthStorage,
+		Backoff:          cfg.Health.Backoff,
+		AllowProbing:     cfg.Health.AllowProbing,
+		FailureThreshold: cfg.Health.FailureThreshold,
+		HalfOpenTrials:   cfg.Health.HalfOpenTrials,
+		Evaluator:        cfg.Health.Evaluator,
 	}
 	healthService, err := health.New(healthConfig)
 	if err != nil {
@@ -61,35 +136,82 @@ func New(ctx context.Context, cfg Config) (*Routing, error) {
 	}
 
 	return &Routing{
-		selector:      cfg.Selector,
+		selector:      sel,
 		platformRepo:  cfg.PlatformRepo,
 		modelRepo:     cfg.ModelRepo,
 		keyRepo:       cfg.KeyRepo,
 		healthService: healthService,
+		latency:       newLatencyTracker(),
+		inflight:      newInflightTracker(),
+		resolver:      cfg.ModelResolver,
+		limiter:       newRateLimiter(),
+		cache:         newLookupCache(cfg.Cache),
+		healthCache:   healthCache,
+		splits:        append([]TrafficSplit(nil), cfg.TrafficSplits...),
+		probes:        newProbeTargets(),
 	}, nil
 }
 
+// SetSelector 在运行时替换通道选择器，sel 为空时按 selectorType 创建
+//
+// 替换后的选择器从下一次通道选择开始生效，选择器内部状态（如轮询进度）不会迁移。
+func (r *Routing) SetSelector(sel selector.Selector, selectorType selector.SelectorType) error {
+	resolved, err := resolveSelector(sel, selectorType)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.selector = resolved
+	r.mu.Unlock()
+	return nil
+}
+
+// SetHealthConfig 在运行时调整退避策略、探测开关、失败阈值、半开试探请求数与失败判定器
+//
+// 仅影响之后的健康状态更新与判定，已写入的退避时间不会重新计算。
+func (r *Routing) SetHealthConfig(cfg HealthConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	r.healthService.SetBackoff(cfg.Backoff)
+	r.healthService.SetAllowProbing(cfg.AllowProbing)
+	r.healthService.SetHalfOpenTrials(cfg.HalfOpenTrials)
+	r.healthService.SetEvaluator(cfg.Evaluator)
+	return r.healthService.SetFailureThreshold(cfg.FailureThreshold)
+}
+
+// SubscribeHealthEvents 订阅平台/模型/密钥的健康状态变更事件，buffer <= 0 时使用 health.DefaultEventBuffer
+func (r *Routing) SubscribeHealthEvents(buffer int) *health.Subscription {
+	return r.healthService.SubscribeEvents(buffer)
+}
+
 // GetChannel 根据模型名称获取一个可用的通道（使用默认端点）
-func (r *Routing) GetChannel(ctx context.Context, modelName string) (*Channel, error) {
+func (r *Routing) GetChannel(ctx context.Context, modelName string, opts ...SelectOption) (*Channel, error) {
 	if modelName == "" {
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "模型名称不能为空").WithHTTPStatus(http.StatusBadRequest)
 	}
 
-	// 通过模型名称查找，返回带有平台和默认端点的完整信息
-	modelsWithEndpoint, err := r.modelRepo.FindModelsWithDefaultEndpoint(ctx, modelName)
+	modelsWithEndpoint, names, err := r.lookupDefaultEndpoint(ctx, modelName)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrCodeInternal, "查询模型失败", err).WithHTTPStatus(http.StatusInternalServerError)
+		return nil, err
 	}
 
-	if len(modelsWithEndpoint) == 0 {
-		return nil, errors.New(errors.ErrCodeNotFound, "未找到模型或平台未配置默认端点").WithHTTPStatus(http.StatusNotFound)
+	options := applySelectOptions(opts)
+	options.model = modelName
+	ch, err := r.selectChannelFromModelsWithEndpoint(modelsWithEndpoint, options)
+	if err != nil {
+		return nil, err
 	}
-
-	return r.selectChannelFromModelsWithEndpoint(modelsWithEndpoint)
+	markResolved(ch, modelName, names)
+	return ch, nil
 }
 
 // GetChannelByProvider 根据模型名称、端点类型和变体获取一个可用的通道
-func (r *Routing) GetChannelByProvider(ctx context.Context, modelName, endpointType, endpointVariant string) (*Channel, error) {
+func (r *Routing) GetChannelByProvider(
+	ctx context.Context,
+	modelName, endpointType, endpointVariant string,
+	opts ...SelectOption,
+) (*Channel, error) {
 	// 参数校验
 	if modelName == "" {
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "模型名称不能为空").WithHTTPStatus(http.StatusBadRequest)
@@ -101,65 +223,272 @@ func (r *Routing) GetChannelByProvider(ctx context.Context, modelName, endpointT
 		return nil, errors.New(errors.ErrCodeInvalidArgument, "端点变体不能为空").WithHTTPStatus(http.StatusBadRequest)
 	}
 
-	// 通过模型名称 + 端点类型 + 变体查找
-	modelsWithEndpoint, err := r.modelRepo.FindModelsWithEndpoint(ctx, modelName, endpointType, endpointVariant)
+	modelsWithEndpoint, names, err := r.lookupEndpoint(ctx, modelName, endpointType, endpointVariant)
 	if err != nil {
-		return nil, errors.Wrap(errors.ErrCodeInternal, "查询模型失败", err).WithHTTPStatus(http.StatusInternalServerError)
+		return nil, err
+	}
+
+	options := applySelectOptions(opts)
+	options.model = modelName
+	ch, err := r.selectChannelFromModelsWithEndpoint(modelsWithEndpoint, options)
+	if err != nil {
+		return nil, err
+	}
+	markResolved(ch, modelName, names)
+	return ch, nil
+}
+
+// lookupDefaultEndpoint 解析模型名称后逐个查找，返回带有平台和默认端点的完整信息与解析后的名称
+func (r *Routing) lookupDefaultEndpoint(ctx context.Context, modelName string) ([]ModelWithEndpoint, []string, error) {
+	modelsWithEndpoint, names, err := r.findModelsWithEndpoint(ctx, modelName, func(ctx context.Context, name string) ([]ModelWithEndpoint, error) {
+		found, err := r.cache.get(ctx, lookupKey{name: name}, func(ctx context.Context) ([]ModelWithEndpoint, error) {
+			return r.modelRepo.FindModelsWithDefaultEndpoint(ctx, name)
+		})
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrCodeInternal, "查询模型失败", err).WithHTTPStatus(http.StatusInternalServerError)
+		}
+		return found, nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if len(modelsWithEndpoint) == 0 {
-		return nil, errors.New(errors.ErrCodeEndpointNotFound, "未找到匹配的端点").WithHTTPStatus(http.StatusNotFound)
+		return nil, nil, errors.New(errors.ErrCodeNotFound, "未找到模型或平台未配置默认端点").WithHTTPStatus(http.StatusNotFound)
 	}
+	return modelsWithEndpoint, names, nil
+}
 
-	return r.selectChannelFromModelsWithEndpoint(modelsWithEndpoint)
+// lookupEndpoint 解析模型名称后按模型名称 + 端点类型 + 变体查找，同时返回解析后的名称
+func (r *Routing) lookupEndpoint(
+	ctx context.Context,
+	modelName, endpointType, endpointVariant string,
+) ([]ModelWithEndpoint, []string, error) {
+	modelsWithEndpoint, names, err := r.findModelsWithEndpoint(ctx, modelName, func(ctx context.Context, name string) ([]ModelWithEndpoint, error) {
+		key := lookupKey{name: name, endpointType: endpointType, endpointVariant: endpointVariant}
+		found, err := r.cache.get(ctx, key, func(ctx context.Context) ([]ModelWithEndpoint, error) {
+			return r.modelRepo.FindModelsWithEndpoint(ctx, name, endpointType, endpointVariant)
+		})
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrCodeInternal, "查询模型失败", err).WithHTTPStatus(http.StatusInternalServerError)
+		}
+		return found, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(modelsWithEndpoint) == 0 {
+		return nil, nil, errors.New(errors.ErrCodeEndpointNotFound, "未找到匹配的端点").WithHTTPStatus(http.StatusNotFound)
+	}
+	return modelsWithEndpoint, names, nil
 }
 
 // selectChannelFromModelsWithEndpoint 从模型列表中选择一个可用的通道
-func (r *Routing) selectChannelFromModelsWithEndpoint(modelsWithEndpoint []ModelWithEndpoint) (*Channel, error) {
+//
+// 命中流量切分规则时先在分配到的分组内选择；分组内没有可用通道时回退到全部通道，
+// 避免灰度平台故障导致请求失败。选中通道的 SplitArm 记录其实际所属分组。
+func (r *Routing) selectChannelFromModelsWithEndpoint(
+	modelsWithEndpoint []ModelWithEndpoint,
+	options *selectOptions,
+) (*Channel, error) {
+	assignment := r.assignSplit(modelsWithEndpoint, options)
+	if assignment == nil {
+		return r.selectChannel(modelsWithEndpoint, options)
+	}
+
+	armed := *options
+	armed.split = assignment
+	ch, err := r.selectChannel(modelsWithEndpoint, &armed)
+	if err != nil && (errors.IsCode(err, errors.ErrCodeResourceExhausted) ||
+		errors.IsCode(err, errors.ErrCodeRateLimitExceeded) ||
+		errors.IsCode(err, errors.ErrCodeCircuitBreakerOpen) ||
+		errors.IsCode(err, errors.ErrCodeNoHealthyChannel)) {
+		ch, err = r.selectChannel(modelsWithEndpoint, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ch.SplitArm = assignment.split.armOf(ch)
+	return ch, nil
+}
+
+// selectChannel 从模型列表中选择一个可用的通道
+//
+// 通道按平台优先级分层：仅在最优（数值最小）且存在健康通道的层级内进行选择，
+// 更低层级的通道只有在更高层级全部处于退避或不可用时才会被使用。
+// 处于半开状态的通道在选中后需获得试探名额，名额已满时排除该通道重新选择。
+func (r *Routing) selectChannel(
+	modelsWithEndpoint []ModelWithEndpoint,
+	options *selectOptions,
+) (*Channel, error) {
 	// 为每个模型构建通道
 	var availableChannels []*Channel
 	var channelInfos []selector.ChannelInfo
+	var unknownChannel *Channel
+	bestTier := 0
+	hasTier := false
+
+	// 因本地限流饱和而被跳过的通道，记录最短的预计等待时长
+	rateLimited := false
+	var retryAfter time.Duration
+
+	// 是否有通道因本次请求已尝试过而被排除
+	channelExcluded := false
+
+	// 是否有通道因熔断器拒绝半开试探（试探名额已满）而被跳过
+	trialRejected := false
+
+	// 未知状态的通道是否与可用通道一同交由选择器选择
+	unknownAsCandidate := r.selectsUnknownByAffinity(options)
+
+	// 候选通道数，以及是否有通道支持所需能力、能容纳请求、满足标签约束
+	candidates := 0
+	capable := false
+	fits := false
+	labelMatched := false
 
 	for _, mwe := range modelsWithEndpoint {
 		channels := r.buildChannelsForModelWithEndpoint(mwe)
 
 		// 使用 health 验证通道是否可用
 		for _, ch := range channels {
+			// 不支持所需能力、上下文窗口不足或不满足标签约束的通道始终不可用
+			candidates++
+			if !ch.Supports(options.capabilities...) {
+				continue
+			}
+			capable = true
+			if !ch.fitsContext(options) {
+				continue
+			}
+			fits = true
+			if !ch.matchesLabels(options.labels) {
+				continue
+			}
+			labelMatched = true
+
+			// 不属于分配到的流量切分分组
+			if !options.split.allows(ch) {
+				continue
+			}
+
+			// 已找到更高优先级层级的健康通道时，低优先级通道无需检查
+			if hasTier && ch.priority > bestTier {
+				continue
+			}
+
+			// 本次请求已排除的密钥
+			if options.isKeyExcluded(ch.APIKeyID) {
+				continue
+			}
+			if options.isChannelExcluded(ch.ID()) {
+				channelExcluded = true
+				continue
+			}
+			if options.isTripped(ch.ID()) {
+				trialRejected = true
+				continue
+			}
+
 			result, platformLastTry, modelLastTry, keyLastTry := r.healthService.GetChannelHealthAndLastTryTimes(
 				ch.PlatformID,
 				ch.ModelID,
 				ch.APIKeyID,
 			)
 
+			// 不可用的通道直接跳过
+			if result.Status == health.ChannelStatusUnavailable {
+				if result.TrialsExhausted {
+					trialRejected = true
+				}
+				continue
+			}
+
+			// 本地限流已饱和的通道跳过，不参与层级判定
+			if wait := r.limiter.check(ch, options.estimatedTokens); wait > 0 {
+				if !rateLimited || wait < retryAfter {
+					retryAfter = wait
+				}
+				rateLimited = true
+				continue
+			}
+
+			// 发现更高优先级层级的健康通道，丢弃已收集的低优先级候选
+			if !hasTier || ch.priority < bestTier {
+				bestTier = ch.priority
+				hasTier = true
+				availableChannels = nil
+				channelInfos = nil
+				unknownChannel = nil
+			}
+
 			switch result.Status {
 			case health.ChannelStatusAvailable:
 				availableChannels = append(availableChannels, ch)
-				channelInfos = append(channelInfos, selector.ChannelInfo{
-					ID:              fmt.Sprintf("%d-%d-%d", ch.PlatformID, ch.ModelID, ch.APIKeyID),
-					PlatformID:      ch.PlatformID,
-					ModelID:         ch.ModelID,
-					APIKeyID:        ch.APIKeyID,
-					LastTryPlatform: platformLastTry,
-					LastTryModel:    modelLastTry,
-					LastTryKey:      keyLastTry,
-				})
+				channelInfos = append(channelInfos, r.channelInfo(ch, platformLastTry, modelLastTry, keyLastTry))
 			case health.ChannelStatusUnknown:
-				// 对于未知状态的通道，直接返回它
-				return ch, nil
+				if unknownAsCandidate {
+					availableChannels = append(availableChannels, ch)
+					channelInfos = append(channelInfos, r.channelInfo(ch, platformLastTry, modelLastTry, keyLastTry))
+				} else if unknownChannel == nil {
+					unknownChannel = ch
+				}
 			}
-			// 不可用的通道直接跳过
 		}
 	}
 
+	// 对于最优层级内未知状态的通道，直接返回它（按亲和键选择时未知通道已并入候选）
+	if unknownChannel != nil {
+		if !r.admitChannel(unknownChannel) {
+			return r.selectChannel(modelsWithEndpoint, options.withTripped(unknownChannel))
+		}
+		r.reserveChannel(unknownChannel, options)
+		unknownChannel.acquire()
+		return unknownChannel, nil
+	}
+
 	// 如果没有可用通道，返回错误
 	if len(availableChannels) == 0 {
-		return nil, errors.New(errors.ErrCodeResourceExhausted, "没有可用的通道").WithHTTPStatus(http.StatusServiceUnavailable)
+		if candidates > 0 {
+			switch {
+			case !capable:
+				return nil, newCapabilityError(modelsWithEndpoint, options.capabilities)
+			case !fits:
+				return nil, newContextWindowError(modelsWithEndpoint, options)
+			case !labelMatched:
+				return nil, r.newLabelConstraintError(modelsWithEndpoint, options.labels)
+			}
+		}
+		// 未尝试过的通道已耗尽，策略允许时回到已尝试过的通道
+		if channelExcluded && !rateLimited && options.reuseExcluded {
+			relaxed := *options
+			relaxed.excludedChannels = nil
+			return r.selectChannel(modelsWithEndpoint, &relaxed)
+		}
+		if rateLimited {
+			return nil, errors.New(errors.ErrCodeRateLimitExceeded, "所有可用通道均已达到本地限流上限").
+				WithHTTPStatus(http.StatusTooManyRequests).
+				WithContext("error_from", string(errors.ErrorFromGateway)).
+				WithContext("retry_after", retryAfter)
+		}
+		// 熔断器拒绝了半开试探（而非仅处于退避或本次请求已尝试过）
+		if trialRejected && !channelExcluded {
+			return nil, errors.New(errors.ErrCodeCircuitBreakerOpen, "熔断器半开试探名额已满，没有可用的通道").
+				WithHTTPStatus(http.StatusServiceUnavailable).
+				WithContext("error_from", string(errors.ErrorFromGateway))
+		}
+		exhaustedErr := errors.New(errors.ErrCodeResourceExhausted, "没有可用的通道").WithHTTPStatus(http.StatusServiceUnavailable)
+		if channelExcluded {
+			exhaustedErr = exhaustedErr.WithContext("excluded_channels", len(options.excludedChannels))
+		}
+		return nil, exhaustedErr
 	}
 
 	// 使用互斥锁保护通道选择和时间更新操作
 	// 确保在并发环境下，选择通道和更新使用时间是原子操作
 	r.mu.Lock()
-	selectedID, err := r.selector.Select(channelInfos)
+	selectedID, err := r.selectChannelID(channelInfos, options)
 	if err != nil {
 		r.mu.Unlock()
 		return nil, errors.Wrap(errors.ErrCodeInternal, "选择通道失败", err).WithHTTPStatus(http.StatusInternalServerError)
@@ -176,8 +505,14 @@ func (r *Routing) selectChannelFromModelsWithEndpoint(modelsWithEndpoint []Model
 		return nil, errors.New(errors.ErrCodeInternal, "选择的通道未找到").WithHTTPStatus(http.StatusInternalServerError)
 	}
 
-	// 立即更新选中通道的最近尝试时间
+	// 半开通道需获得试探名额，否则排除后重新选择
 	selectedChannel := availableChannels[selectedIndex]
+	if !r.admitChannel(selectedChannel) {
+		r.mu.Unlock()
+		return r.selectChannel(modelsWithEndpoint, options.withTripped(selectedChannel))
+	}
+
+	// 立即更新选中通道的最近尝试时间
 	if updateErr := r.healthService.UpdateLastTry(
 		selectedChannel.PlatformID,
 		selectedChannel.ModelID,
@@ -187,12 +522,82 @@ func (r *Routing) selectChannelFromModelsWithEndpoint(modelsWithEndpoint []Model
 		// TODO: 添加日志记录
 		_ = updateErr
 	}
+	// 在锁内预扣限流额度并增加在途计数，保证并发选择能观察到彼此的选择结果
+	r.reserveChannel(selectedChannel, options)
+	selectedChannel.acquire()
 	r.mu.Unlock()
 
 	// 找到对应的通道
 	return selectedChannel, nil
 }
 
+// channelInfo 构建选择器所需的通道元数据
+func (r *Routing) channelInfo(ch *Channel, platformLastTry, modelLastTry, keyLastTry time.Time) selector.ChannelInfo {
+	channelID := ch.ID()
+	latencyEWMA, firstByteEWMA := r.latency.snapshot(channelID)
+	inflightPlatform, inflightModel, inflightKey := r.inflight.channelCounts(ch.PlatformID, ch.ModelID, ch.APIKeyID)
+	return selector.ChannelInfo{
+		ID:               channelID,
+		PlatformID:       ch.PlatformID,
+		ModelID:          ch.ModelID,
+		APIKeyID:         ch.APIKeyID,
+		LastTryPlatform:  platformLastTry,
+		LastTryModel:     modelLastTry,
+		LastTryKey:       keyLastTry,
+		PlatformWeight:   ch.platformWeight,
+		ModelWeight:      ch.modelWeight,
+		KeyWeight:        ch.keyWeight,
+		LatencyEWMA:      latencyEWMA,
+		FirstByteEWMA:    firstByteEWMA,
+		InFlightPlatform: inflightPlatform,
+		InFlightModel:    inflightModel,
+		InFlightKey:      inflightKey,
+		Priced:           ch.Pricing != nil,
+		InputPrice:       ch.Pricing.inputPrice(),
+		OutputPrice:      ch.Pricing.outputPrice(),
+	}
+}
+
+// admitChannel 为选中的通道申请半开试探名额，名额已满时返回 false
+func (r *Routing) admitChannel(ch *Channel) bool {
+	trial, err := r.healthService.AdmitChannel(ch.PlatformID, ch.ModelID, ch.APIKeyID)
+	if err != nil {
+		return false
+	}
+	ch.trial = trial
+	return true
+}
+
+// reserveChannel 为选中的通道预扣本地限流额度
+func (r *Routing) reserveChannel(ch *Channel, options *selectOptions) {
+	ch.reservedTokens = options.estimatedTokens
+	r.limiter.reserve(ch, options.estimatedTokens)
+}
+
+// selectsUnknownByAffinity 判断未知状态的通道是否交由选择器选择
+//
+// 默认未知状态的通道会被直接选中以尽快探测其健康状态；设置了亲和键且选择器感知请求时，
+// 未知通道与可用通道一同参与选择，避免新通道破坏亲和键到通道的稳定映射。
+func (r *Routing) selectsUnknownByAffinity(options *selectOptions) bool {
+	if options.affinityKey == "" {
+		return false
+	}
+	r.mu.Lock()
+	_, aware := r.selector.(selector.RequestAwareSelector)
+	r.mu.Unlock()
+	return aware
+}
+
+// selectChannelID 调用选择器选出通道 ID
+//
+// 选择器实现 selector.RequestAwareSelector 时传入请求上下文，否则使用普通选择。
+func (r *Routing) selectChannelID(channelInfos []selector.ChannelInfo, options *selectOptions) (string, error) {
+	if aware, ok := r.selector.(selector.RequestAwareSelector); ok {
+		return aware.SelectForRequest(options.selectorRequest(), channelInfos)
+	}
+	return r.selector.Select(channelInfos)
+}
+
 // buildChannelsForModelWithEndpoint 为指定模型构建所有可能的通道
 // 从 ModelWithEndpoint 中获取所有需要的信息
 func (r *Routing) buildChannelsForModelWithEndpoint(mwe ModelWithEndpoint) []*Channel {
@@ -207,22 +612,41 @@ func (r *Routing) buildChannelsForModelWithEndpoint(mwe ModelWithEndpoint) []*Ch
 
 	// 合并 CustomHeaders（Platform 级别 + Endpoint 级别，Endpoint 覆盖同名）
 	customHeaders := mergeCustomHeaders(platform.CustomHeaders, endpoint.CustomHeaders)
+	labels := mergeLabels(platform.Labels, endpoint.Labels)
+	pricing := effectivePricing(model.Pricing, endpoint.Pricing)
 
 	// 为每个 APIKey 创建一个 Channel
 	var channels []*Channel
 	for _, key := range model.APIKeys {
 		channel := &Channel{
-			PlatformID:        platform.ID,
-			ModelID:           model.ID,
-			APIKeyID:          key.ID,
-			Provider:          endpoint.EndpointType, // 从 Endpoint 获取
-			BaseURL:           platform.BaseURL,      // 从 Platform 获取
-			ModelName:         model.Name,
-			APIKey:            key.Value,
-			APIVariant:        endpoint.EndpointVariant, // 从 Endpoint 获取
-			APIEndpointConfig: endpoint.Path,            // 从 Endpoint 获取
-			CustomHeaders:     customHeaders,
-			healthService:     r.healthService,
+			PlatformID:           platform.ID,
+			ModelID:              model.ID,
+			APIKeyID:             key.ID,
+			Provider:             endpoint.EndpointType, // 从 Endpoint 获取
+			BaseURL:              platform.BaseURL,      // 从 Platform 获取
+			ModelName:            model.Name,
+			APIKey:               key.Value,
+			APIVariant:           endpoint.EndpointVariant, // 从 Endpoint 获取
+			APIEndpointConfig:    endpoint.Path,            // 从 Endpoint 获取
+			CustomHeaders:        customHeaders,
+			Labels:               labels,
+			Pricing:              pricing,
+			endpointID:           endpoint.ID,
+			modelCapabilities:    model.Capabilities,
+			endpointCapabilities: endpoint.Capabilities,
+			maxContextTokens:     model.MaxContextTokens,
+			maxOutputTokens:      model.MaxOutputTokens,
+			platformWeight:       platform.Weight,
+			modelWeight:          model.Weight,
+			keyWeight:            key.Weight,
+			priority:             platform.Priority,
+			healthService:        r.healthService,
+			latency:              r.latency,
+			inflight:             r.inflight,
+			platformLimit:        platform.RateLimit,
+			keyLimit:             key.RateLimit,
+			limiter:              r.limiter,
+			probes:               r.probes,
 		}
 		channels = append(channels, channel)
 	}
@@ -245,3 +669,13 @@ func mergeCustomHeaders(platform, endpoint map[string]string) map[string]string
 	}
 	return result
 }
+
+// InFlight 返回指定资源当前的在途请求数
+func (r *Routing) InFlight(resourceType health.ResourceType, resourceID uint) int64 {
+	return r.inflight.count(resourceType, resourceID)
+}
+
+// InFlightStats 返回所有平台/模型/密钥在途请求数的快照
+func (r *Routing) InFlightStats() InFlightStats {
+	return r.inflight.snapshot()
+}
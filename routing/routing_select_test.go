@@ -0,0 +1,402 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+type testPlatformRepo struct{}
+
+func (testPlatformRepo) GetPlatformByID(ctx context.Context, id uint) (*Platform, error) {
+	return nil, nil
+}
+
+type testKeyRepo struct{}
+
+func (testKeyRepo) GetAllAPIKeysByPlatformID(ctx context.Context, platformID uint) ([]*APIKey, error) {
+	return nil, nil
+}
+
+type testModelRepo struct {
+	models []ModelWithEndpoint
+}
+
+func (r *testModelRepo) FindModelsWithDefaultEndpoint(ctx context.Context, name string) ([]ModelWithEndpoint, error) {
+	var result []ModelWithEndpoint
+	for _, mwe := range r.models {
+		if mwe.Model.Name == name {
+			result = append(result, mwe)
+		}
+	}
+	return result, nil
+}
+
+func (r *testModelRepo) FindModelsWithEndpoint(ctx context.Context, name, endpointType, endpointVariant string) ([]ModelWithEndpoint, error) {
+	var result []ModelWithEndpoint
+	for _, mwe := range r.models {
+		if mwe.Model.Name == name && mwe.Endpoint.EndpointType == endpointType && mwe.Endpoint.EndpointVariant == endpointVariant {
+			result = append(result, mwe)
+		}
+	}
+	return result, nil
+}
+
+func (r *testModelRepo) FindModelsByAlias(ctx context.Context, alias string) ([]Model, error) {
+	var result []Model
+	for _, mwe := range r.models {
+		if mwe.Model.Alias == alias {
+			result = append(result, mwe.Model)
+		}
+	}
+	return result, nil
+}
+
+// recordingSelector 记录最近一次传入的候选通道，并委托给内部选择器
+type recordingSelector struct {
+	inner selector.Selector
+	last  []selector.ChannelInfo
+}
+
+func (s *recordingSelector) Select(channels []selector.ChannelInfo) (string, error) {
+	s.last = append([]selector.ChannelInfo(nil), channels...)
+	return s.inner.Select(channels)
+}
+
+func (s *recordingSelector) Name() string { return "Recording" }
+
+// markAvailable 将资源写入为已知可用状态，避免未知通道直接返回绕过选择器
+func markAvailable(storage *testChannelStorage, resourceType health.ResourceType, ids ...uint) {
+	now := time.Now()
+	for _, id := range ids {
+		_ = storage.Set(&health.Health{
+			ResourceType: resourceType,
+			ResourceID:   id,
+			Status:       health.HealthStatusAvailable,
+			LastCheckAt:  now,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+}
+
+func newTestRouting(t *testing.T, sel selector.Selector, models []ModelWithEndpoint) (*Routing, *testChannelStorage) {
+	t.Helper()
+
+	storage := newTestChannelStorage()
+	r, err := New(context.Background(), Config{
+		Selector:      sel,
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     &testModelRepo{models: models},
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: storage,
+	})
+	if err != nil {
+		t.Fatalf("创建路由失败: %v", err)
+	}
+	return r, storage
+}
+
+func TestGetChannel_WeightsThreadedIntoChannelInfo(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1, Weight: 3},
+			Model:    Model{ID: 10, Name: "gpt-4o", Weight: 2, APIKeys: []APIKey{{ID: 100, Weight: 4}, {ID: 101}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	sel := &recordingSelector{inner: selector.NewWeightedSelector()}
+	r, storage := newTestRouting(t, sel, models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 101)
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.APIKeyID != 100 {
+		t.Fatalf("高权重密钥应被优先选择，actual=%d", ch.APIKeyID)
+	}
+
+	if len(sel.last) != 2 {
+		t.Fatalf("候选通道数量期望 2，actual=%d", len(sel.last))
+	}
+	first := sel.last[0]
+	if first.PlatformWeight != 3 || first.ModelWeight != 2 || first.KeyWeight != 4 {
+		t.Fatalf("权重未正确传递，actual=%+v", first)
+	}
+	if got := sel.last[1].EffectiveWeight(); got != 6 {
+		t.Fatalf("未配置密钥权重时综合权重期望 6，actual=%d", got)
+	}
+}
+
+func TestGetChannel_PriorityTiers(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 2, Priority: 1},
+			Model:    Model{ID: 20, Name: "claude-sonnet", APIKeys: []APIKey{{ID: 200}}},
+			Endpoint: Endpoint{EndpointType: "anthropic", EndpointVariant: "messages"},
+		},
+		{
+			Platform: Platform{ID: 1, Priority: 0},
+			Model:    Model{ID: 10, Name: "claude-sonnet", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{EndpointType: "anthropic", EndpointVariant: "messages"},
+		},
+		{
+			Platform: Platform{ID: 3, Priority: 2},
+			Model:    Model{ID: 30, Name: "claude-sonnet", APIKeys: []APIKey{{ID: 300}}},
+			Endpoint: Endpoint{EndpointType: "anthropic", EndpointVariant: "messages"},
+		},
+	}
+
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+	// 第三层级保持未知状态，不应抢占更高层级的可用通道
+
+	ch, err := r.GetChannel(context.Background(), "claude-sonnet")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.PlatformID != 1 {
+		t.Fatalf("主层级健康时应选择主层级平台，actual=%d", ch.PlatformID)
+	}
+
+	// 主层级进入退避后应降级到第二层级
+	ch.MarkFailure(context.Background(), nil)
+	ch, err = r.GetChannel(context.Background(), "claude-sonnet")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.PlatformID != 2 {
+		t.Fatalf("主层级退避后应选择第二层级平台，actual=%d", ch.PlatformID)
+	}
+
+	// 第二层级也进入退避后应降级到第三层级
+	ch.MarkFailure(context.Background(), nil)
+	ch, err = r.GetChannel(context.Background(), "claude-sonnet")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if ch.PlatformID != 3 {
+		t.Fatalf("前两个层级退避后应选择第三层级平台，actual=%d", ch.PlatformID)
+	}
+}
+
+func TestGetChannel_AffinityKeyPassedToRequestAwareSelector(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}, {ID: 102}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	r, storage := newTestRouting(t, selector.NewConsistentHashSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 101, 102)
+
+	first, err := r.GetChannel(context.Background(), "gpt-4o", WithAffinityKey("conversation-1"))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	first.Release()
+
+	for i := 0; i < 5; i++ {
+		ch, err := r.GetChannel(context.Background(), "gpt-4o", WithAffinityKey("conversation-1"))
+		if err != nil {
+			t.Fatalf("获取通道失败: %v", err)
+		}
+		if ch.APIKeyID != first.APIKeyID {
+			t.Fatalf("相同亲和键应路由到同一通道，expected=%d actual=%d", first.APIKeyID, ch.APIKeyID)
+		}
+		ch.Release()
+	}
+}
+
+func TestGetChannel_AffinityKeyHashesUnknownChannels(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}, {ID: 102}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	// 密钥健康状态均未知
+	r, storage := newTestRouting(t, selector.NewConsistentHashSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+
+	picked := make(map[uint]bool)
+	for i := 0; i < 20; i++ {
+		key := WithAffinityKey(fmt.Sprintf("conversation-%d", i))
+		first, err := r.GetChannel(context.Background(), "gpt-4o", key)
+		if err != nil {
+			t.Fatalf("获取通道失败: %v", err)
+		}
+		first.Release()
+		again, err := r.GetChannel(context.Background(), "gpt-4o", key)
+		if err != nil {
+			t.Fatalf("获取通道失败: %v", err)
+		}
+		again.Release()
+		if again.APIKeyID != first.APIKeyID {
+			t.Fatalf("相同亲和键应路由到同一未知通道，expected=%d actual=%d", first.APIKeyID, again.APIKeyID)
+		}
+		picked[first.APIKeyID] = true
+	}
+	if len(picked) < 2 {
+		t.Fatalf("未知通道应按亲和键哈希分布，而不是总选中首个未知通道，actual=%v", picked)
+	}
+}
+
+func TestGetChannel_ExcludedChannelsSkippedEvenWhenUnknown(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	// 健康状态全部未知：未知通道原本会被直接返回
+	r, _ := newTestRouting(t, selector.NewLRUSelector(), models)
+
+	first, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	second, err := r.GetChannel(context.Background(), "gpt-4o", WithExcludedChannels(first.ID()))
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	if second.ID() == first.ID() {
+		t.Fatalf("已排除的通道不应被再次选中，actual=%s", second.ID())
+	}
+
+	_, err = r.GetChannel(context.Background(), "gpt-4o", WithExcludedChannels(first.ID(), second.ID()))
+	if !errors.IsCode(err, errors.ErrCodeResourceExhausted) {
+		t.Fatalf("全部通道被排除时应返回资源耗尽错误，actual=%v", err)
+	}
+
+	reused, err := r.GetChannel(context.Background(), "gpt-4o",
+		WithExcludedChannels(first.ID(), second.ID()), WithExcludedChannelReuse())
+	if err != nil {
+		t.Fatalf("允许复用时应回到已排除的通道: %v", err)
+	}
+	if reused.ID() != first.ID() && reused.ID() != second.ID() {
+		t.Fatalf("复用的通道应来自已排除集合，actual=%s", reused.ID())
+	}
+
+	reused, err = r.GetChannel(context.Background(), "gpt-4o",
+		WithExcludedChannels(first.ID()), WithExcludedAPIKeys(second.APIKeyID), WithExcludedChannelReuse())
+	if err != nil || reused.ID() != first.ID() {
+		t.Fatalf("复用时应只放宽通道排除，密钥排除仍然生效，actual=%v err=%v", reused, err)
+	}
+}
+
+func TestNew_SelectorTypeResolvedFromRegistry(t *testing.T) {
+	base := Config{
+		PlatformRepo:  testPlatformRepo{},
+		ModelRepo:     &testModelRepo{},
+		KeyRepo:       testKeyRepo{},
+		HealthStorage: newTestChannelStorage(),
+	}
+
+	cfg := base
+	cfg.SelectorType = selector.WeightedSelector
+	r, err := New(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("按已注册类型创建选择器失败: %v", err)
+	}
+	if r.selector.Name() != selector.NewWeightedSelector().Name() {
+		t.Fatalf("选择器类型不符合预期，actual=%s", r.selector.Name())
+	}
+
+	cfg.SelectorType = "unknown"
+	if _, err := New(context.Background(), cfg); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("未注册的选择器类型应校验失败，actual=%v", err)
+	}
+
+	cfg = base
+	cfg.Selector = selector.NewLRUSelector()
+	cfg.Health = HealthConfig{FailureThreshold: -1}
+	if _, err := New(context.Background(), cfg); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("非法的健康配置应校验失败，actual=%v", err)
+	}
+}
+
+func TestSetSelector_TakesEffectAtRuntime(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 101)
+
+	sel := &recordingSelector{inner: selector.NewRandomSelector()}
+	if err := r.SetSelector(sel, ""); err != nil {
+		t.Fatalf("替换选择器失败: %v", err)
+	}
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil {
+		t.Fatalf("获取通道失败: %v", err)
+	}
+	ch.Release()
+	if len(sel.last) != 2 {
+		t.Fatalf("替换后的选择器应参与选择，candidates=%d", len(sel.last))
+	}
+
+	if err := r.SetSelector(nil, "unknown"); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+		t.Fatalf("未注册的选择器类型应校验失败，actual=%v", err)
+	}
+}
+
+func TestGetChannel_HalfOpenAdmitsLimitedTrials(t *testing.T) {
+	models := []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}, {ID: 101}}},
+			Endpoint: Endpoint{EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), models)
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10)
+	past, future := time.Now().Add(-time.Second), time.Now().Add(time.Minute)
+	_ = storage.Set(&health.Health{ResourceType: health.ResourceTypeAPIKey, ResourceID: 100, Status: health.HealthStatusWarning, NextAvailableAt: &past})
+	_ = storage.Set(&health.Health{ResourceType: health.ResourceTypeAPIKey, ResourceID: 101, Status: health.HealthStatusWarning, NextAvailableAt: &future})
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil || ch.APIKeyID != 100 {
+		t.Fatalf("半开通道应放行试探请求，channel=%v err=%v", ch, err)
+	}
+
+	_, err = r.GetChannel(context.Background(), "gpt-4o")
+	if !errors.IsCode(err, errors.ErrCodeCircuitBreakerOpen) || errors.GetHTTPStatus(err) != 503 {
+		t.Fatalf("试探名额已满且其他通道熔断时应返回 CircuitBreakerOpen(503)，actual=%v", err)
+	}
+
+	ch.Release()
+	next, err := r.GetChannel(context.Background(), "gpt-4o")
+	if err != nil || next.APIKeyID != 100 {
+		t.Fatalf("归还试探名额后应再次放行，channel=%v err=%v", next, err)
+	}
+	next.Release()
+}
@@ -0,0 +1,115 @@
+package selector
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func init() {
+	Register(ConsistentHashSelector, NewConsistentHashSelector)
+}
+
+// consistentHashSelector 实现基于会话亲和键的加权 Rendezvous（HRW）哈希调度策略
+//
+// 对每个候选通道计算 score = -weight / ln(hash(affinityKey, channelID))，选择得分最高的通道。
+// 相同亲和键在候选集合不变时总是落在同一通道；当某个通道因健康状态退出候选集合时，
+// 只有原本映射到该通道的亲和键会被重新分配，其余亲和键保持不变。
+//
+// 未提供亲和键的请求回退到 fallback 选择器。
+type consistentHashSelector struct {
+	fallback Selector
+}
+
+// NewConsistentHashSelector 创建一个新的一致性哈希选择器实例
+//
+// 未携带亲和键的请求使用多维 LRU 选择器处理。
+func NewConsistentHashSelector() Selector {
+	return NewConsistentHashSelectorWithFallback(NewLRUSelector())
+}
+
+// NewConsistentHashSelectorWithFallback 创建一个指定回退选择器的一致性哈希选择器实例
+func NewConsistentHashSelectorWithFallback(fallback Selector) Selector {
+	if fallback == nil {
+		fallback = NewLRUSelector()
+	}
+	return &consistentHashSelector{fallback: fallback}
+}
+
+// Select 在没有请求上下文时回退到 fallback 选择器
+func (s *consistentHashSelector) Select(channels []ChannelInfo) (string, error) {
+	return s.fallback.Select(channels)
+}
+
+// SelectForRequest 根据亲和键选择通道
+//
+// 平局处理：得分相同时选择 ID 较小的通道。
+func (s *consistentHashSelector) SelectForRequest(req Request, channels []ChannelInfo) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New(errors.ErrCodeInvalidArgument, "通道列表不能为空")
+	}
+	if req.AffinityKey == "" {
+		return s.fallback.Select(channels)
+	}
+
+	bestID := ""
+	bestScore := math.Inf(-1)
+	for _, ch := range channels {
+		score := rendezvousScore(req.AffinityKey, ch.ID, ch.EffectiveWeight())
+		if score > bestScore || (score == bestScore && ch.ID < bestID) {
+			bestID = ch.ID
+			bestScore = score
+		}
+	}
+	return bestID, nil
+}
+
+// Name 返回选择器的名称
+func (s *consistentHashSelector) Name() string {
+	return "ConsistentHash"
+}
+
+// Score 返回各通道的 Rendezvous 哈希得分
+//
+// 未携带亲和键时由回退选择器解释；回退选择器未实现 Scorer 时不返回得分与选中通道。
+func (s *consistentHashSelector) Score(req Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+	if req.AffinityKey == "" {
+		if scorer, ok := s.fallback.(Scorer); ok {
+			return scorer.Score(req, channels)
+		}
+		return nil, ""
+	}
+
+	scores := make([]float64, len(channels))
+	for i, ch := range channels {
+		scores[i] = rendezvousScore(req.AffinityKey, ch.ID, ch.EffectiveWeight())
+	}
+	winner, _ := s.SelectForRequest(req, channels)
+	return scores, winner
+}
+
+// rendezvousScore 计算加权 Rendezvous 哈希得分
+func rendezvousScore(key, channelID string, weight int) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(channelID))
+
+	// 将哈希值映射到 (0, 1) 开区间
+	u := (float64(mix64(h.Sum64())>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(u)
+}
+
+// mix64 对哈希值做 SplitMix64 终结混合，改善 FNV 低位分布
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,73 @@
+package selector
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConsistentHashSelector_StableForSameKey(t *testing.T) {
+	s := NewConsistentHashSelector().(RequestAwareSelector)
+	channels := []ChannelInfo{{ID: "1-1-1"}, {ID: "1-1-2"}, {ID: "2-2-3"}, {ID: "3-3-4"}}
+
+	first, err := s.SelectForRequest(Request{AffinityKey: "conversation-42"}, channels)
+	if err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		got, err := s.SelectForRequest(Request{AffinityKey: "conversation-42"}, channels)
+		if err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+		if got != first {
+			t.Fatalf("相同亲和键应稳定落在同一通道，expected=%q actual=%q", first, got)
+		}
+	}
+}
+
+func TestConsistentHashSelector_MinimalRemapOnChannelRemoval(t *testing.T) {
+	s := NewConsistentHashSelector().(RequestAwareSelector)
+	channels := []ChannelInfo{{ID: "1-1-1"}, {ID: "1-1-2"}, {ID: "2-2-3"}, {ID: "3-3-4"}}
+	removed := "2-2-3"
+	var remaining []ChannelInfo
+	for _, ch := range channels {
+		if ch.ID != removed {
+			remaining = append(remaining, ch)
+		}
+	}
+
+	assigned := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		before, _ := s.SelectForRequest(Request{AffinityKey: key}, channels)
+		after, _ := s.SelectForRequest(Request{AffinityKey: key}, remaining)
+		assigned[before]++
+
+		if before != removed && before != after {
+			t.Fatalf("未受影响的亲和键不应被重新映射，key=%s before=%s after=%s", key, before, after)
+		}
+	}
+
+	// 四个通道等权时每个通道应分到大致四分之一的亲和键
+	for _, ch := range channels {
+		if assigned[ch.ID] < 180 || assigned[ch.ID] > 320 {
+			t.Fatalf("亲和键分布不均，assigned=%v", assigned)
+		}
+	}
+}
+
+func TestConsistentHashSelector_FallbackWithoutKey(t *testing.T) {
+	s := NewConsistentHashSelectorWithFallback(&fixedSelector{id: "fallback"}).(RequestAwareSelector)
+	got, err := s.SelectForRequest(Request{}, []ChannelInfo{{ID: "a"}, {ID: "fallback"}})
+	if err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if got != "fallback" {
+		t.Fatalf("无亲和键时应使用回退选择器，actual=%q", got)
+	}
+}
+
+type fixedSelector struct{ id string }
+
+func (s *fixedSelector) Select(channels []ChannelInfo) (string, error) { return s.id, nil }
+
+func (s *fixedSelector) Name() string { return "Fixed" }
@@ -0,0 +1,144 @@
+package selector
+
+import (
+	"math"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// DefaultCostLatencyTolerance 成本选择器默认的延迟容忍度（允许比最快通道慢 50%）
+const DefaultCostLatencyTolerance = 0.5
+
+func init() {
+	Register(CostSelector, NewCostSelector)
+}
+
+// costSelector 实现成本感知调度策略
+//
+// 先按延迟代价（见 latencyCost）筛出容忍范围内的通道：代价不超过最快通道的 (1 + tolerance) 倍，
+// 尚无延迟样本的通道视为在范围内。失败会以惩罚耗时计入延迟 EWMA，因此错误率高的通道同样会被筛除。
+// 再在范围内选择预估成本最低的通道；未配置价格的通道排在已定价通道之后。
+//
+// 比较顺序：预估成本 -> 延迟代价 -> LastTryKey（更早优先）-> stable ID
+type costSelector struct {
+	tolerance float64
+}
+
+// NewCostSelector 创建一个使用默认延迟容忍度的成本选择器实例
+func NewCostSelector() Selector {
+	return NewCostSelectorWithTolerance(DefaultCostLatencyTolerance)
+}
+
+// NewCostSelectorWithTolerance 创建一个指定延迟容忍度的成本选择器实例
+//
+// tolerance 为允许比最快通道多出的延迟比例（如 0.2 表示慢 20% 以内），< 0 时按 0 处理。
+func NewCostSelectorWithTolerance(tolerance float64) Selector {
+	return &costSelector{tolerance: max(tolerance, 0)}
+}
+
+// Select 在没有请求上下文时按输入与输出单价之和比较成本
+func (s *costSelector) Select(channels []ChannelInfo) (string, error) {
+	return s.SelectForRequest(Request{}, channels)
+}
+
+// SelectForRequest 结合请求的预估 Token 数选择成本最低的通道
+func (s *costSelector) SelectForRequest(req Request, channels []ChannelInfo) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New(errors.ErrCodeInvalidArgument, "通道列表不能为空")
+	}
+
+	eligible := s.eligible(channels)
+	best := -1
+	for i, ch := range channels {
+		if !eligible[i] {
+			continue
+		}
+		if best == -1 || lessCost(req, ch, channels[best]) {
+			best = i
+		}
+	}
+	return channels[best].ID, nil
+}
+
+// Name 返回选择器的名称
+func (s *costSelector) Name() string {
+	return "LowestCost"
+}
+
+// Score 返回各通道的排名得分（容忍范围内最优通道为范围内通道数，范围外通道为 0），选择器无内部状态
+func (s *costSelector) Score(req Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+
+	eligible := s.eligible(channels)
+	count := 0
+	for _, ok := range eligible {
+		if ok {
+			count++
+		}
+	}
+
+	scores := make([]float64, len(channels))
+	for i, a := range channels {
+		if !eligible[i] {
+			continue
+		}
+		better := 0
+		for j, b := range channels {
+			if i != j && eligible[j] && lessCost(req, b, a) {
+				better++
+			}
+		}
+		scores[i] = float64(count - better)
+	}
+	winner, _ := s.SelectForRequest(req, channels)
+	return scores, winner
+}
+
+// eligible 标记延迟代价在容忍范围内的通道
+func (s *costSelector) eligible(channels []ChannelInfo) []bool {
+	fastest := time.Duration(0)
+	for _, ch := range channels {
+		if cost := latencyCost(ch); cost > 0 && (fastest == 0 || cost < fastest) {
+			fastest = cost
+		}
+	}
+
+	limit := time.Duration(float64(fastest) * (1 + s.tolerance))
+	result := make([]bool, len(channels))
+	for i, ch := range channels {
+		cost := latencyCost(ch)
+		result[i] = cost == 0 || cost <= limit
+	}
+	return result
+}
+
+// lessCost 判断通道 a 是否优于通道 b
+func lessCost(req Request, a, b ChannelInfo) bool {
+	if costA, costB := expectedCost(req, a), expectedCost(req, b); costA != costB {
+		return costA < costB
+	}
+	if latencyA, latencyB := latencyCost(a), latencyCost(b); latencyA != latencyB {
+		return latencyA < latencyB
+	}
+	if !a.LastTryKey.Equal(b.LastTryKey) {
+		return a.LastTryKey.Before(b.LastTryKey)
+	}
+	return a.ID < b.ID
+}
+
+// expectedCost 计算通道处理请求的预估成本，未配置价格的通道为 +Inf
+//
+// 请求未提供 Token 数时按输入与输出单价之和比较。
+func expectedCost(req Request, ch ChannelInfo) float64 {
+	if !ch.Priced {
+		return math.Inf(1)
+	}
+	promptTokens, outputTokens := req.PromptTokens, req.OutputTokens
+	if promptTokens <= 0 && outputTokens <= 0 {
+		promptTokens, outputTokens = 1, 1
+	}
+	return float64(promptTokens)*ch.InputPrice + float64(outputTokens)*ch.OutputPrice
+}
@@ -0,0 +1,46 @@
+package selector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCostSelector_PrefersCheapestWithinTolerance(t *testing.T) {
+	s := NewCostSelectorWithTolerance(0.5)
+	channels := []ChannelInfo{
+		{ID: "fast-expensive", Priced: true, InputPrice: 10, OutputPrice: 30, LatencyEWMA: 100 * time.Millisecond},
+		{ID: "ok-cheap", Priced: true, InputPrice: 2, OutputPrice: 6, LatencyEWMA: 140 * time.Millisecond},
+		{ID: "slow-cheapest", Priced: true, InputPrice: 1, OutputPrice: 3, LatencyEWMA: 400 * time.Millisecond},
+		{ID: "unpriced", LatencyEWMA: 100 * time.Millisecond},
+	}
+
+	got, err := s.Select(channels)
+	if err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if got != "ok-cheap" {
+		t.Fatalf("应在延迟容忍范围内选择最便宜的通道，actual=%q", got)
+	}
+
+	scores, winner := s.(Scorer).Score(Request{}, channels)
+	if winner != "ok-cheap" || scores[2] != 0 {
+		t.Fatalf("超出延迟容忍范围的通道得分应为 0，scores=%v winner=%q", scores, winner)
+	}
+}
+
+func TestCostSelector_UsesRequestTokens(t *testing.T) {
+	s := NewCostSelector().(RequestAwareSelector)
+	channels := []ChannelInfo{
+		{ID: "cheap-input", Priced: true, InputPrice: 1, OutputPrice: 20},
+		{ID: "cheap-output", Priced: true, InputPrice: 5, OutputPrice: 5},
+	}
+
+	got, _ := s.SelectForRequest(Request{PromptTokens: 10000, OutputTokens: 100}, channels)
+	if got != "cheap-input" {
+		t.Fatalf("长提示词请求应选择输入单价低的通道，actual=%q", got)
+	}
+	got, _ = s.SelectForRequest(Request{PromptTokens: 100, OutputTokens: 4000}, channels)
+	if got != "cheap-output" {
+		t.Fatalf("长输出请求应选择输出单价低的通道，actual=%q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package selector
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func init() {
+	Register(EWMASelector, NewEWMASelector)
+}
+
+// ewmaSelector 实现基于峰值 EWMA 延迟的二选一（Power of Two Choices）调度策略
+//
+// 每次从候选通道中随机抽取两个，选择延迟代价较低的一个。
+// 相比总是选择全局最快的通道，该策略可以避免所有流量涌向同一通道，
+// 同时仍能让低延迟通道获得明显更多的流量。
+//
+// 延迟代价 = (总耗时 EWMA + 首字耗时 EWMA) × (密钥在途请求数 + 1)，
+// 尚无样本的通道代价为 0，会被优先探索。
+type ewmaSelector struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewEWMASelector 创建一个新的峰值 EWMA 选择器实例
+func NewEWMASelector() Selector {
+	return &ewmaSelector{
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Select 从给定的通道列表中选择延迟代价较低的通道
+//
+// 平局处理：代价相同时选择 ID 较小的通道。
+func (s *ewmaSelector) Select(channels []ChannelInfo) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New(errors.ErrCodeInvalidArgument, "通道列表不能为空")
+	}
+	if len(channels) == 1 {
+		return channels[0].ID, nil
+	}
+
+	// 随机抽取两个不同的候选通道
+	s.mu.Lock()
+	i := s.rng.Intn(len(channels))
+	j := s.rng.Intn(len(channels) - 1)
+	s.mu.Unlock()
+	if j >= i {
+		j++
+	}
+
+	a, b := channels[i], channels[j]
+	costA, costB := latencyCost(a), latencyCost(b)
+	if costB < costA || (costB == costA && b.ID < a.ID) {
+		return b.ID, nil
+	}
+	return a.ID, nil
+}
+
+// Name 返回选择器的名称
+func (s *ewmaSelector) Name() string {
+	return "PeakEWMA"
+}
+
+// Score 返回各通道在二选一抽样下被选中的概率，以及概率最高的通道
+//
+// 每个有序候选对被抽中的概率相同，通道被选中的概率 = 其胜过的通道数 × 2 / (n × (n - 1))。
+func (s *ewmaSelector) Score(_ Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+	if len(channels) == 1 {
+		return []float64{1}, channels[0].ID
+	}
+
+	n := float64(len(channels))
+	scores := make([]float64, len(channels))
+	bestID := ""
+	bestScore := -1.0
+	for i, a := range channels {
+		wins := 0
+		costA := latencyCost(a)
+		for j, b := range channels {
+			if i == j {
+				continue
+			}
+			if costB := latencyCost(b); costA < costB || (costA == costB && a.ID < b.ID) {
+				wins++
+			}
+		}
+		scores[i] = float64(wins) * 2 / (n * (n - 1))
+		if scores[i] > bestScore || (scores[i] == bestScore && a.ID < bestID) {
+			bestID = a.ID
+			bestScore = scores[i]
+		}
+	}
+	return scores, bestID
+}
+
+// latencyCost 计算通道的延迟代价
+func latencyCost(ch ChannelInfo) time.Duration {
+	return (ch.LatencyEWMA + ch.FirstByteEWMA) * time.Duration(ch.InFlightKey+1)
+}
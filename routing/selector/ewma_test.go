@@ -0,0 +1,67 @@
+package selector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEWMASelector_PrefersLowerLatency(t *testing.T) {
+	s := NewEWMASelector()
+	channels := []ChannelInfo{
+		{ID: "slow", LatencyEWMA: 3 * time.Second, FirstByteEWMA: 800 * time.Millisecond},
+		{ID: "fast", LatencyEWMA: 1 * time.Second, FirstByteEWMA: 200 * time.Millisecond},
+	}
+
+	// 两个候选时二选一总会比较全部通道，结果确定
+	for i := 0; i < 10; i++ {
+		got, err := s.Select(channels)
+		if err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+		if got != "fast" {
+			t.Fatalf("应选择低延迟通道，actual=%q", got)
+		}
+	}
+}
+
+func TestEWMASelector_ExploresUnobservedChannel(t *testing.T) {
+	s := NewEWMASelector()
+	channels := []ChannelInfo{
+		{ID: "observed", LatencyEWMA: 500 * time.Millisecond},
+		{ID: "fresh"},
+	}
+
+	got, err := s.Select(channels)
+	if err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if got != "fresh" {
+		t.Fatalf("尚无样本的通道应被优先探索，actual=%q", got)
+	}
+}
+
+func TestEWMASelector_SkewsTrafficTowardsFastChannels(t *testing.T) {
+	s := NewEWMASelector()
+	channels := []ChannelInfo{
+		{ID: "a", LatencyEWMA: 100 * time.Millisecond},
+		{ID: "b", LatencyEWMA: 200 * time.Millisecond},
+		{ID: "c", LatencyEWMA: 900 * time.Millisecond},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		id, err := s.Select(channels)
+		if err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+		counts[id]++
+	}
+
+	// 二选一下最慢的通道永远不会胜出
+	if counts["c"] != 0 {
+		t.Fatalf("最慢通道不应被选择，actual=%d", counts["c"])
+	}
+	if counts["a"] <= counts["b"] {
+		t.Fatalf("最快通道应获得最多流量，counts=%v", counts)
+	}
+}
@@ -1,6 +1,7 @@
 package selector
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/MeowSalty/portal/errors"
@@ -50,6 +51,21 @@ func Create(selectorType SelectorType) (Selector, error) {
 	return factory(), nil
 }
 
+// Registered 返回所有已注册的选择器类型（按名称排序）
+//
+// 此函数是并发安全的。
+func Registered() []SelectorType {
+	mu.RLock()
+	types := make([]SelectorType, 0, len(factories))
+	for selectorType := range factories {
+		types = append(types, selectorType)
+	}
+	mu.RUnlock()
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
 // IsRegistered 检查指定的选择器类型是否已注册
 //
 // 此函数是并发安全的。
@@ -0,0 +1,79 @@
+package selector
+
+import (
+	"github.com/MeowSalty/portal/errors"
+)
+
+func init() {
+	Register(LeastInFlightSelector, NewLeastInFlightSelector)
+}
+
+// leastInFlightSelector 实现最少在途请求（Least Outstanding Requests）调度策略
+//
+// 供应商的并发上限通常作用于密钥与平台，因此优先比较密钥在途数，
+// 其次比较平台和模型在途数；在途数完全相同时退化为密钥维度的 LRU。
+type leastInFlightSelector struct{}
+
+// NewLeastInFlightSelector 创建一个新的最少在途请求选择器实例
+func NewLeastInFlightSelector() Selector {
+	return &leastInFlightSelector{}
+}
+
+// Select 从给定的通道列表中选择在途请求最少的通道
+//
+// 比较顺序：InFlightKey -> InFlightPlatform -> InFlightModel -> LastTryKey（更早优先）-> stable ID
+func (s *leastInFlightSelector) Select(channels []ChannelInfo) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New(errors.ErrCodeInvalidArgument, "通道列表不能为空")
+	}
+
+	best := channels[0]
+	for _, ch := range channels[1:] {
+		if lessInFlight(ch, best) {
+			best = ch
+		}
+	}
+	return best.ID, nil
+}
+
+// Name 返回选择器的名称
+func (s *leastInFlightSelector) Name() string {
+	return "LeastInFlight"
+}
+
+// Score 返回各通道按比较顺序的排名得分（最优通道为 n，最差为 1），选择器无内部状态
+func (s *leastInFlightSelector) Score(_ Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+
+	scores := make([]float64, len(channels))
+	for i, a := range channels {
+		better := 0
+		for j, b := range channels {
+			if i != j && lessInFlight(b, a) {
+				better++
+			}
+		}
+		scores[i] = float64(len(channels) - better)
+	}
+	winner, _ := s.Select(channels)
+	return scores, winner
+}
+
+// lessInFlight 判断通道 a 是否优于通道 b
+func lessInFlight(a, b ChannelInfo) bool {
+	if a.InFlightKey != b.InFlightKey {
+		return a.InFlightKey < b.InFlightKey
+	}
+	if a.InFlightPlatform != b.InFlightPlatform {
+		return a.InFlightPlatform < b.InFlightPlatform
+	}
+	if a.InFlightModel != b.InFlightModel {
+		return a.InFlightModel < b.InFlightModel
+	}
+	if !a.LastTryKey.Equal(b.LastTryKey) {
+		return a.LastTryKey.Before(b.LastTryKey)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,40 @@
+package selector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeastInFlightSelector_PrefersFewestKeyRequests(t *testing.T) {
+	s := NewLeastInFlightSelector()
+	channels := []ChannelInfo{
+		{ID: "busy", InFlightKey: 3},
+		{ID: "idle-busy-platform", InFlightKey: 1, InFlightPlatform: 5},
+		{ID: "idle", InFlightKey: 1, InFlightPlatform: 2},
+	}
+
+	got, err := s.Select(channels)
+	if err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if got != "idle" {
+		t.Fatalf("应选择密钥与平台在途数最少的通道，actual=%q", got)
+	}
+}
+
+func TestLeastInFlightSelector_TieBreaksByLastTry(t *testing.T) {
+	s := NewLeastInFlightSelector()
+	now := time.Now()
+	channels := []ChannelInfo{
+		{ID: "a", LastTryKey: now},
+		{ID: "b", LastTryKey: now.Add(-time.Minute)},
+	}
+
+	got, err := s.Select(channels)
+	if err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if got != "b" {
+		t.Fatalf("在途数相同时应选择最久未尝试的通道，actual=%q", got)
+	}
+}
@@ -56,9 +56,7 @@ func (s *lruSelector) Select(channels []ChannelInfo) (string, error) {
 		ageModel := now.Sub(ch.LastTryModel)
 		ageKey := now.Sub(ch.LastTryKey)
 
-		score := s.platformWeight*agePlatform.Seconds() +
-			s.modelWeight*ageModel.Seconds() +
-			s.keyWeight*ageKey.Seconds()
+		score := s.score(agePlatform, ageModel, ageKey)
 
 		if score > bestScore {
 			bestIndex = i
@@ -110,3 +108,25 @@ func (s *lruSelector) Select(channels []ChannelInfo) (string, error) {
 func (s *lruSelector) Name() string {
 	return "LRU"
 }
+
+// Score 返回各通道的 LRU 分数，选择器无内部状态，选中结果与 Select 一致
+func (s *lruSelector) Score(_ Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+
+	now := time.Now()
+	scores := make([]float64, len(channels))
+	for i, ch := range channels {
+		scores[i] = s.score(now.Sub(ch.LastTryPlatform), now.Sub(ch.LastTryModel), now.Sub(ch.LastTryKey))
+	}
+	winner, _ := s.Select(channels)
+	return scores, winner
+}
+
+// score 按各维度距最近尝试的时长计算加权分数
+func (s *lruSelector) score(agePlatform, ageModel, ageKey time.Duration) float64 {
+	return s.platformWeight*agePlatform.Seconds() +
+		s.modelWeight*ageModel.Seconds() +
+		s.keyWeight*ageKey.Seconds()
+}
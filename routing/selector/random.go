@@ -46,3 +46,19 @@ func (s *randomSelector) Select(channels []ChannelInfo) (string, error) {
 func (s *randomSelector) Name() string {
 	return "Random"
 }
+
+// Score 返回各通道被选中的概率（均为 1/n），选择结果完全随机，不返回选中通道
+func (s *randomSelector) Score(_ Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+
+	scores := make([]float64, len(channels))
+	for i := range scores {
+		scores[i] = 1 / float64(len(channels))
+	}
+	if len(channels) == 1 {
+		return scores, channels[0].ID
+	}
+	return scores, ""
+}
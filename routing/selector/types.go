@@ -11,6 +11,35 @@ type ChannelInfo struct {
 	LastTryPlatform time.Time // 平台最近尝试时间
 	LastTryModel    time.Time // 模型最近尝试时间
 	LastTryKey      time.Time // 密钥最近尝试时间
+	PlatformWeight  int       // 平台权重（<= 0 时视为 1）
+	ModelWeight     int       // 模型权重（<= 0 时视为 1）
+	KeyWeight       int       // 密钥权重（<= 0 时视为 1）
+
+	LatencyEWMA   time.Duration // 总耗时峰值 EWMA（0 表示尚无样本）
+	FirstByteEWMA time.Duration // 首字耗时峰值 EWMA（0 表示尚无样本）
+
+	InFlightPlatform int64 // 平台在途请求数
+	InFlightModel    int64 // 模型在途请求数
+	InFlightKey      int64 // 密钥在途请求数
+
+	Priced      bool    // 是否配置了价格
+	InputPrice  float64 // 每百万输入 Token 价格
+	OutputPrice float64 // 每百万输出 Token 价格
+}
+
+// EffectiveWeight 返回通道的综合权重
+//
+// 综合权重为平台、模型、密钥三个维度权重的乘积，未配置（<= 0）的维度按 1 计算。
+func (c ChannelInfo) EffectiveWeight() int {
+	return normalizeWeight(c.PlatformWeight) * normalizeWeight(c.ModelWeight) * normalizeWeight(c.KeyWeight)
+}
+
+// normalizeWeight 将未配置或非法的权重归一为 1
+func normalizeWeight(weight int) int {
+	if weight <= 0 {
+		return 1
+	}
+	return weight
 }
 
 // Selector 定义了通道选择器接口
@@ -23,6 +52,35 @@ type Selector interface {
 	Name() string
 }
 
+// Request 表示单次通道选择的请求上下文
+type Request struct {
+	AffinityKey  string // 会话亲和键（为空表示无亲和要求）
+	PromptTokens int    // 预估提示词 Token 数（0 表示未知）
+	OutputTokens int    // 最大输出 Token 数（0 表示未知）
+}
+
+// RequestAwareSelector 定义可感知请求上下文的选择器接口
+//
+// 路由在选择器实现该接口时优先调用 SelectForRequest，否则回退到 Select。
+type RequestAwareSelector interface {
+	Selector
+
+	// SelectForRequest 结合请求上下文从给定的通道列表中选择一个通道
+	SelectForRequest(req Request, channels []ChannelInfo) (string, error)
+}
+
+// Scorer 定义可解释的选择器接口
+//
+// 用于路由解释（dry-run）：在不修改选择器内部状态的前提下，
+// 给出每个候选通道的得分以及当前状态下将被选中的通道。
+type Scorer interface {
+	// Score 返回与 channels 一一对应的得分（越高越优先）及将被选中的通道 ID
+	//
+	// 选择结果带随机性时，得分为各通道被选中的概率，返回被选中概率最高的通道；
+	// 所有通道概率相同时返回空字符串。
+	Score(req Request, channels []ChannelInfo) ([]float64, string)
+}
+
 // SelectorType 定义选择器类型
 type SelectorType string
 
@@ -32,6 +90,21 @@ const (
 
 	// LRUSelector 多维 LRU 选择器
 	LRUSelector SelectorType = "multi_dim_lru"
+
+	// WeightedSelector 平滑加权轮询选择器
+	WeightedSelector SelectorType = "weighted_round_robin"
+
+	// EWMASelector 基于峰值 EWMA 延迟的二选一（Power of Two Choices）选择器
+	EWMASelector SelectorType = "peak_ewma"
+
+	// LeastInFlightSelector 最少在途请求选择器
+	LeastInFlightSelector SelectorType = "least_inflight"
+
+	// ConsistentHashSelector 基于会话亲和键的一致性哈希（Rendezvous）选择器
+	ConsistentHashSelector SelectorType = "consistent_hash"
+
+	// CostSelector 在延迟容忍范围内选择预估成本最低的通道
+	CostSelector SelectorType = "lowest_cost"
 )
 
 // SelectorFactory 选择器工厂函数类型
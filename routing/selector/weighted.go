@@ -0,0 +1,126 @@
+package selector
+
+import (
+	"sync"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+func init() {
+	Register(WeightedSelector, NewWeightedSelector)
+}
+
+// weightedStaleRounds 通道连续未出现在候选列表中的选择次数达到该值后，其 currentWeight 被清理
+const weightedStaleRounds = 1024
+
+// weightedSelector 实现平滑加权轮询（Smooth Weighted Round-Robin）调度策略
+//
+// 算法与 nginx upstream 的加权轮询一致：
+//  1. 每轮为每个候选通道累加其综合权重到 currentWeight
+//  2. 选择 currentWeight 最大的通道
+//  3. 被选中通道的 currentWeight 减去本轮候选通道的总权重
+//
+// 该算法在保证长期比例与权重一致的同时，避免同一通道被连续集中选择。
+// 选择结果只依赖调用序列与候选集合，不引入随机性，便于测试。
+type weightedSelector struct {
+	mu            sync.Mutex
+	currentWeight map[string]*weightedEntry // 通道 ID -> 当前权重
+	rounds        uint64                    // 已执行的选择次数
+}
+
+// weightedEntry 通道的轮询状态
+type weightedEntry struct {
+	current  int
+	lastSeen uint64 // 最近一次出现在候选列表中的选择序号
+}
+
+// NewWeightedSelector 创建一个新的平滑加权轮询选择器实例
+func NewWeightedSelector() Selector {
+	return &weightedSelector{
+		currentWeight: make(map[string]*weightedEntry),
+	}
+}
+
+// Select 从给定的通道列表中按权重比例选择通道
+//
+// 平局处理：currentWeight 相同时选择 ID 较小的通道，保证结果确定。
+// 暂时不在候选列表中的通道（如退避中，或属于其他模型）保留其 currentWeight，恢复后继续参与轮询；
+// 连续 weightedStaleRounds 次选择未出现的通道（如已删除）会被清理，避免状态无限增长。
+func (s *weightedSelector) Select(channels []ChannelInfo) (string, error) {
+	if len(channels) == 0 {
+		return "", errors.New(errors.ErrCodeInvalidArgument, "通道列表不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rounds++
+	totalWeight := 0
+	var best *weightedEntry
+	bestID := ""
+	for i, ch := range channels {
+		weight := ch.EffectiveWeight()
+		totalWeight += weight
+
+		entry, ok := s.currentWeight[ch.ID]
+		if !ok {
+			entry = &weightedEntry{}
+			s.currentWeight[ch.ID] = entry
+		}
+		entry.current += weight
+		entry.lastSeen = s.rounds
+
+		if i == 0 || entry.current > best.current || (entry.current == best.current && ch.ID < bestID) {
+			best = entry
+			bestID = ch.ID
+		}
+	}
+
+	best.current -= totalWeight
+	if s.rounds%weightedStaleRounds == 0 {
+		s.prune()
+	}
+	return bestID, nil
+}
+
+// prune 清理连续 weightedStaleRounds 次选择未出现在候选列表中的通道
+func (s *weightedSelector) prune() {
+	for id, entry := range s.currentWeight {
+		if s.rounds-entry.lastSeen >= weightedStaleRounds {
+			delete(s.currentWeight, id)
+		}
+	}
+}
+
+// Name 返回选择器的名称
+func (s *weightedSelector) Name() string {
+	return "WeightedRoundRobin"
+}
+
+// Score 返回本轮累加后各通道的 currentWeight，不修改选择器状态
+//
+// 得分最高（平局时 ID 较小）的通道即下一次 Select 将选中的通道。
+func (s *weightedSelector) Score(_ Request, channels []ChannelInfo) ([]float64, string) {
+	if len(channels) == 0 {
+		return nil, ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores := make([]float64, len(channels))
+	bestID := ""
+	bestWeight := 0
+	for i, ch := range channels {
+		current := ch.EffectiveWeight()
+		if entry, ok := s.currentWeight[ch.ID]; ok {
+			current += entry.current
+		}
+		scores[i] = float64(current)
+		if i == 0 || current > bestWeight || (current == bestWeight && ch.ID < bestID) {
+			bestID = ch.ID
+			bestWeight = current
+		}
+	}
+	return scores, bestID
+}
@@ -0,0 +1,116 @@
+package selector
+
+import "testing"
+
+func TestWeightedSelector_SmoothDistribution(t *testing.T) {
+	s := NewWeightedSelector()
+	channels := []ChannelInfo{
+		{ID: "a", KeyWeight: 5},
+		{ID: "b", KeyWeight: 1},
+		{ID: "c", KeyWeight: 1},
+	}
+
+	// nginx 平滑加权轮询的经典序列：a a b a c a a
+	expected := []string{"a", "a", "b", "a", "c", "a", "a"}
+	for i, want := range expected {
+		got, err := s.Select(channels)
+		if err != nil {
+			t.Fatalf("第 %d 次选择失败: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("第 %d 次选择期望 %q，实际 %q", i, want, got)
+		}
+	}
+}
+
+func TestWeightedSelector_CombinesDimensionWeights(t *testing.T) {
+	s := NewWeightedSelector()
+	channels := []ChannelInfo{
+		{ID: "contract", PlatformWeight: 2, KeyWeight: 4},
+		{ID: "payg", PlatformWeight: 2, KeyWeight: 1},
+		{ID: "unset"},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		id, err := s.Select(channels)
+		if err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+		counts[id]++
+	}
+
+	// 综合权重 8:2:1，共 11，100 次选择中比例应严格贴近权重
+	if counts["contract"] < 72 || counts["contract"] > 73 {
+		t.Fatalf("contract 选择次数不符合权重，actual=%d", counts["contract"])
+	}
+	if counts["payg"] < 18 || counts["payg"] > 19 {
+		t.Fatalf("payg 选择次数不符合权重，actual=%d", counts["payg"])
+	}
+	if counts["unset"] < 9 || counts["unset"] > 10 {
+		t.Fatalf("unset 选择次数不符合权重，actual=%d", counts["unset"])
+	}
+}
+
+func TestWeightedSelector_EmptyChannels(t *testing.T) {
+	s := NewWeightedSelector()
+	if _, err := s.Select(nil); err == nil {
+		t.Fatalf("空通道列表应返回错误")
+	}
+}
+
+func TestWeightedSelector_ScoreDoesNotAdvanceState(t *testing.T) {
+	s := NewWeightedSelector()
+	channels := []ChannelInfo{
+		{ID: "a", PlatformWeight: 2},
+		{ID: "b"},
+	}
+
+	scorer := s.(Scorer)
+	for i := 0; i < 3; i++ {
+		scores, winner := scorer.Score(Request{}, channels)
+		if winner != "a" || scores[0] != 2 || scores[1] != 1 {
+			t.Fatalf("评分不应推进轮询状态，scores=%v winner=%s", scores, winner)
+		}
+	}
+
+	if id, _ := s.Select(channels); id != "a" {
+		t.Fatalf("Select 结果应与评分一致，actual=%s", id)
+	}
+	if _, winner := scorer.Score(Request{}, channels); winner != "b" {
+		t.Fatalf("Select 后评分应反映新的轮询状态，actual=%s", winner)
+	}
+}
+
+func TestWeightedSelector_PrunesStaleChannels(t *testing.T) {
+	s := NewWeightedSelector().(*weightedSelector)
+	old := []ChannelInfo{{ID: "a", KeyWeight: 3}, {ID: "b", KeyWeight: 1}}
+	current := []ChannelInfo{{ID: "c", KeyWeight: 1}, {ID: "d", KeyWeight: 1}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Select(old); err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+	}
+	before := s.currentWeight["a"].current
+
+	// 短暂离开候选列表的通道保留轮询状态
+	if _, err := s.Select(current); err != nil {
+		t.Fatalf("选择失败: %v", err)
+	}
+	if entry, ok := s.currentWeight["a"]; !ok || entry.current != before {
+		t.Fatal("暂时不在候选列表中的通道应保留 currentWeight")
+	}
+
+	for i := 0; i < 2*weightedStaleRounds; i++ {
+		if _, err := s.Select(current); err != nil {
+			t.Fatalf("选择失败: %v", err)
+		}
+	}
+	if len(s.currentWeight) != 2 {
+		t.Fatalf("长期不在候选列表中的通道应被清理，actual=%d", len(s.currentWeight))
+	}
+	if _, ok := s.currentWeight["a"]; ok {
+		t.Fatal("已清理的通道不应保留 currentWeight")
+	}
+}
@@ -0,0 +1,197 @@
+package routing
+
+import (
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/MeowSalty/portal/errors"
+)
+
+// DefaultSplitArm 未落入任何分组的流量所属分组的名称
+const DefaultSplitArm = "default"
+
+// splitBuckets 分流桶数量，百分比精度为 0.01%
+const splitBuckets = 10000
+
+// TrafficSplit 模型级流量切分规则
+//
+// 在选择器之前按请求键将流量确定性地分配到各分组：
+// 每个分组按 Percent 占据一段桶区间，剩余流量落入 DefaultSplitArm 分组，
+// 该分组由不属于任何分组的平台/端点组成。例如灰度上线新平台时，
+// 配置一个仅包含新平台、Percent 为 5 的分组即可。
+type TrafficSplit struct {
+	// Model 规则作用的模型名称，匹配请求的模型名称或解析后的模型名称
+	Model string
+
+	// Arms 分组列表，Percent 之和不能超过 100
+	Arms []SplitArm
+}
+
+// SplitArm 流量切分分组
+//
+// 通道所属平台在 PlatformIDs 中或端点在 EndpointIDs 中即属于该分组；
+// 同一通道属于多个分组时以靠前的分组为准。
+type SplitArm struct {
+	Name        string  // 分组名称，记录在请求日志的 SplitArm 字段
+	Percent     float64 // 流量百分比（0, 100]
+	PlatformIDs []uint  // 分组包含的平台
+	EndpointIDs []uint  // 分组包含的端点
+}
+
+// Validate 校验流量切分规则
+func (s TrafficSplit) Validate() error {
+	if s.Model == "" {
+		return errors.New(errors.ErrCodeConfigInvalid, "流量切分规则的模型名称不能为空")
+	}
+	if len(s.Arms) == 0 {
+		return errors.New(errors.ErrCodeConfigInvalid, "流量切分规则至少需要一个分组").
+			WithContext("model", s.Model)
+	}
+
+	total := 0.0
+	names := make(map[string]struct{}, len(s.Arms))
+	for _, arm := range s.Arms {
+		if arm.Name == "" || arm.Name == DefaultSplitArm {
+			return errors.New(errors.ErrCodeConfigInvalid, "分组名称不能为空且不能使用保留名称").
+				WithContext("model", s.Model).
+				WithContext("arm", arm.Name)
+		}
+		if _, ok := names[arm.Name]; ok {
+			return errors.New(errors.ErrCodeConfigInvalid, "分组名称重复").
+				WithContext("model", s.Model).
+				WithContext("arm", arm.Name)
+		}
+		names[arm.Name] = struct{}{}
+
+		if arm.Percent <= 0 || arm.Percent > 100 {
+			return errors.New(errors.ErrCodeConfigInvalid, "分组流量百分比必须在 (0, 100] 范围内").
+				WithContext("model", s.Model).
+				WithContext("arm", arm.Name).
+				WithContext("percent", arm.Percent)
+		}
+		if len(arm.PlatformIDs) == 0 && len(arm.EndpointIDs) == 0 {
+			return errors.New(errors.ErrCodeConfigInvalid, "分组至少需要包含一个平台或端点").
+				WithContext("model", s.Model).
+				WithContext("arm", arm.Name)
+		}
+		total += arm.Percent
+	}
+	if total > 100 {
+		return errors.New(errors.ErrCodeConfigInvalid, "分组流量百分比之和不能超过 100").
+			WithContext("model", s.Model).
+			WithContext("total_percent", total)
+	}
+	return nil
+}
+
+// assign 根据请求键返回分配的分组名称，键为空时随机分配
+func (s TrafficSplit) assign(key string) string {
+	var bucket int
+	if key == "" {
+		bucket = rand.Intn(splitBuckets)
+	} else {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s.Model))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(key))
+		bucket = int(h.Sum64() % splitBuckets)
+	}
+
+	upper := 0.0
+	for _, arm := range s.Arms {
+		upper += arm.Percent * splitBuckets / 100
+		if float64(bucket) < upper {
+			return arm.Name
+		}
+	}
+	return DefaultSplitArm
+}
+
+// armOf 返回通道所属的分组名称
+func (s TrafficSplit) armOf(ch *Channel) string {
+	for _, arm := range s.Arms {
+		for _, id := range arm.PlatformIDs {
+			if id == ch.PlatformID {
+				return arm.Name
+			}
+		}
+		for _, id := range arm.EndpointIDs {
+			if id == ch.endpointID {
+				return arm.Name
+			}
+		}
+	}
+	return DefaultSplitArm
+}
+
+// splitAssignment 单次选择中分配到的分组
+type splitAssignment struct {
+	split TrafficSplit
+	arm   string
+}
+
+// allows 判断通道是否属于分配到的分组
+func (a *splitAssignment) allows(ch *Channel) bool {
+	return a == nil || a.split.armOf(ch) == a.arm
+}
+
+// validateTrafficSplits 校验流量切分规则列表
+func validateTrafficSplits(splits []TrafficSplit) error {
+	models := make(map[string]struct{}, len(splits))
+	for _, split := range splits {
+		if err := split.Validate(); err != nil {
+			return err
+		}
+		if _, ok := models[split.Model]; ok {
+			return errors.New(errors.ErrCodeConfigInvalid, "同一模型只能配置一条流量切分规则").
+				WithContext("model", split.Model)
+		}
+		models[split.Model] = struct{}{}
+	}
+	return nil
+}
+
+// SetTrafficSplits 在运行时替换流量切分规则，nil 表示关闭流量切分
+func (r *Routing) SetTrafficSplits(splits []TrafficSplit) error {
+	if err := validateTrafficSplits(splits); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.splits = append([]TrafficSplit(nil), splits...)
+	r.mu.Unlock()
+	return nil
+}
+
+// assignSplit 查找请求命中的流量切分规则并分配分组，未命中时返回 nil
+//
+// 规则依次匹配请求的模型名称与解析后的模型名称。分流键优先使用会话亲和键，
+// 使同一会话稳定落在同一分组；否则使用 WithSplitKey 指定的请求键。
+func (r *Routing) assignSplit(modelsWithEndpoint []ModelWithEndpoint, options *selectOptions) *splitAssignment {
+	r.mu.Lock()
+	splits := r.splits
+	r.mu.Unlock()
+	if len(splits) == 0 {
+		return nil
+	}
+
+	for _, split := range splits {
+		matched := split.Model == options.model
+		for _, mwe := range modelsWithEndpoint {
+			if matched {
+				break
+			}
+			matched = split.Model == mwe.Model.Name
+		}
+		if !matched {
+			continue
+		}
+
+		key := options.affinityKey
+		if key == "" {
+			key = options.splitKey
+		}
+		return &splitAssignment{split: split, arm: split.assign(key)}
+	}
+	return nil
+}
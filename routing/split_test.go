@@ -0,0 +1,117 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/MeowSalty/portal/errors"
+	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
+)
+
+func splitTestModels() []ModelWithEndpoint {
+	return []ModelWithEndpoint{
+		{
+			Platform: Platform{ID: 1},
+			Model:    Model{ID: 10, Name: "gpt-4o", APIKeys: []APIKey{{ID: 100}}},
+			Endpoint: Endpoint{ID: 1000, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+		{
+			Platform: Platform{ID: 2},
+			Model:    Model{ID: 20, Name: "gpt-4o", APIKeys: []APIKey{{ID: 200}}},
+			Endpoint: Endpoint{ID: 2000, EndpointType: "openai", EndpointVariant: "chat_completions"},
+		},
+	}
+}
+
+func TestTrafficSplit_CanaryPercentageAndStickiness(t *testing.T) {
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), splitTestModels())
+	markAvailable(storage, health.ResourceTypePlatform, 1, 2)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+
+	if err := r.SetTrafficSplits([]TrafficSplit{{
+		Model: "gpt-4o",
+		Arms:  []SplitArm{{Name: "canary", Percent: 10, PlatformIDs: []uint{2}}},
+	}}); err != nil {
+		t.Fatalf("设置流量切分规则失败: %v", err)
+	}
+
+	const requests = 2000
+	canary := 0
+	for i := 0; i < requests; i++ {
+		key := fmt.Sprintf("req-%d", i)
+		ch, err := r.GetChannel(context.Background(), "gpt-4o", WithSplitKey(key))
+		if err != nil {
+			t.Fatalf("获取通道失败: %v", err)
+		}
+		ch.Release()
+
+		expectedPlatform := uint(1)
+		if ch.SplitArm == "canary" {
+			canary++
+			expectedPlatform = 2
+		} else if ch.SplitArm != DefaultSplitArm {
+			t.Fatalf("未知分组: %q", ch.SplitArm)
+		}
+		if ch.PlatformID != expectedPlatform {
+			t.Fatalf("分组 %s 选中了不属于该分组的平台 %d", ch.SplitArm, ch.PlatformID)
+		}
+
+		retry, err := r.GetChannel(context.Background(), "gpt-4o", WithSplitKey(key))
+		if err != nil {
+			t.Fatalf("获取通道失败: %v", err)
+		}
+		retry.Release()
+		if retry.SplitArm != ch.SplitArm {
+			t.Fatalf("相同请求键应分配到同一分组，first=%s retry=%s", ch.SplitArm, retry.SplitArm)
+		}
+	}
+
+	if ratio := float64(canary) / requests; ratio < 0.07 || ratio > 0.13 {
+		t.Fatalf("灰度分组流量比例应接近 10%%，actual=%.3f", ratio)
+	}
+}
+
+func TestTrafficSplit_FallsBackWhenArmUnavailable(t *testing.T) {
+	r, storage := newTestRouting(t, selector.NewLRUSelector(), splitTestModels())
+	markAvailable(storage, health.ResourceTypePlatform, 1)
+	markAvailable(storage, health.ResourceTypeModel, 10, 20)
+	markAvailable(storage, health.ResourceTypeAPIKey, 100, 200)
+	_ = storage.Set(&health.Health{ResourceType: health.ResourceTypePlatform, ResourceID: 2, Status: health.HealthStatusUnavailable})
+
+	if err := r.SetTrafficSplits([]TrafficSplit{{
+		Model: "gpt-4o",
+		Arms:  []SplitArm{{Name: "canary", Percent: 100, EndpointIDs: []uint{2000}}},
+	}}); err != nil {
+		t.Fatalf("设置流量切分规则失败: %v", err)
+	}
+
+	ch, err := r.GetChannel(context.Background(), "gpt-4o", WithSplitKey("req"))
+	if err != nil {
+		t.Fatalf("分组内无可用通道时应回退到全部通道: %v", err)
+	}
+	defer ch.Release()
+	if ch.PlatformID != 1 || ch.SplitArm != DefaultSplitArm {
+		t.Fatalf("回退后应记录通道实际所属分组，platform=%d arm=%s", ch.PlatformID, ch.SplitArm)
+	}
+}
+
+func TestTrafficSplit_Validate(t *testing.T) {
+	invalid := [][]TrafficSplit{
+		{{Model: "", Arms: []SplitArm{{Name: "a", Percent: 5, PlatformIDs: []uint{1}}}}},
+		{{Model: "m", Arms: []SplitArm{{Name: DefaultSplitArm, Percent: 5, PlatformIDs: []uint{1}}}}},
+		{{Model: "m", Arms: []SplitArm{{Name: "a", Percent: 60, PlatformIDs: []uint{1}}, {Name: "b", Percent: 50, PlatformIDs: []uint{2}}}}},
+		{{Model: "m", Arms: []SplitArm{{Name: "a", Percent: 5}}}},
+		{
+			{Model: "m", Arms: []SplitArm{{Name: "a", Percent: 5, PlatformIDs: []uint{1}}}},
+			{Model: "m", Arms: []SplitArm{{Name: "b", Percent: 5, PlatformIDs: []uint{2}}}},
+		},
+	}
+	for i, splits := range invalid {
+		if err := validateTrafficSplits(splits); !errors.IsCode(err, errors.ErrCodeConfigInvalid) {
+			t.Fatalf("用例 %d 应校验失败，actual=%v", i, err)
+		}
+	}
+}
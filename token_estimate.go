@@ -0,0 +1,131 @@
+package portal
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+)
+
+const (
+	// messageOverheadTokens 每条消息的角色与分隔符开销
+	messageOverheadTokens = 4
+
+	// imageTokens 单张图像的估算 Token 数（高精度/自动）
+	imageTokens = 765
+
+	// lowDetailImageTokens 单张低精度图像的估算 Token 数
+	lowDetailImageTokens = 85
+
+	// toolOverheadTokens 每个工具定义的固定开销
+	toolOverheadTokens = 8
+)
+
+// TokenEstimator 估算 Contract 请求的提示词 Token 数
+//
+// 用于上下文窗口路由：返回值与请求的 MaxOutputTokens 之和超过模型上下文窗口的通道会被跳过。
+// 返回 <= 0 表示不估算。
+type TokenEstimator func(req *types.RequestContract) int
+
+// EstimatePromptTokens 在本地粗略估算请求的提示词 Token 数
+//
+// 覆盖消息、系统指令、工具定义与图像：ASCII 文本按每 4 字节 1 个 Token 计算，
+// 其他字符（如中日韩文字）按每字符 1 个 Token 计算；图像按固定开销计算。
+// 估算结果偏保守，仅用于路由判断，不代替供应商返回的用量。
+func EstimatePromptTokens(req *types.RequestContract) int {
+	if req == nil {
+		return 0
+	}
+
+	tokens := 0
+	if req.Prompt != nil {
+		tokens += estimateTextTokens(*req.Prompt)
+	}
+	if req.System != nil {
+		tokens += messageOverheadTokens
+		if req.System.Text != nil {
+			tokens += estimateTextTokens(*req.System.Text)
+		}
+		tokens += estimatePartsTokens(req.System.Parts)
+	}
+	for _, msg := range req.Messages {
+		tokens += messageOverheadTokens
+		if msg.Content.Text != nil {
+			tokens += estimateTextTokens(*msg.Content.Text)
+		}
+		tokens += estimatePartsTokens(msg.Content.Parts)
+		for _, call := range msg.ToolCalls {
+			tokens += estimateToolCallTokens(&call)
+		}
+	}
+	for _, tool := range req.Tools {
+		tokens += toolOverheadTokens + estimateJSONTokens(tool.Function) + estimateJSONTokens(tool.Config)
+	}
+	return tokens
+}
+
+// estimatePartsTokens 估算内容片段的 Token 数
+func estimatePartsTokens(parts []types.ContentPart) int {
+	tokens := 0
+	for _, part := range parts {
+		switch {
+		case part.Text != nil:
+			tokens += estimateTextTokens(*part.Text)
+		case part.Image != nil:
+			if part.Image.Detail != nil && strings.EqualFold(*part.Image.Detail, "low") {
+				tokens += lowDetailImageTokens
+			} else {
+				tokens += imageTokens
+			}
+		case part.ToolCall != nil:
+			tokens += estimateToolCallTokens(part.ToolCall)
+		case part.ToolResult != nil:
+			if part.ToolResult.Content != nil {
+				tokens += estimateTextTokens(*part.ToolResult.Content)
+			}
+			tokens += estimateJSONTokens(part.ToolResult.Payload)
+		}
+	}
+	return tokens
+}
+
+// estimateToolCallTokens 估算工具调用的 Token 数
+func estimateToolCallTokens(call *types.ToolCall) int {
+	tokens := 0
+	if call.Name != nil {
+		tokens += estimateTextTokens(*call.Name)
+	}
+	if call.Arguments != nil {
+		tokens += estimateTextTokens(*call.Arguments)
+	}
+	return tokens + estimateJSONTokens(call.Payload)
+}
+
+// estimateJSONTokens 按序列化后的 JSON 文本估算 Token 数
+func estimateJSONTokens(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return 0
+	}
+	return estimateTextTokens(string(data))
+}
+
+// estimateTextTokens 估算文本的 Token 数
+func estimateTextTokens(text string) int {
+	asciiBytes, otherRunes := 0, 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			asciiBytes++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		otherRunes++
+		i += size
+	}
+	return (asciiBytes+3)/4 + otherRunes
+}
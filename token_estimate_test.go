@@ -0,0 +1,41 @@
+package portal
+
+import (
+	"testing"
+
+	"github.com/MeowSalty/portal/request/adapter/types"
+)
+
+func TestEstimatePromptTokens(t *testing.T) {
+	text := "hello world!"
+	chinese := "你好世界"
+	low := "low"
+	url := "https://example.com/cat.png"
+
+	req := &types.RequestContract{
+		System: &types.System{Text: &text},
+		Messages: []types.Message{
+			{Role: "user", Content: types.Content{Text: &chinese}},
+			{Role: "user", Content: types.Content{Parts: []types.ContentPart{
+				{Type: "image", Image: &types.Image{URL: &url, Detail: &low}},
+				{Type: "image", Image: &types.Image{URL: &url}},
+			}}},
+		},
+	}
+	// 系统：4 + 3；中文消息：4 + 4；图像消息：4 + 85 + 765
+	if got := EstimatePromptTokens(req); got != 869 {
+		t.Fatalf("Token 估算错误，actual=%d", got)
+	}
+
+	withTools := &types.RequestContract{
+		Messages: req.Messages[:1],
+		Tools:    []types.Tool{{Type: "function", Function: &types.Function{Name: "lookup"}}},
+	}
+	if got := EstimatePromptTokens(withTools); got <= 8+toolOverheadTokens {
+		t.Fatalf("工具定义应计入估算，actual=%d", got)
+	}
+
+	if got := EstimatePromptTokens(nil); got != 0 {
+		t.Fatalf("空请求应返回 0，actual=%d", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/MeowSalty/portal/request"
 	"github.com/MeowSalty/portal/routing"
 	"github.com/MeowSalty/portal/routing/health"
+	"github.com/MeowSalty/portal/routing/selector"
 	"github.com/MeowSalty/portal/session"
 )
 
@@ -16,6 +17,14 @@ type Portal struct {
 	request    *request.Request
 	logger     logger.Logger
 	middleware *middleware.Chain
+
+	affinityKey      AffinityKeyFunc      // 会话亲和键提取函数
+	labelConstraints LabelConstraintsFunc // 标签约束提取函数
+	tokenEstimator   TokenEstimator       // 提示词 Token 估算函数
+	modelFallbacks   ModelFallbacks       // 跨模型回退链
+	retryPolicy      RetryPolicy          // 默认重试策略
+
+	prober *health.Prober // 后台主动健康探测器（未启用时为 nil）
 }
 
 // Config 是 Portal 的配置结构体
@@ -23,8 +32,53 @@ type Config struct {
 	PlatformRepo  routing.PlatformRepository
 	ModelRepo     routing.ModelRepository
 	KeyRepo       routing.KeyRepository
-	HealthStorage health.Storage // 健康状态存储
+	HealthStorage health.Storage // 健康状态存储，为 nil 时使用不过期的内存存储（health.NewMemoryStorage）
 	LogRepo       request.RequestLogRepository
 	Logger        logger.Logger           // 可选的日志记录器，如果为 nil 则使用默认的空操作日志记录器
 	Middlewares   []middleware.Middleware // 可选的中间件列表
+
+	// AffinityKey 可选的会话亲和键提取函数，为 nil 时使用 DefaultAffinityKey()。
+	// 仅在使用一致性哈希等请求感知的选择器时生效。
+	AffinityKey AffinityKeyFunc
+
+	// LabelConstraints 可选的标签约束提取函数，为 nil 时使用 DefaultLabelConstraints()。
+	// 请求只会被路由到标签满足约束的通道（平台/端点的 Labels），用于数据驻留与合规路由。
+	LabelConstraints LabelConstraintsFunc
+
+	// TokenEstimator 可选的提示词 Token 估算函数，为 nil 时使用 EstimatePromptTokens。
+	// 估算结果与请求的 MaxOutputTokens 之和超过模型上下文窗口（Model.MaxContextTokens）的通道会被跳过。
+	TokenEstimator TokenEstimator
+
+	// ModelResolver 可选的模型名称解析器，用于将别名、通配名称解析为上游模型名称。
+	// 为 nil 时按请求的原始模型名称查询。
+	ModelResolver routing.ModelResolver
+
+	// ModelFallbacks 可选的跨模型回退链，请求模型没有可用通道时按顺序尝试回退模型。
+	// 仅作用于统一格式（Contract）路径和兼容模式路径。
+	ModelFallbacks ModelFallbacks
+
+	// RetryPolicy 可选的重试策略，为 nil 时使用 DefaultRetryPolicy()。
+	// 单次调用可通过 WithRetryPolicy(ctx, policy) 覆盖。
+	RetryPolicy *RetryPolicy
+
+	// Cache 可选的路由缓存配置，零值表示不缓存。
+	// 管理端修改平台、模型或密钥后可调用 Portal.Invalidate 使修改立即生效。
+	Cache routing.CacheConfig
+
+	// Selector 可选的通道选择器实例，优先于 SelectorType。
+	// Selector 与 SelectorType 均为空时使用多维 LRU 选择器。
+	Selector selector.Selector
+
+	// SelectorType 可选的选择器类型，通过 selector.Create 创建（须已注册）。
+	SelectorType selector.SelectorType
+
+	// Health 可选的健康判定配置（退避策略、探测开关、失败阈值与失败判定器等），可通过 Portal.SetHealthConfig 在运行时调整。
+	Health routing.HealthConfig
+
+	// HealthProber 可选的后台主动健康探测配置，为 nil 时不启用
+	HealthProber *HealthProberConfig
+
+	// TrafficSplits 可选的模型级流量切分规则（灰度/金丝雀），可通过 Portal.SetTrafficSplits 在运行时调整。
+	// 选中通道所属的分组记录在请求日志的 SplitArm 字段。
+	TrafficSplits []routing.TrafficSplit
 }